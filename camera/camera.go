@@ -16,29 +16,29 @@ type Camera struct {
 	Fov float64
 
 	// World position of the camera
-	Position *vmath.Vector3
+	Position vmath.Vec3
 
 	// Point where the camera is looking at
-	LookAt *vmath.Vector3
+	LookAt vmath.Vec3
 
 	// Up direction to calculate the camera look direction
-	Up *vmath.Vector3
+	Up vmath.Vec3
 
 	// The Lower left corner of the camera relative to the center considering the vertical and horizontal sizes.
 	// Calculated by the UpdateViewport method.
-	LowerLeftCorner *vmath.Vector3
+	LowerLeftCorner vmath.Vec3
 
 	// Vertical size of the camera (usually only uses Y).
 	// Calculated by the UpdateViewport method.
-	Vertical *vmath.Vector3
+	Vertical vmath.Vec3
 
 	// Horizontal size of the camera (usually only uses X).
 	// Calculated by the UpdateViewport method.
-	Horizontal *vmath.Vector3
+	Horizontal vmath.Vec3
 }
 
 // Create camera from bouding box
-func NewCamera(bounds pixel.Rect, position *vmath.Vector3, lookAt *vmath.Vector3, up *vmath.Vector3, fov float64) *Camera {
+func NewCamera(bounds pixel.Rect, position vmath.Vec3, lookAt vmath.Vec3, up vmath.Vec3, fov float64) *Camera {
 	var c = new(Camera)
 	var size = bounds.Size()
 
@@ -59,9 +59,9 @@ func NewCameraBounds(bounds pixel.Rect) *Camera {
 
 	c.Fov = 70
 	c.AspectRatio = size.X / size.Y
-	c.Position = vmath.NewVector3(-2.0, 2.0, 1.0)
-	c.LookAt = vmath.NewVector3(0.0, 0.0, -1.0)
-	c.Up = vmath.NewVector3(0.0, 1.0, 0.0)
+	c.Position = vmath.NewVec3(-2.0, 2.0, 1.0)
+	c.LookAt = vmath.NewVec3(0.0, 0.0, -1.0)
+	c.Up = vmath.NewVec3(0.0, 1.0, 0.0)
 	c.UpdateViewport()
 
 	return c
@@ -75,40 +75,52 @@ func (c *Camera) UpdateViewport() {
 	var halfHeight = math.Tan(fovRad / 2.0)
 	var halfWidth = c.AspectRatio * halfHeight
 
-	var direction = c.Position.Clone()
-	direction.Sub(c.LookAt)
-	var w = direction.UnitVector()
+	var w = c.Position.Sub(c.LookAt).Normalize()
+	var u = c.Up.Cross(w)
+	var v = w.Cross(u)
 
-	var u = vmath.Cross(c.Up, w)
-	var v = vmath.Cross(w, u)
+	u = u.MulScalar(halfWidth)
+	v = v.MulScalar(halfHeight)
 
-	u.MulScalar(halfWidth)
-	v.MulScalar(halfHeight)
+	c.LowerLeftCorner = c.Position.Sub(u).Sub(v).Sub(w)
+	c.Horizontal = u.MulScalar(2.0)
+	c.Vertical = v.MulScalar(2.0)
+}
+
+// Get the orientation of the camera as a rotation from the default orientation (looking at -Z with +Y up).
+func (c *Camera) Rotation() *vmath.Quaternion {
+	var w = c.Position.Sub(c.LookAt).Normalize()
+	var u = c.Up.Cross(w).Normalize()
+	var v = w.Cross(u)
+
+	// Rotation matrix with the camera axes as columns
+	var m = vmath.NewMatrix4([16]float64{
+		u.X, v.X, w.X, 0,
+		u.Y, v.Y, w.Y, 0,
+		u.Z, v.Z, w.Z, 0,
+		0, 0, 0, 1,
+	})
+
+	return vmath.NewQuaternionFromMatrix4(m)
+}
 
-	c.LowerLeftCorner = c.Position.Clone()
-	c.LowerLeftCorner.Sub(u)
-	c.LowerLeftCorner.Sub(v)
-	c.LowerLeftCorner.Sub(w)
+// Set the orientation of the camera from a rotation of the default orientation (looking at -Z with +Y up).
+// The look at point is moved keeping its distance to the camera, UpdateViewport should be called after changing the rotation.
+func (c *Camera) SetRotation(rotation *vmath.Quaternion) {
+	var distance = c.LookAt.Sub(c.Position).Length()
+	if distance == 0.0 {
+		distance = 1.0
+	}
 
-	c.Horizontal = u.Clone()
-	c.Horizontal.MulScalar(2.0)
+	var forward = rotation.RotateVector(vmath.NewVec3(0.0, 0.0, -1.0))
 
-	c.Vertical = v.Clone()
-	c.Vertical.MulScalar(2.0)
+	c.LookAt = c.Position.AddScaled(forward, distance)
+	c.Up = rotation.RotateVector(vmath.NewVec3(0.0, 1.0, 0.0))
 }
 
 // Get a ray from this camera, from a normalized UV screen coordinate.
-func (c *Camera) GetRay(u float64, v float64) *vmath.Ray {
-	var hor = c.Horizontal.Clone()
-	hor.MulScalar(u)
-
-	var vert = c.Vertical.Clone()
-	vert.MulScalar(v)
-
-	var direction = c.LowerLeftCorner.Clone()
-	direction.Add(hor)
-	direction.Add(vert)
-	direction.Sub(c.Position)
+func (c *Camera) GetRay(u float64, v float64) vmath.Ray {
+	var direction = c.LowerLeftCorner.AddScaled(c.Horizontal, u).AddScaled(c.Vertical, v).Sub(c.Position)
 
 	return vmath.NewRay(c.Position, direction)
 }
@@ -117,9 +129,9 @@ func (c *Camera) GetRay(u float64, v float64) *vmath.Ray {
 func (c *Camera) Copy(o *Camera) {
 	c.Fov = o.Fov
 	c.AspectRatio = o.AspectRatio
-	c.Position.Copy(o.Position)
-	c.LookAt.Copy(o.LookAt)
-	c.Up.Copy(o.Up)
+	c.Position = o.Position
+	c.LookAt = o.LookAt
+	c.Up = o.Up
 }
 
 // Clone the camera object
@@ -127,9 +139,9 @@ func (o *Camera) Clone() *Camera {
 	var c = new(Camera)
 	c.Fov = o.Fov
 	c.AspectRatio = o.AspectRatio
-	c.Position = o.Position.Clone()
-	c.LookAt = o.LookAt.Clone()
-	c.Up = o.Up.Clone()
+	c.Position = o.Position
+	c.LookAt = o.LookAt
+	c.Up = o.Up
 	c.UpdateViewport()
 	return c
 }
@@ -3,6 +3,7 @@ package camera
 import "C"
 
 import (
+	"gotracer/sampler"
 	"gotracer/vmath"
 	"math"
 
@@ -22,13 +23,13 @@ type CameraDefocus struct {
 	// Distance to be in perfect focus of the camera.
 	FocusDistance float64
 
-	U *vmath.Vector3
-	V *vmath.Vector3
-	W *vmath.Vector3
+	U vmath.Vec3
+	V vmath.Vec3
+	W vmath.Vec3
 }
 
 // Create camera from bouding box
-func NewCameraDefocus(bounds pixel.Rect, position *vmath.Vector3, lookAt *vmath.Vector3, up *vmath.Vector3, fov float64, aperture float64, focusDistance float64) *CameraDefocus {
+func NewCameraDefocus(bounds pixel.Rect, position vmath.Vec3, lookAt vmath.Vec3, up vmath.Vec3, fov float64, aperture float64, focusDistance float64) *CameraDefocus {
 	var c = new(CameraDefocus)
 	var size = bounds.Size()
 
@@ -51,14 +52,12 @@ func NewCameraDefocusBounds(bounds pixel.Rect) *CameraDefocus {
 
 	c.Fov = 90
 	c.AspectRatio = size.X / size.Y
-	c.Position = vmath.NewVector3(-0.15, 0.2, 0.15)
-	c.LookAt = vmath.NewVector3(0.0, 0.0, 0.0)
-	c.Up = vmath.NewVector3(0.0, 1.0, 0.0)
+	c.Position = vmath.NewVec3(-0.15, 0.2, 0.15)
+	c.LookAt = vmath.NewVec3(0.0, 0.0, 0.0)
+	c.Up = vmath.NewVec3(0.0, 1.0, 0.0)
 	c.Aperture = 0.0
 
-	var direction = c.Position.Clone()
-	direction.Sub(c.LookAt)
-	c.FocusDistance = direction.Length()
+	c.FocusDistance = c.Position.Sub(c.LookAt).Length()
 	c.UpdateViewport()
 
 	return c
@@ -71,71 +70,39 @@ func (c *CameraDefocus) UpdateViewport() {
 	var halfHeight = math.Tan(fovRad / 2.0)
 	var halfWidth = c.AspectRatio * halfHeight
 
-	var direction = c.Position.Clone()
-	direction.Sub(c.LookAt)
-
 	c.LensRadius = c.Aperture / 2.0
-	c.W = direction.UnitVector()
-	c.U = vmath.Cross(c.Up, c.W).UnitVector()
-	c.V = vmath.Cross(c.W, c.U)
-
-	var u = c.U.Clone()
-	var v = c.V.Clone()
-	var w = c.W.Clone()
-
-	u.MulScalar(halfWidth * c.FocusDistance)
-	v.MulScalar(halfHeight * c.FocusDistance)
-	w.MulScalar(c.FocusDistance)
+	c.W = c.Position.Sub(c.LookAt).Normalize()
+	c.U = c.Up.Cross(c.W).Normalize()
+	c.V = c.W.Cross(c.U)
 
-	c.LowerLeftCorner = c.Position.Clone()
-	c.LowerLeftCorner.Sub(u)
-	c.LowerLeftCorner.Sub(v)
-	c.LowerLeftCorner.Sub(w)
+	var u = c.U.MulScalar(halfWidth * c.FocusDistance)
+	var v = c.V.MulScalar(halfHeight * c.FocusDistance)
+	var w = c.W.MulScalar(c.FocusDistance)
 
-	c.Horizontal = u.Clone()
-	c.Horizontal.MulScalar(2.0)
-
-	c.Vertical = v.Clone()
-	c.Vertical.MulScalar(2.0)
+	c.LowerLeftCorner = c.Position.Sub(u).Sub(v).Sub(w)
+	c.Horizontal = u.MulScalar(2.0)
+	c.Vertical = v.MulScalar(2.0)
 }
 
 // Get a ray from this camera, from a normalized UV screen coordinate.
-func (c *CameraDefocus) GetRay(u float64, v float64) *vmath.Ray {
-
-	var rd = vmath.RandomInUnitDisk()
-	rd.MulScalar(c.LensRadius)
-
-	var offset = c.U.Clone()
-	offset.MulScalar(rd.X)
-
-	var vclone = c.V.Clone()
-	vclone.MulScalar(rd.Y)
-	offset.Add(vclone)
-
-	var hor = c.Horizontal.Clone()
-	hor.MulScalar(u)
-
-	var vert = c.Vertical.Clone()
-	vert.MulScalar(v)
+// The origin of the ray is sampled in the lens disk using the sampler.
+func (c *CameraDefocus) GetRay(u float64, v float64, sampler sampler.Sampler) vmath.Ray {
 
-	var direction = c.LowerLeftCorner.Clone()
-	direction.Add(hor)
-	direction.Add(vert)
-	direction.Sub(c.Position)
-	direction.Sub(offset)
+	var rd = vmath.RandomInUnitDisk(sampler).MulScalar(c.LensRadius)
+	var offset = c.U.MulScalar(rd.X).AddScaled(c.V, rd.Y)
 
-	offset.Add(c.Position)
+	var direction = c.LowerLeftCorner.AddScaled(c.Horizontal, u).AddScaled(c.Vertical, v).Sub(c.Position).Sub(offset)
 
-	return vmath.NewRay(offset, direction)
+	return vmath.NewRay(c.Position.Add(offset), direction)
 }
 
 // Copy data from another camera object
 func (c *CameraDefocus) Copy(o *CameraDefocus) {
 	c.Fov = o.Fov
 	c.AspectRatio = o.AspectRatio
-	c.Position.Copy(o.Position)
-	c.LookAt.Copy(o.LookAt)
-	c.Up.Copy(o.Up)
+	c.Position = o.Position
+	c.LookAt = o.LookAt
+	c.Up = o.Up
 	c.Aperture = o.Aperture
 	c.FocusDistance = o.FocusDistance
 }
@@ -145,9 +112,9 @@ func (o *CameraDefocus) Clone() *CameraDefocus {
 	var c = new(CameraDefocus)
 	c.Fov = o.Fov
 	c.AspectRatio = o.AspectRatio
-	c.Position = o.Position.Clone()
-	c.LookAt = o.LookAt.Clone()
-	c.Up = o.Up.Clone()
+	c.Position = o.Position
+	c.LookAt = o.LookAt
+	c.Up = o.Up
 	c.Aperture = o.Aperture
 	c.FocusDistance = o.FocusDistance
 	c.UpdateViewport()
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"gotracer/camera"
+	"gotracer/framebuffer"
+	"gotracer/geometry"
+	"gotracer/sampler"
+	"gotracer/scenefile"
+	"gotracer/tonemap"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gopxl/pixel/v2"
+)
+
+// Minimum interval between the progress messages of the render command.
+const ProgressLogInterval = time.Second
+
+// Render subcommand, renders the scene into a image file without creating any window.
+// Accepts the render settings flags (e.g. -width, -height, -samples, -config) in addition to the output flags.
+// The progress is logged while rendering, interrupting the command cancels the render and no image is written.
+//
+// Usage: gotracer render [-scene scene.json] [-width 640] [-height 480] [-samples 32] [-format png|jpeg|ppm] [-quality 90] [-quiet] [-output render.png]
+func RenderCommand(args []string) error {
+	var flags = flag.NewFlagSet("render", flag.ContinueOnError)
+
+	var sceneFile = flags.String("scene", "", "Scene description file to render, the default scene is used if empty.")
+	var output = flags.String("output", "render.png", "Path of the output image file.")
+	var format = flags.String("format", "", "Output format (png, jpeg or ppm), detected from the output file extension if empty.")
+	var quality = flags.Int("quality", 90, "Quality of the JPEG output (1 to 100).")
+	var quiet = flags.Bool("quiet", false, "Do not log the progress of the render.")
+
+	var settings, err = ParseRenderSettings(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*output)), ".")
+	}
+	*format = strings.ToLower(*format)
+
+	if *format != "png" && *format != "jpeg" && *format != "jpg" && *format != "ppm" {
+		return fmt.Errorf("render: unsupported output format %q", *format)
+	}
+
+	if *quality < 1 || *quality > 100 {
+		return fmt.Errorf("render: JPEG quality %d out of range (1 to 100)", *quality)
+	}
+
+	var bounds = pixel.R(0, 0, float64(settings.Width), float64(settings.Height))
+	var scene *geometry.Scene
+	var cam *camera.CameraDefocus
+
+	if *sceneFile != "" {
+		scene, cam, err = scenefile.Load(*sceneFile, bounds)
+		if err != nil {
+			return err
+		}
+	} else {
+		scene = CreateScene(settings.Seed)
+		cam = camera.NewCameraDefocusBounds(bounds)
+	}
+
+	CreateCopies(scene, cam, settings)
+
+	var sampler sampler.Sampler
+	sampler, err = settings.NewSampler()
+	if err != nil {
+		return err
+	}
+
+	var start = time.Now()
+
+	// Without the temporal filter the rays are not jittered and a single frame is enough
+	var samples = 1
+	if settings.TemporalFilter {
+		samples = settings.Samples
+	}
+
+	// Interrupting the command (Ctrl+C) cancels the render
+	var ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Each pass adds one jittered sample per pixel to the buffer
+	var buffer = framebuffer.NewFramebufferBounds(bounds)
+	var logged time.Time
+
+	err = RenderPasses(ctx, buffer, scene, cam, sampler, settings, samples, func(p Progress) {
+		if *quiet || (time.Since(logged) < ProgressLogInterval && p.Passes < p.TotalPasses) {
+			return
+		}
+
+		logged = time.Now()
+		log.Printf("Rendering %5.1f%%, %d/%d tiles, %d samples per pixel, %s remaining", p.Fraction()*100.0, p.Tiles, p.TotalTiles, p.Samples, p.Remaining.Round(time.Second))
+	})
+	if err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+
+	var toneMapper *tonemap.ToneMapper
+	toneMapper, err = settings.ToneMapper()
+	if err != nil {
+		return err
+	}
+
+	var picture = buffer.Picture(toneMapper)
+
+	log.Printf("Rendered %dx%d with %d samples in %s", settings.Width, settings.Height, samples, time.Since(start))
+	if invalid := buffer.InvalidSamples(); invalid > 0 {
+		log.Printf("Discarded %d samples with invalid values (NaN or infinite)", invalid)
+	}
+
+	switch *format {
+	case "png":
+		return WritePNG(picture, *output)
+	case "jpeg", "jpg":
+		return WriteJPEG(picture, *output, *quality)
+	default:
+		return WritePPM(picture, *output)
+	}
+}
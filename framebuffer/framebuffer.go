@@ -0,0 +1,160 @@
+package framebuffer
+
+import (
+	"gotracer/tonemap"
+	"gotracer/vmath"
+	"math"
+	"sync/atomic"
+
+	"github.com/gopxl/pixel/v2"
+)
+
+// Framebuffer accumulates the color samples of each pixel with floating point precision.
+//
+// Samples are summed without any limit, the color of a pixel is the average of all samples added to it.
+// Values are kept in linear HDR space and are only quantized when converted into a picture.
+type Framebuffer struct {
+	// Size of the buffer in pixels.
+	Width  int
+	Height int
+
+	// Sum of the color samples of each pixel, stored row by row starting on the bottom row.
+	Color []vmath.Vec3
+
+	// Number of samples accumulated in each pixel.
+	Samples []uint32
+
+	// Number of samples taken by each pixel, including the discarded samples.
+	// Used as the index of the next sample of the pixel, so each sample uses different random numbers even if a pass was cancelled.
+	Taken []uint32
+
+	// Number of complete passes rendered into the buffer since it was reset.
+	Passes int
+
+	// Number of samples discarded because of invalid values since the buffer was reset.
+	invalid atomic.Uint64
+}
+
+// Create a new framebuffer with the size in pixels.
+func NewFramebuffer(width int, height int) *Framebuffer {
+	var f = new(Framebuffer)
+	f.Width = width
+	f.Height = height
+	f.Color = make([]vmath.Vec3, width*height)
+	f.Samples = make([]uint32, width*height)
+	f.Taken = make([]uint32, width*height)
+	return f
+}
+
+// Create a new framebuffer with the size of a pixel rectangle.
+func NewFramebufferBounds(bounds pixel.Rect) *Framebuffer {
+	var size = bounds.Size()
+	return NewFramebuffer(int(size.X), int(size.Y))
+}
+
+// Index of a pixel in the buffer.
+func (f *Framebuffer) Index(x int, y int) int {
+	return y*f.Width + x
+}
+
+// Add a color sample to a pixel.
+// Samples with invalid values (NaN or infinite) are discarded and counted, see InvalidSamples.
+//
+// Each pixel can be written from a different goroutine as long as the same pixel is not written at the same time.
+func (f *Framebuffer) AddSample(x int, y int, color vmath.Vec3) {
+	var index = f.Index(x, y)
+	f.Taken[index]++
+
+	if !valid(color.X) || !valid(color.Y) || !valid(color.Z) {
+		f.invalid.Add(1)
+		return
+	}
+
+	f.Color[index] = f.Color[index].Add(color)
+	f.Samples[index]++
+}
+
+// Get the average color of a pixel, black if no samples were added.
+func (f *Framebuffer) Get(x int, y int) vmath.Vec3 {
+	var index = f.Index(x, y)
+
+	if f.Samples[index] > 0 {
+		return f.Color[index].DivideScalar(float64(f.Samples[index]))
+	}
+
+	return f.Color[index]
+}
+
+// Get the minimum number of samples accumulated by the pixels of the buffer.
+func (f *Framebuffer) MinSamples() uint32 {
+	if len(f.Samples) == 0 {
+		return 0
+	}
+
+	var min = f.Samples[0]
+	for i := 1; i < len(f.Samples); i++ {
+		if f.Samples[i] < min {
+			min = f.Samples[i]
+		}
+	}
+
+	return min
+}
+
+// Get the number of samples with invalid values (NaN or infinite) discarded since the buffer was reset.
+// Invalid samples usually come from a division by zero in a material or light, they should not happen.
+func (f *Framebuffer) InvalidSamples() uint64 {
+	return f.invalid.Load()
+}
+
+// Reset the buffer discarding all the samples accumulated.
+// Should be called when the camera or scene change.
+func (f *Framebuffer) Reset() {
+	for i := 0; i < len(f.Color); i++ {
+		f.Color[i] = vmath.Vec3{}
+		f.Samples[i] = 0
+		f.Taken[i] = 0
+	}
+	f.Passes = 0
+	f.invalid.Store(0)
+}
+
+// Convert the buffer into a 8 bit picture.
+// The tone mapper converts the HDR values into display values, if nil the default tone mapper is used.
+func (f *Framebuffer) Picture(mapper *tonemap.ToneMapper) *pixel.PictureData {
+	if mapper == nil {
+		mapper = tonemap.NewDefaultToneMapper()
+	}
+
+	var picture = pixel.MakePictureData(pixel.R(0, 0, float64(f.Width), float64(f.Height)))
+
+	for j := 0; j < f.Height; j++ {
+		for i := 0; i < f.Width; i++ {
+			var color = mapper.Map(f.Get(i, j))
+
+			var index = picture.Index(pixel.Vec{X: float64(i), Y: float64(j)})
+			picture.Pix[index].R = quantize(color.X)
+			picture.Pix[index].G = quantize(color.Y)
+			picture.Pix[index].B = quantize(color.Z)
+			picture.Pix[index].A = 255
+		}
+	}
+
+	return picture
+}
+
+// Clamp a value to the [0, 1] range and convert it into a 8 bit value.
+func quantize(v float64) uint8 {
+	if !(v > 0.0) {
+		return 0
+	}
+	if v >= 1.0 {
+		return 255
+	}
+	return uint8(v*255.0 + 0.5)
+}
+
+// Check if a value is finite.
+func valid(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
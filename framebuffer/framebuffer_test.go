@@ -0,0 +1,40 @@
+package framebuffer
+
+import (
+	"gotracer/vmath"
+	"math"
+	"testing"
+)
+
+// Invalid samples are not added to the pixels but are counted until the buffer is reset.
+func TestAddSampleInvalid(t *testing.T) {
+	var f = NewFramebuffer(2, 1)
+
+	f.AddSample(0, 0, vmath.NewVec3(1.0, 0.5, 0.25))
+	f.AddSample(0, 0, vmath.NewVec3(math.NaN(), 0.0, 0.0))
+	f.AddSample(1, 0, vmath.NewVec3(0.0, math.Inf(1), 0.0))
+	f.AddSample(1, 0, vmath.NewVec3(0.0, 0.0, math.Inf(-1)))
+
+	if f.Samples[0] != 1 || f.Samples[1] != 0 {
+		t.Errorf("pixels have %d and %d samples, expected 1 and 0", f.Samples[0], f.Samples[1])
+	}
+	if color := f.Get(0, 0); color != vmath.NewVec3(1.0, 0.5, 0.25) {
+		t.Errorf("pixel color is %s", color.ToString())
+	}
+	if invalid := f.InvalidSamples(); invalid != 3 {
+		t.Errorf("counted %d invalid samples, expected 3", invalid)
+	}
+
+	// Discarded samples are taken, the next sample of the pixel uses a new index
+	if f.Taken[0] != 2 || f.Taken[1] != 2 {
+		t.Errorf("pixels have taken %d and %d samples, expected 2 and 2", f.Taken[0], f.Taken[1])
+	}
+
+	f.Reset()
+	if invalid := f.InvalidSamples(); invalid != 0 {
+		t.Errorf("counted %d invalid samples after reset", invalid)
+	}
+	if f.Taken[0] != 0 || f.Taken[1] != 0 {
+		t.Errorf("pixels have taken %d and %d samples after reset", f.Taken[0], f.Taken[1])
+	}
+}
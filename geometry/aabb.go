@@ -0,0 +1,102 @@
+package geometry
+
+import (
+	"gotracer/vmath"
+	"math"
+)
+
+// Axis aligned bounding box, represented by its minimum and maximum corners.
+// Used by the acceleration structures to quickly discard objects that cannot be intersected by a ray.
+type AABB struct {
+	// Minimum corner of the bounding box
+	Min vmath.Vec3
+
+	// Maximum corner of the bounding box
+	Max vmath.Vec3
+}
+
+// Create new bounding box from its corners.
+func NewAABB(min vmath.Vec3, max vmath.Vec3) *AABB {
+	var box = new(AABB)
+	box.Min = min
+	box.Max = max
+	return box
+}
+
+// Create new empty bounding box, the box is inverted so that any expansion sets the correct values.
+func NewEmptyAABB() *AABB {
+	var box = new(AABB)
+	box.Min = vmath.NewVec3(math.Inf(1), math.Inf(1), math.Inf(1))
+	box.Max = vmath.NewVec3(math.Inf(-1), math.Inf(-1), math.Inf(-1))
+	return box
+}
+
+// Expand the bounding box to include a point.
+func (box *AABB) ExpandByPoint(p vmath.Vec3) {
+	box.Min = box.Min.Min(p)
+	box.Max = box.Max.Max(p)
+}
+
+// Expand the bounding box to include another bounding box.
+func (box *AABB) Union(b *AABB) {
+	box.ExpandByPoint(b.Min)
+	box.ExpandByPoint(b.Max)
+}
+
+// Center point of the bounding box.
+func (box *AABB) Centroid() vmath.Vec3 {
+	return box.Min.Add(box.Max).MulScalar(0.5)
+}
+
+// Size of the bounding box in each axis.
+func (box *AABB) Size() vmath.Vec3 {
+	return box.Max.Sub(box.Min)
+}
+
+// Surface area of the bounding box, used by the surface area heuristic.
+func (box *AABB) SurfaceArea() float64 {
+	if box.Max.X < box.Min.X || box.Max.Y < box.Min.Y || box.Max.Z < box.Min.Z {
+		return 0.0
+	}
+
+	var s = box.Size()
+	return 2.0 * (s.X*s.Y + s.Y*s.Z + s.Z*s.X)
+}
+
+// Check if the ray intersects the box in the interval between tmin and tmax.
+// Uses the slab method, does not calculate any hit information.
+func (box *AABB) Hit(ray vmath.Ray, tmin float64, tmax float64) bool {
+	var origin = [3]float64{ray.Origin.X, ray.Origin.Y, ray.Origin.Z}
+	var direction = [3]float64{ray.Direction.X, ray.Direction.Y, ray.Direction.Z}
+	var min = [3]float64{box.Min.X, box.Min.Y, box.Min.Z}
+	var max = [3]float64{box.Max.X, box.Max.Y, box.Max.Z}
+
+	for a := 0; a < 3; a++ {
+		var invD = 1.0 / direction[a]
+		var t0 = (min[a] - origin[a]) * invD
+		var t1 = (max[a] - origin[a]) * invD
+
+		if invD < 0.0 {
+			t0, t1 = t1, t0
+		}
+
+		// Comparisons written so that NaN values (ray parallel and touching the slab) do not discard the box
+		if t0 > tmin {
+			tmin = t0
+		}
+		if t1 < tmax {
+			tmax = t1
+		}
+
+		if tmax < tmin {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Clone the bounding box.
+func (box *AABB) Clone() *AABB {
+	return NewAABB(box.Min, box.Max)
+}
@@ -8,16 +8,16 @@ import (
 // Box hitable object.
 type Box struct {
 	// Origin corner of the box
-	Min *vmath.Vector3
+	Min vmath.Vec3
 
 	// The opposite maximum corner of the box
-	Max *vmath.Vector3
+	Max vmath.Vec3
 
 	// Material used to render the box.
 	Material material.Material
 }
 
-func NewBox(min *vmath.Vector3, max *vmath.Vector3, material material.Material) *Box {
+func NewBox(min vmath.Vec3, max vmath.Vec3, material material.Material) *Box {
 	var box = new(Box)
 	box.Min = min
 	box.Max = max
@@ -25,97 +25,117 @@ func NewBox(min *vmath.Vector3, max *vmath.Vector3, material material.Material)
 	return box
 }
 
-func (box *Box) Hit(ray *vmath.Ray, tmin float64, tmax float64, hitRecord *material.HitRecord) bool {
-
-	var txmin = (box.Min.X - ray.Origin.X) / ray.Direction.X
-	var txmax = (box.Max.X - ray.Origin.X) / ray.Direction.X
-
-	var normal = vmath.NewVector3(0, 1.0, 0)
-	var signal = -1.0
-
-	if txmin > txmax {
-		var temp = txmin
-		txmin = txmax
-		txmax = temp
-		signal = 1.0
+// Intersect the ray with the slabs of the box, the ray hits the face where it enters the box.
+// If the ray starts inside of the box (e.g. refracted inside of a dielectric box) it hits the face where it leaves the box.
+func (box *Box) Hit(ray vmath.Ray, tmin float64, tmax float64, hitRecord *material.HitRecord) bool {
+	var enter = tmin
+	var exit = tmax
+	var enterNormal, exitNormal vmath.Vec3
+	var entered, exited = false, false
+
+	for axis := 0; axis < 3; axis++ {
+		var origin = ray.Origin.Axis(axis)
+		var direction = ray.Direction.Axis(axis)
+
+		var t0 = (box.Min.Axis(axis) - origin) / direction
+		var t1 = (box.Max.Axis(axis) - origin) / direction
+		var signal = -1.0
+
+		if t0 > t1 {
+			t0, t1 = t1, t0
+			signal = 1.0
+		}
+
+		if (enter > t1) || (t0 > exit) {
+			return false
+		}
+
+		if t0 > enter {
+			enter = t0
+			enterNormal = axisNormal(axis, signal)
+			entered = true
+		}
+		if t1 < exit {
+			exit = t1
+			exitNormal = axisNormal(axis, -signal)
+			exited = true
+		}
 	}
 
-	if (tmin > txmax) || (txmin > tmax) {
-		return false
-	}
-
-	if txmin > tmin {
-		normal.Set(signal, 0.0, 0.0)
-		tmin = txmin
-	}
-	if txmax < tmax {
-		tmax = txmax
-	}
+	var normal, t = enterNormal, enter
 
-	var tymin = (box.Min.Y - ray.Origin.Y) / ray.Direction.Y
-	var tymax = (box.Max.Y - ray.Origin.Y) / ray.Direction.Y
-	signal = -1.0
-
-	if tymin > tymax {
-		var temp = tymin
-		tymin = tymax
-		tymax = temp
-		signal = 1.0
-	}
-
-	if (tmin > tymax) || (tymin > tmax) {
-		return false
-	}
-
-	if tymin > tmin {
-		normal.Set(0.0, signal, 0.0)
-		tmin = tymin
-	}
-	if tymax < tmax {
-		tmax = tymax
+	// The hit record is only changed if the box is hit
+	if !entered {
+		if !exited {
+			return false
+		}
+		normal, t = exitNormal, exit
 	}
 
-	var tzmin = (box.Min.Z - ray.Origin.Z) / ray.Direction.Z
-	var tzmax = (box.Max.Z - ray.Origin.Z) / ray.Direction.Z
-	signal = -1.0
+	hitRecord.T = t
+	hitRecord.Material = box.Material
+	hitRecord.P = ray.PointAtParameter(hitRecord.T)
+	hitRecord.U, hitRecord.V = box.UV(hitRecord.P, normal)
+	hitRecord.SetFaceNormal(ray, normal)
 
-	if tzmin > tzmax {
-		var temp = tzmin
-		tzmin = tzmax
-		tzmax = temp
-		signal = 1.0
-	}
+	return true
+}
 
-	if (tmin > tzmax) || (tzmin > tmax) {
-		return false
+// Outward normal of the face of the box perpendicular to the axis, the signal indicates the side of the face.
+func axisNormal(axis int, signal float64) vmath.Vec3 {
+	switch axis {
+	case 0:
+		return vmath.NewVec3(signal, 0.0, 0.0)
+	case 1:
+		return vmath.NewVec3(0.0, signal, 0.0)
 	}
+	return vmath.NewVec3(0.0, 0.0, signal)
+}
 
-	if tzmin > tmin {
-		normal.Set(0.0, 0.0, signal)
-		tmin = tzmin
-	}
-	if tzmax < tmax {
-		tmax = tzmax
+// Calculate the texture coordinates of a point in the surface of the box.
+// Each face is mapped to the full [0, 1] range using the two axis parallel to the face.
+func (box *Box) UV(p vmath.Vec3, normal vmath.Vec3) (float64, float64) {
+	var size = box.Max.Sub(box.Min)
+	var local = p.Sub(box.Min)
+
+	var u, v float64
+
+	if normal.X != 0 {
+		u = local.Z / size.Z
+		v = local.Y / size.Y
+		if normal.X > 0 {
+			u = 1.0 - u
+		}
+	} else if normal.Y != 0 {
+		u = local.X / size.X
+		v = local.Z / size.Z
+		if normal.Y > 0 {
+			v = 1.0 - v
+		}
+	} else {
+		u = local.X / size.X
+		v = local.Y / size.Y
+		if normal.Z < 0 {
+			u = 1.0 - u
+		}
 	}
 
-	if tmin > tmax {
-		var temp = tmin
-		tmin = tmax
-		tmax = temp
-	}
+	return u, v
+}
 
-	hitRecord.Material = box.Material
-	hitRecord.T = tmin
-	hitRecord.P = ray.PointAtParameter(hitRecord.T)
-	hitRecord.Normal = normal
+// Surface area of the box.
+func (box *Box) Area() float64 {
+	return box.BoundingBox().SurfaceArea()
+}
 
-	return true
+func (box *Box) BoundingBox() *AABB {
+	return NewAABB(box.Min, box.Max)
 }
 
 func (o *Box) Clone() Hitable {
 	var box = new(Box)
-	box.Min = o.Min.Clone()
-	box.Max = o.Max.Clone()
+	box.Min = o.Min
+	box.Max = o.Max
 	box.Material = o.Material.Clone()
 	return box
 }
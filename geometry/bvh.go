@@ -0,0 +1,249 @@
+package geometry
+
+import (
+	"gotracer/material"
+	"gotracer/vmath"
+)
+
+// Number of buckets used to evaluate the surface area heuristic split candidates.
+const BVHBuckets = 12
+
+// Maximum number of objects that can be stored in a leaf node.
+const BVHMaxLeafSize = 4
+
+// Relative cost of traversing a node when compared with the cost of intersecting a object.
+const BVHTraversalCost = 0.125
+
+// Bounding volume hierarchy node, used to accelerate the ray intersection with a large number of objects.
+// Interior nodes have two children, leaf nodes store a small list of objects that are tested directly.
+type BVHNode struct {
+	// Bounding box that contains all the objects inside of this node.
+	Box *AABB
+
+	// Children nodes, nil for leaf nodes.
+	Left  *BVHNode
+	Right *BVHNode
+
+	// Objects stored in the leaf node.
+	Objects []Hitable
+}
+
+// Auxiliary structure used while building the hierarchy, caches the bounds and centroid of each object.
+// The index identifies the primitive when it is not a hitable object (e.g. the faces of a mesh).
+type bvhPrimitive struct {
+	object   Hitable
+	index    int
+	box      *AABB
+	centroid vmath.Vec3
+}
+
+// Create a new bounding volume hierarchy from a list of hitable objects.
+// The tree is built top-down using a binned surface area heuristic (SAH) to choose the split planes.
+func NewBVH(list []Hitable) *BVHNode {
+	var primitives = make([]bvhPrimitive, len(list))
+
+	for i := 0; i < len(list); i++ {
+		var box = list[i].BoundingBox()
+		primitives[i] = bvhPrimitive{object: list[i], index: i, box: box, centroid: box.Centroid()}
+	}
+
+	return buildBVH(primitives)
+}
+
+// Build a BVH node recursively for the list of primitives.
+func buildBVH(primitives []bvhPrimitive) *BVHNode {
+	var node = new(BVHNode)
+	node.Box = boundsBVH(primitives)
+
+	var mid = splitBVH(primitives, node.Box)
+	if mid < 0 {
+		node.setLeaf(primitives)
+		return node
+	}
+
+	node.Left = buildBVH(primitives[:mid])
+	node.Right = buildBVH(primitives[mid:])
+
+	return node
+}
+
+// Get the bounding box that contains all the primitives.
+func boundsBVH(primitives []bvhPrimitive) *AABB {
+	var box = NewEmptyAABB()
+	for i := 0; i < len(primitives); i++ {
+		box.Union(primitives[i].box)
+	}
+	return box
+}
+
+// Choose how to split a list of primitives contained in the box.
+// The primitives are partitioned in place and the index of the first primitive of the right side is returned.
+// Returns -1 if the primitives should be stored in a leaf.
+func splitBVH(primitives []bvhPrimitive, box *AABB) int {
+	if len(primitives) <= 1 {
+		return -1
+	}
+
+	var centroidBox = NewEmptyAABB()
+	for i := 0; i < len(primitives); i++ {
+		centroidBox.ExpandByPoint(primitives[i].centroid)
+	}
+
+	// Split along the axis where the centroids are more spread
+	var extent = centroidBox.Size()
+	var axis = 0
+	if extent.Y > extent.X && extent.Y >= extent.Z {
+		axis = 1
+	} else if extent.Z > extent.X && extent.Z > extent.Y {
+		axis = 2
+	}
+
+	var minimum = centroidBox.Min.Axis(axis)
+	var width = extent.Axis(axis)
+
+	// All centroids are in the same point, the objects cannot be split by position
+	if width <= 0.0 {
+		if len(primitives) <= BVHMaxLeafSize {
+			return -1
+		}
+		return len(primitives) / 2
+	}
+
+	// Place primitives into buckets based on the centroid position
+	var counts [BVHBuckets]int
+	var bounds [BVHBuckets]*AABB
+	for b := 0; b < BVHBuckets; b++ {
+		bounds[b] = NewEmptyAABB()
+	}
+
+	var bucket = func(p *bvhPrimitive) int {
+		var b = int(BVHBuckets * (p.centroid.Axis(axis) - minimum) / width)
+		if b >= BVHBuckets {
+			b = BVHBuckets - 1
+		}
+		return b
+	}
+
+	for i := 0; i < len(primitives); i++ {
+		var b = bucket(&primitives[i])
+		counts[b]++
+		bounds[b].Union(primitives[i].box)
+	}
+
+	// Evaluate the cost of splitting after each bucket
+	var bestCost = -1.0
+	var bestSplit = 0
+	var area = box.SurfaceArea()
+
+	for s := 0; s < BVHBuckets-1; s++ {
+		var left = NewEmptyAABB()
+		var right = NewEmptyAABB()
+		var countLeft = 0
+		var countRight = 0
+
+		for b := 0; b <= s; b++ {
+			left.Union(bounds[b])
+			countLeft += counts[b]
+		}
+		for b := s + 1; b < BVHBuckets; b++ {
+			right.Union(bounds[b])
+			countRight += counts[b]
+		}
+
+		if countLeft == 0 || countRight == 0 {
+			continue
+		}
+
+		var cost = BVHTraversalCost + (float64(countLeft)*left.SurfaceArea()+float64(countRight)*right.SurfaceArea())/area
+		if bestCost < 0 || cost < bestCost {
+			bestCost = cost
+			bestSplit = s
+		}
+	}
+
+	// Create a leaf if splitting is not worth it
+	if len(primitives) <= BVHMaxLeafSize && (bestCost < 0 || bestCost >= float64(len(primitives))) {
+		return -1
+	}
+
+	// Partition the primitives in place
+	var mid = 0
+	for i := 0; i < len(primitives); i++ {
+		if bucket(&primitives[i]) <= bestSplit {
+			primitives[i], primitives[mid] = primitives[mid], primitives[i]
+			mid++
+		}
+	}
+
+	if mid == 0 || mid == len(primitives) {
+		mid = len(primitives) / 2
+	}
+
+	return mid
+}
+
+// Store the primitives as the object list of a leaf node.
+func (node *BVHNode) setLeaf(primitives []bvhPrimitive) {
+	node.Objects = make([]Hitable, len(primitives))
+	for i := 0; i < len(primitives); i++ {
+		node.Objects[i] = primitives[i].object
+	}
+}
+
+// Hit traverses the hierarchy testing only the objects whose bounding boxes are intersected by the ray.
+func (node *BVHNode) Hit(ray vmath.Ray, tmin float64, tmax float64, hitRecord *material.HitRecord) bool {
+	if !node.Box.Hit(ray, tmin, tmax) {
+		return false
+	}
+
+	if node.Left == nil {
+		var hitAnything = false
+		var closestSoFar = tmax
+
+		for i := 0; i < len(node.Objects); i++ {
+			if node.Objects[i].Hit(ray, tmin, closestSoFar, hitRecord) {
+				hitAnything = true
+				closestSoFar = hitRecord.T
+			}
+		}
+
+		return hitAnything
+	}
+
+	var hitLeft = node.Left.Hit(ray, tmin, tmax, hitRecord)
+	if hitLeft {
+		tmax = hitRecord.T
+	}
+
+	var hitRight = node.Right.Hit(ray, tmin, tmax, hitRecord)
+
+	return hitLeft || hitRight
+}
+
+// BoundingBox of the node contains all the objects inside of it.
+func (node *BVHNode) BoundingBox() *AABB {
+	return node.Box.Clone()
+}
+
+// Clone the objects in the hierarchy and build a new hierarchy for them.
+func (node *BVHNode) Clone() Hitable {
+	var list []Hitable
+	node.collect(&list)
+
+	for i := 0; i < len(list); i++ {
+		list[i] = list[i].Clone()
+	}
+
+	return NewBVH(list)
+}
+
+// Collect all objects stored in the leafs of the hierarchy.
+func (node *BVHNode) collect(list *[]Hitable) {
+	if node.Left == nil {
+		*list = append(*list, node.Objects...)
+		return
+	}
+
+	node.Left.collect(list)
+	node.Right.collect(list)
+}
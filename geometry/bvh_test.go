@@ -0,0 +1,128 @@
+package geometry_test
+
+import (
+	"gotracer/geometry"
+	"gotracer/material"
+	"gotracer/vmath"
+	"math/rand"
+	"testing"
+)
+
+// Number of random rays cast against each scene by the hierarchy tests.
+const testRays = 2000
+
+// Random point inside of a cube centered at the origin.
+func randomPoint(r *rand.Rand, size float64) vmath.Vec3 {
+	return vmath.NewVec3((r.Float64()*2.0-1.0)*size, (r.Float64()*2.0-1.0)*size, (r.Float64()*2.0-1.0)*size)
+}
+
+// Create a random sphere, triangle or box around the center, each object has its own material to identify it.
+func randomObject(r *rand.Rand, center vmath.Vec3, size float64) geometry.Hitable {
+	var m = material.NewLambertMaterial(vmath.NewVec3(r.Float64(), r.Float64(), r.Float64()))
+
+	switch r.Intn(3) {
+	case 0:
+		return geometry.NewSphere(size*(0.2+r.Float64()), center, m)
+	case 1:
+		return geometry.NewTriangle(center.Add(randomPoint(r, size)), center.Add(randomPoint(r, size)), center.Add(randomPoint(r, size)), m)
+	default:
+		var half = vmath.NewVec3(size*r.Float64(), size*r.Float64(), size*r.Float64())
+		return geometry.NewBox(center.Sub(half), center.Add(half), m)
+	}
+}
+
+// Scenes used to compare the hierarchy with the brute force test of every object.
+var testHierarchies = map[string]func(r *rand.Rand) *geometry.Scene{
+	"random": func(r *rand.Rand) *geometry.Scene {
+		var scene = geometry.NewScene()
+		for i := 0; i < 300; i++ {
+			scene.Add(randomObject(r, randomPoint(r, 10.0), 1.0))
+		}
+		return scene
+	},
+	"single object": func(r *rand.Rand) *geometry.Scene {
+		var scene = geometry.NewScene()
+		scene.Add(geometry.NewSphere(2.0, vmath.NewVec3(1.0, 0.0, -1.0), material.NewLambertMaterial(vmath.NewVec3(0.5, 0.5, 0.5))))
+		return scene
+	},
+	"same centroid": func(r *rand.Rand) *geometry.Scene {
+		var scene = geometry.NewScene()
+		for i := 0; i < 64; i++ {
+			var size = 0.5 + 5.0*r.Float64()
+			var m = material.NewLambertMaterial(vmath.NewVec3(r.Float64(), r.Float64(), r.Float64()))
+			if i%2 == 0 {
+				scene.Add(geometry.NewSphere(size, vmath.Vec3{}, m))
+			} else {
+				scene.Add(geometry.NewBox(vmath.NewVec3(-size, -size, -size), vmath.NewVec3(size, size, size), m))
+			}
+		}
+		return scene
+	},
+	"flat boxes": func(r *rand.Rand) *geometry.Scene {
+		var scene = geometry.NewScene()
+		for i := 0; i < 100; i++ {
+			var min = randomPoint(r, 10.0)
+			var max = min.Add(vmath.NewVec3(r.Float64()*3.0, r.Float64()*3.0, r.Float64()*3.0))
+
+			// Flatten one of the axes of the box
+			switch i % 3 {
+			case 0:
+				max.X = min.X
+			case 1:
+				max.Y = min.Y
+			default:
+				max.Z = min.Z
+			}
+			scene.Add(geometry.NewBox(min, max, material.NewLambertMaterial(vmath.NewVec3(r.Float64(), r.Float64(), r.Float64()))))
+		}
+		return scene
+	},
+}
+
+// The hierarchy finds the same closest object at the same distance as testing every object of the scene.
+func TestSceneHitBruteForce(t *testing.T) {
+	for name, create := range testHierarchies {
+		t.Run(name, func(t *testing.T) {
+			var r = rand.New(rand.NewSource(1))
+			var scene = create(r)
+
+			var hitRecord = material.NewHitRecord()
+			var expected = material.NewHitRecord()
+			var hits = 0
+
+			for i := 0; i < testRays; i++ {
+				// Rays from outside and inside of the scene aimed at points inside of it
+				var origin = randomPoint(r, 15.0)
+				var ray = vmath.NewRay(origin, randomPoint(r, 8.0).Sub(origin))
+				var tmin, tmax = 1e-4, 1e9
+
+				var hit = scene.Hit(ray, tmin, tmax, hitRecord)
+
+				var closest = tmax
+				var bruteForce = false
+				for j := 0; j < len(scene.List); j++ {
+					if scene.List[j].Hit(ray, tmin, closest, expected) {
+						closest = expected.T
+						bruteForce = true
+					}
+				}
+
+				if hit != bruteForce {
+					t.Fatalf("ray %d hit is %t, expected %t", i, hit, bruteForce)
+				}
+				if !hit {
+					continue
+				}
+				hits++
+
+				if hitRecord.T != expected.T || hitRecord.Material != expected.Material {
+					t.Fatalf("ray %d hit at %f, expected %f (same object %t)", i, hitRecord.T, expected.T, hitRecord.Material == expected.Material)
+				}
+			}
+
+			if hits == 0 {
+				t.Errorf("no ray hit the scene")
+			}
+		})
+	}
+}
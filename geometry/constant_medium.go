@@ -0,0 +1,51 @@
+package geometry
+
+import (
+	"gotracer/material"
+	"gotracer/vmath"
+)
+
+// Constant medium is a volume of fog or smoke with constant density, filling the inside of a boundary object.
+//
+// The boundary must be a closed object and both of its faces must be hit by rays (meshes should not use culling).
+// The surface of the boundary uses the volume material, rays cross it and are scattered inside by the integrator at distances sampled from the density.
+type ConstantMedium struct {
+	// Object that limits the volume, the material of the object is ignored.
+	Boundary Hitable
+
+	// Material with the density and phase function of the particles inside of the volume.
+	Material *material.VolumeMaterial
+}
+
+// Create a constant medium with the density, albedo and phase function of the particles.
+func NewConstantMedium(boundary Hitable, density float64, albedo vmath.Vec3, phase material.PhaseFunction) *ConstantMedium {
+	return NewConstantMediumMaterial(boundary, material.NewVolumeMaterial(density, albedo, phase))
+}
+
+func NewConstantMediumMaterial(boundary Hitable, volume *material.VolumeMaterial) *ConstantMedium {
+	var c = new(ConstantMedium)
+	c.Boundary = boundary
+	c.Material = volume
+	return c
+}
+
+// Hit the boundary of the volume, the hit record uses the volume material.
+func (c *ConstantMedium) Hit(ray vmath.Ray, tmin float64, tmax float64, hitRecord *material.HitRecord) bool {
+	if !c.Boundary.Hit(ray, tmin, tmax, hitRecord) {
+		return false
+	}
+
+	hitRecord.Material = c.Material
+	return true
+}
+
+func (c *ConstantMedium) BoundingBox() *AABB {
+	return c.Boundary.BoundingBox()
+}
+
+func (o *ConstantMedium) Clone() Hitable {
+	var c = new(ConstantMedium)
+	c.Boundary = o.Boundary.Clone()
+	c.Material = o.Material.Clone().(*material.VolumeMaterial)
+	return c
+}
@@ -0,0 +1,15 @@
+package geometry
+
+// Cull mode indicates which faces of a triangle are ignored by the ray intersection.
+type CullMode int
+
+const (
+	// Back faces are ignored, only rays arriving from the front side of the triangle hit it.
+	CullBack CullMode = iota
+
+	// Front faces are ignored, only rays arriving from the back side of the triangle hit it.
+	CullFront
+
+	// Both faces can be hit, used for dielectric and open meshes.
+	CullNone
+)
@@ -9,7 +9,10 @@ import (
 type Hitable interface {
 	// The hit method indicates if the object was intersected by the ray.
 	// If true the result is stored on the hitrecord object provided.
-	Hit(ray *vmath.Ray, tmin float64, tmax float64, hitRecord *material.HitRecord) bool
+	Hit(ray vmath.Ray, tmin float64, tmax float64, hitRecord *material.HitRecord) bool
+
+	// Bounding box that contains the whole object, used to build acceleration structures.
+	BoundingBox() *AABB
 
 	// Clone object create a new object with the same properties.
 	Clone() Hitable
@@ -0,0 +1,82 @@
+package geometry
+
+import (
+	"errors"
+	"gotracer/material"
+	"gotracer/vmath"
+)
+
+// Instance places a hitable object in the scene with a object-to-world transform.
+//
+// Rays are transformed into the space of the object instead of transforming the object,
+// so the same object (e.g. a large mesh) can be used by many instances without duplicating its geometry.
+type Instance struct {
+	// Object placed by the instance.
+	Object Hitable
+
+	// Transform from the object space to the world space.
+	Transform *vmath.Matrix4
+
+	// Inverse of the transform, from the world space to the object space.
+	Inverse *vmath.Matrix4
+}
+
+// Create a instance of the object with a transform.
+// Returns a error if the transform cannot be inverted (e.g. a scale of zero).
+func NewInstance(object Hitable, transform *vmath.Matrix4) (*Instance, error) {
+	var inverse, ok = transform.Inverse()
+	if !ok {
+		return nil, errors.New("geometry: instance transform is not invertible")
+	}
+
+	var i = new(Instance)
+	i.Object = object
+	i.Transform = transform
+	i.Inverse = inverse
+	return i, nil
+}
+
+// Hit transforms the ray into the object space and the hit record back into the world space.
+// The direction of the ray is not normalized after the transform so the distance T is the same in both spaces.
+func (i *Instance) Hit(ray vmath.Ray, tmin float64, tmax float64, hitRecord *material.HitRecord) bool {
+	var local = vmath.NewRay(i.Inverse.TransformPoint(ray.Origin), i.Inverse.TransformDirection(ray.Direction))
+
+	if !i.Object.Hit(local, tmin, tmax, hitRecord) {
+		return false
+	}
+
+	hitRecord.P = ray.PointAtParameter(hitRecord.T)
+	hitRecord.Normal = i.Inverse.TransformNormal(hitRecord.Normal)
+
+	return true
+}
+
+// BoundingBox of the instance contains the transformed corners of the object bounding box.
+func (i *Instance) BoundingBox() *AABB {
+	var box = i.Object.BoundingBox()
+	var result = NewEmptyAABB()
+
+	for c := 0; c < 8; c++ {
+		var corner = box.Min
+		if c&1 != 0 {
+			corner.X = box.Max.X
+		}
+		if c&2 != 0 {
+			corner.Y = box.Max.Y
+		}
+		if c&4 != 0 {
+			corner.Z = box.Max.Z
+		}
+		result.ExpandByPoint(i.Transform.TransformPoint(corner))
+	}
+
+	return result
+}
+
+func (i *Instance) Clone() Hitable {
+	var c = new(Instance)
+	c.Object = i.Object.Clone()
+	c.Transform = i.Transform.Clone()
+	c.Inverse = i.Inverse.Clone()
+	return c
+}
@@ -0,0 +1,235 @@
+package geometry
+
+import (
+	"gotracer/material"
+	"gotracer/vmath"
+)
+
+// Mesh is a hitable triangle mesh, the vertex attributes are stored in shared arrays indexed by the faces.
+//
+// The faces are organized in a internal bounding volume hierarchy so the mesh is a single object in the scene.
+// The arrays and the hierarchy are not modified after the mesh is created and are shared by its clones.
+type Mesh struct {
+	// Vertex attributes of the mesh, normals and texture coordinates are optional.
+	Positions []vmath.Vec3
+	Normals   []vmath.Vec3
+	TexCoords []vmath.Vec3
+
+	// Index of the attributes of each vertex, three indices per face.
+	// Normal and texture coordinate indices can be empty, or -1 for vertices without the attribute.
+	PositionIndices []int32
+	NormalIndices   []int32
+	TexCoordIndices []int32
+
+	// Materials used by the mesh and the index of the material of each face.
+	Materials     []material.Material
+	FaceMaterials []int32
+
+	// Index of the material used by the faces that have no material in the file the mesh was loaded from, -1 if there is no such material.
+	DefaultMaterial int32
+
+	// Faces ignored by the ray intersection, by default the back faces are culled.
+	Cull CullMode
+
+	// OBJ file the mesh was loaded from and the crease angle used to generate its normals, the file is empty if the mesh was not loaded from a file.
+	File        string
+	CreaseAngle float64
+
+	// Nodes of the hierarchy, the first node is the root.
+	nodes []meshNode
+
+	// Faces ordered by the leaf nodes.
+	faces []int32
+}
+
+// Node of the mesh hierarchy, stored in a flat array and referenced by index.
+type meshNode struct {
+	box AABB
+
+	// Children nodes, -1 for leaf nodes.
+	left  int32
+	right int32
+
+	// Range of faces stored in a leaf node.
+	start int32
+	count int32
+}
+
+// Create a mesh from the vertex positions and the index of the vertices of each face.
+// Each face has the index of its material, normals and texture coordinates can be set after the mesh is created.
+func NewMesh(positions []vmath.Vec3, positionIndices []int32, materials []material.Material, faceMaterials []int32) *Mesh {
+	var mesh = new(Mesh)
+	mesh.Positions = positions
+	mesh.PositionIndices = positionIndices
+	mesh.Materials = materials
+	mesh.FaceMaterials = faceMaterials
+	mesh.DefaultMaterial = -1
+	mesh.Cull = CullBack
+	mesh.build()
+	return mesh
+}
+
+// Number of faces of the mesh.
+func (mesh *Mesh) FaceCount() int {
+	return len(mesh.PositionIndices) / 3
+}
+
+// Build the hierarchy of the faces using the same surface area heuristic of the scene hierarchy.
+func (mesh *Mesh) build() {
+	var count = mesh.FaceCount()
+	var primitives = make([]bvhPrimitive, count)
+
+	for i := 0; i < count; i++ {
+		var box = NewEmptyAABB()
+		for j := 0; j < 3; j++ {
+			box.ExpandByPoint(mesh.Positions[mesh.PositionIndices[i*3+j]])
+		}
+		primitives[i] = bvhPrimitive{index: i, box: box, centroid: box.Centroid()}
+	}
+
+	mesh.nodes = make([]meshNode, 0, 2*count/BVHMaxLeafSize+1)
+	mesh.faces = make([]int32, 0, count)
+	mesh.buildNode(primitives)
+}
+
+// Add a node for the primitives and its children to the hierarchy, returns the index of the node.
+func (mesh *Mesh) buildNode(primitives []bvhPrimitive) int32 {
+	var index = int32(len(mesh.nodes))
+	mesh.nodes = append(mesh.nodes, meshNode{})
+
+	var box = boundsBVH(primitives)
+	var node = meshNode{box: *box, left: -1, right: -1}
+
+	var mid = splitBVH(primitives, box)
+	if mid < 0 {
+		node.start = int32(len(mesh.faces))
+		node.count = int32(len(primitives))
+		for i := 0; i < len(primitives); i++ {
+			mesh.faces = append(mesh.faces, int32(primitives[i].index))
+		}
+	} else {
+		node.left = mesh.buildNode(primitives[:mid])
+		node.right = mesh.buildNode(primitives[mid:])
+	}
+
+	mesh.nodes[index] = node
+	return index
+}
+
+// Hit traverses the hierarchy of the mesh and tests the faces whose bounding boxes are intersected by the ray.
+func (mesh *Mesh) Hit(ray vmath.Ray, tmin float64, tmax float64, hitRecord *material.HitRecord) bool {
+	var buffer [64]int32
+	var stack = append(buffer[:0], 0)
+
+	var closest = tmax
+	var face = int32(-1)
+	var u, v float64
+	var front bool
+
+	for len(stack) > 0 {
+		var node = &mesh.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+
+		if !node.box.Hit(ray, tmin, closest) {
+			continue
+		}
+
+		if node.left >= 0 {
+			stack = append(stack, node.right, node.left)
+			continue
+		}
+
+		for i := node.start; i < node.start+node.count; i++ {
+			var f = mesh.faces[i]
+			var a, b, c = mesh.vertices(f)
+
+			var t, fu, fv, ff, ok = IntersectTriangle(ray, a, b, c, mesh.Cull, tmin, closest)
+			if ok {
+				closest = t
+				face = f
+				u, v, front = fu, fv, ff
+			}
+		}
+	}
+
+	if face < 0 {
+		return false
+	}
+
+	hitRecord.T = closest
+	hitRecord.P = ray.PointAtParameter(closest)
+	hitRecord.U, hitRecord.V = mesh.texCoord(face, u, v)
+
+	var normal = mesh.normal(face, u, v)
+	hitRecord.FrontFace = front
+	if !front {
+		normal = normal.Negate()
+	}
+	hitRecord.Normal = normal
+	hitRecord.Material = mesh.Materials[mesh.FaceMaterials[face]]
+
+	return true
+}
+
+// Get the positions of the vertices of a face.
+func (mesh *Mesh) vertices(face int32) (vmath.Vec3, vmath.Vec3, vmath.Vec3) {
+	var i = face * 3
+	return mesh.Positions[mesh.PositionIndices[i]], mesh.Positions[mesh.PositionIndices[i+1]], mesh.Positions[mesh.PositionIndices[i+2]]
+}
+
+// Get the shading normal of a point in a face from its barycentric coordinates.
+// The vertex normals are interpolated if present, otherwise the flat normal of the face is used.
+func (mesh *Mesh) normal(face int32, u float64, v float64) vmath.Vec3 {
+	var i = face * 3
+
+	if len(mesh.NormalIndices) > 0 && mesh.NormalIndices[i] >= 0 && mesh.NormalIndices[i+1] >= 0 && mesh.NormalIndices[i+2] >= 0 {
+		var na = &mesh.Normals[mesh.NormalIndices[i]]
+		var nb = &mesh.Normals[mesh.NormalIndices[i+1]]
+		var nc = &mesh.Normals[mesh.NormalIndices[i+2]]
+
+		var w = 1.0 - u - v
+		var normal = vmath.NewVec3(w*na.X+u*nb.X+v*nc.X, w*na.Y+u*nb.Y+v*nc.Y, w*na.Z+u*nb.Z+v*nc.Z)
+		if normal.SquaredLength() > 0.0 {
+			return normal.Normalize()
+		}
+	}
+
+	var a, b, c = mesh.vertices(face)
+
+	return c.Sub(b).Cross(a.Sub(b)).Normalize()
+}
+
+// Get the texture coordinates of a point in a face from its barycentric coordinates.
+// If the face has no texture coordinates the barycentric coordinates are used.
+func (mesh *Mesh) texCoord(face int32, u float64, v float64) (float64, float64) {
+	var i = face * 3
+
+	if len(mesh.TexCoordIndices) > 0 && mesh.TexCoordIndices[i] >= 0 && mesh.TexCoordIndices[i+1] >= 0 && mesh.TexCoordIndices[i+2] >= 0 {
+		var ta = &mesh.TexCoords[mesh.TexCoordIndices[i]]
+		var tb = &mesh.TexCoords[mesh.TexCoordIndices[i+1]]
+		var tc = &mesh.TexCoords[mesh.TexCoordIndices[i+2]]
+
+		var w = 1.0 - u - v
+		return w*ta.X + u*tb.X + v*tc.X, w*ta.Y + u*tb.Y + v*tc.Y
+	}
+
+	return u, v
+}
+
+// BoundingBox of the mesh is the box of the root node of the hierarchy.
+func (mesh *Mesh) BoundingBox() *AABB {
+	return mesh.nodes[0].box.Clone()
+}
+
+// Clone the mesh, the vertex arrays and the hierarchy are shared and only the materials are cloned.
+func (mesh *Mesh) Clone() Hitable {
+	var m = new(Mesh)
+	*m = *mesh
+
+	m.Materials = make([]material.Material, len(mesh.Materials))
+	for i := 0; i < len(mesh.Materials); i++ {
+		m.Materials[i] = mesh.Materials[i].Clone()
+	}
+
+	return m
+}
@@ -1,42 +1,130 @@
 package geometry
 
 import (
+	"gotracer/light"
 	"gotracer/material"
 	"gotracer/vmath"
+	"math"
+	"sync"
+	"sync/atomic"
 )
 
 // A scene (hittable list) contains hittable objects to be ray traced.
 // Works in the same way as a scene in game engines.
 type Scene struct {
 	List []Hitable
+
+	// Explicit light sources, sampled directly by the integrator.
+	Lights []light.Light
+
+	// Participating medium filling the space outside of the objects (fog, haze), nil if the scene is in vacuum.
+	// The atmosphere fills a sphere centered at the origin, rays that leave the sphere see the background attenuated by the atmosphere crossed.
+	Atmosphere *material.VolumeMaterial
+
+	// Radius of the sphere filled by the atmosphere.
+	AtmosphereRadius float64
+
+	// Bounding volume hierarchy built from the list of objects.
+	// Built automatically on the first hit test after the list is changed.
+	BVH *BVHNode
+
+	// Used to build the hierarchy only once when multiple threads are rendering the scene.
+	// The mutex is held while the hierarchy is built or invalidated, built is checked without locking on each hit test.
+	mutex sync.Mutex
+	built atomic.Bool
 }
 
+// Radius of the atmosphere used by new scenes.
+const DefaultAtmosphereRadius = 50.0
+
 // Create new hittable list
 func NewScene() *Scene {
-	return new(Scene)
+	var scene = new(Scene)
+	scene.AtmosphereRadius = DefaultAtmosphereRadius
+	return scene
 }
 
 // Add a hittable element to the list
 func (scene *Scene) Add(h Hitable) {
 	scene.List = append(scene.List, h)
+	scene.Invalidate()
 }
 
-// Hit iterates and tests all hittable object in the list.
-func (scene *Scene) Hit(r *vmath.Ray, tmin float64, tmax float64, rec *material.HitRecord) bool {
+// Add a light source to the scene
+func (scene *Scene) AddLight(l light.Light) {
+	scene.Lights = append(scene.Lights, l)
+}
 
-	var hitAnything = false
-	var closestSoFar = tmax
-	var tempRec = material.NewHitRecord()
+// Invalidate the acceleration structure of the scene.
+// Should be called if the objects in the list are changed, it will be rebuilt on the next hit test.
+//
+// Waits for a hierarchy being built by another goroutine to finish.
+// The objects of the scene must not be changed while it is rendered, so it should only be called while no render is running.
+func (scene *Scene) Invalidate() {
+	scene.mutex.Lock()
+	defer scene.mutex.Unlock()
 
-	for i := 0; i < len(scene.List); i++ {
-		if scene.List[i].Hit(r, tmin, closestSoFar, tempRec) {
-			hitAnything = true
-			closestSoFar = tempRec.T
-			rec.Copy(tempRec)
+	scene.BVH = nil
+	scene.built.Store(false)
+}
+
+// Build the bounding volume hierarchy for the objects in the scene.
+// Safe to call from multiple goroutines, the hierarchy is only built once.
+func (scene *Scene) Build() {
+	if scene.built.Load() {
+		return
+	}
+
+	scene.mutex.Lock()
+	defer scene.mutex.Unlock()
+
+	if !scene.built.Load() {
+		if len(scene.List) > 0 {
+			scene.BVH = NewBVH(scene.List)
 		}
+		scene.built.Store(true)
+	}
+}
+
+// Hit tests the objects in the list using the bounding volume hierarchy.
+func (scene *Scene) Hit(r vmath.Ray, tmin float64, tmax float64, rec *material.HitRecord) bool {
+	scene.Build()
+
+	if scene.BVH == nil {
+		return false
 	}
 
-	return hitAnything
+	return scene.BVH.Hit(r, tmin, tmax, rec)
+}
+
+// Bounding box of all the objects in the scene.
+func (scene *Scene) BoundingBox() *AABB {
+	var box = NewEmptyAABB()
+
+	for i := 0; i < len(scene.List); i++ {
+		box.Union(scene.List[i].BoundingBox())
+	}
+
+	return box
+}
+
+// Get the range of the ray between tmin and tmax that is inside of the sphere filled by the atmosphere.
+// Returns false if the ray does not cross the atmosphere in the range.
+func (scene *Scene) AtmosphereInterval(ray vmath.Ray, tmin float64, tmax float64) (float64, float64, bool) {
+	var a = ray.Direction.SquaredLength()
+	var b = ray.Origin.Dot(ray.Direction)
+	var c = ray.Origin.SquaredLength() - scene.AtmosphereRadius*scene.AtmosphereRadius
+
+	var discriminant = b*b - a*c
+	if discriminant <= 0 {
+		return 0.0, 0.0, false
+	}
+
+	var root = math.Sqrt(discriminant)
+	var start = math.Max((-b-root)/a, tmin)
+	var end = math.Min((-b+root)/a, tmax)
+
+	return start, end, start < end
 }
 
 // Clone the hittable list and the objects in the list
@@ -47,5 +135,14 @@ func (scene *Scene) Clone() *Scene {
 		l.Add(scene.List[i].Clone())
 	}
 
+	for i := 0; i < len(scene.Lights); i++ {
+		l.AddLight(scene.Lights[i].Clone())
+	}
+
+	if scene.Atmosphere != nil {
+		l.Atmosphere = scene.Atmosphere.Clone().(*material.VolumeMaterial)
+	}
+	l.AtmosphereRadius = scene.AtmosphereRadius
+
 	return l
 }
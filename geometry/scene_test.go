@@ -0,0 +1,119 @@
+package geometry_test
+
+import (
+	"gotracer/camera"
+	"gotracer/geometry"
+	"gotracer/material"
+	"gotracer/sampler"
+	"gotracer/scenefile"
+	"gotracer/vmath"
+	"math"
+	"testing"
+
+	"github.com/gopxl/pixel/v2"
+)
+
+// Size of the images used to cast the primary rays.
+const testWidth, testHeight = 160, 120
+
+// Scenes used to measure the primary rays, with spheres, boxes, meshes and instances.
+var primaryRayScenes = []string{"example", "mesh", "instances"}
+
+// Load a scene file from the scenes directory and build its acceleration structure.
+func loadScene(tb testing.TB, name string) (*geometry.Scene, *camera.CameraDefocus) {
+	tb.Helper()
+
+	var scene, cam, err = scenefile.Load("../scenes/"+name+".json", pixel.R(0, 0, testWidth, testHeight))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	scene.Build()
+	return scene, cam
+}
+
+// Cast the primary rays of a range of pixels against the scene, the hit record is reused for every ray.
+// The lens position of each ray is generated by the first sample of the pixel.
+// Returns the number of rays that hit any object.
+func tracePrimaryRays(scene *geometry.Scene, cam *camera.CameraDefocus, hitRecord *material.HitRecord, s sampler.Sampler, start int, count int) int {
+	var hits = 0
+
+	for p := start; p < start+count; p++ {
+		var x, y = p % testWidth, (p / testWidth) % testHeight
+
+		// The pixel dimensions are skipped, the rays are not jittered
+		s.StartPixelSample(x, y, 0)
+		s.Get2D()
+
+		if scene.Hit(cam.GetRay(float64(x)/testWidth, float64(y)/testHeight, s), 1e-5, math.MaxFloat64, hitRecord) {
+			hits++
+		}
+	}
+
+	return hits
+}
+
+func BenchmarkPrimaryRays(b *testing.B) {
+	for _, name := range primaryRayScenes {
+		b.Run(name, func(b *testing.B) {
+			var scene, cam = loadScene(b, name)
+			var hitRecord = material.NewHitRecord()
+			var s = sampler.NewSobolSampler(1)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			tracePrimaryRays(scene, cam, hitRecord, s, 0, b.N)
+		})
+	}
+}
+
+// The primary ray loop (camera ray generation and scene intersection) must not allocate.
+func TestPrimaryRaysAllocations(t *testing.T) {
+	for _, name := range primaryRayScenes {
+		t.Run(name, func(t *testing.T) {
+			var scene, cam = loadScene(t, name)
+			var hitRecord = material.NewHitRecord()
+			var s = sampler.NewSobolSampler(1)
+
+			var hits int
+			var allocs = testing.AllocsPerRun(3, func() {
+				hits = tracePrimaryRays(scene, cam, hitRecord, s, 0, testWidth*testHeight)
+			})
+
+			if allocs != 0 {
+				t.Errorf("primary ray loop allocated %.0f times per frame", allocs)
+			}
+			if hits == 0 {
+				t.Errorf("no primary ray hit the scene")
+			}
+		})
+	}
+}
+
+// Rays see the atmosphere only inside of its sphere, limited to the range tested.
+func TestSceneAtmosphereInterval(t *testing.T) {
+	var scene = geometry.NewScene()
+	scene.AtmosphereRadius = 10.0
+
+	var tests = []struct {
+		name       string
+		ray        vmath.Ray
+		tmin, tmax float64
+		start, end float64
+		inside     bool
+	}{
+		{"from the center", vmath.NewRay(vmath.NewVec3(0, 0, 0), vmath.NewVec3(1, 0, 0)), 0.0, math.MaxFloat64, 0.0, 10.0, true},
+		{"scaled direction", vmath.NewRay(vmath.NewVec3(0, 0, 0), vmath.NewVec3(0, 2, 0)), 0.0, math.MaxFloat64, 0.0, 5.0, true},
+		{"limited by a surface", vmath.NewRay(vmath.NewVec3(0, 0, 0), vmath.NewVec3(0, 0, 1)), 0.0, 4.0, 0.0, 4.0, true},
+		{"from the outside", vmath.NewRay(vmath.NewVec3(-20, 0, 0), vmath.NewVec3(1, 0, 0)), 0.0, math.MaxFloat64, 10.0, 30.0, true},
+		{"leaving", vmath.NewRay(vmath.NewVec3(-20, 0, 0), vmath.NewVec3(-1, 0, 0)), 0.0, math.MaxFloat64, 0.0, 0.0, false},
+		{"missing", vmath.NewRay(vmath.NewVec3(0, 20, 0), vmath.NewVec3(1, 0, 0)), 0.0, math.MaxFloat64, 0.0, 0.0, false},
+	}
+
+	for _, test := range tests {
+		var start, end, inside = scene.AtmosphereInterval(test.ray, test.tmin, test.tmax)
+		if inside != test.inside || (inside && (math.Abs(start-test.start) > 1e-9 || math.Abs(end-test.end) > 1e-9)) {
+			t.Errorf("%s: got %f %f %t, expected %f %f %t", test.name, start, end, inside, test.start, test.end, test.inside)
+		}
+	}
+}
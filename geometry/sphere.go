@@ -13,13 +13,13 @@ type Sphere struct {
 	Radius float64
 
 	// Center position of the sphere
-	Center *vmath.Vector3
+	Center vmath.Vec3
 
 	// Material used to render the sphere.
 	Material material.Material
 }
 
-func NewSphere(radius float64, center *vmath.Vector3, material material.Material) *Sphere {
+func NewSphere(radius float64, center vmath.Vec3, material material.Material) *Sphere {
 	var s = new(Sphere)
 	s.Radius = radius
 	s.Center = center
@@ -27,14 +27,13 @@ func NewSphere(radius float64, center *vmath.Vector3, material material.Material
 	return s
 }
 
-func (s *Sphere) Hit(ray *vmath.Ray, tmin float64, tmax float64, hitRecord *material.HitRecord) bool {
+func (s *Sphere) Hit(ray vmath.Ray, tmin float64, tmax float64, hitRecord *material.HitRecord) bool {
 
-	var oc = ray.Origin.Clone()
-	oc.Sub(s.Center)
+	var oc = ray.Origin.Sub(s.Center)
 
-	var a = vmath.Dot(ray.Direction, ray.Direction)
-	var b = vmath.Dot(oc, ray.Direction)
-	var c = vmath.Dot(oc, oc) - s.Radius*s.Radius
+	var a = ray.Direction.Dot(ray.Direction)
+	var b = oc.Dot(ray.Direction)
+	var c = oc.Dot(oc) - s.Radius*s.Radius
 	var discriminant = b*b - a*c
 
 	if discriminant > 0 {
@@ -45,9 +44,9 @@ func (s *Sphere) Hit(ray *vmath.Ray, tmin float64, tmax float64, hitRecord *mate
 		if temp < tmax && temp > tmin {
 			hitRecord.T = temp
 			hitRecord.P = ray.PointAtParameter(temp)
-			hitRecord.Normal = hitRecord.P.Clone()
-			hitRecord.Normal.Sub(s.Center)
-			hitRecord.Normal.DivideScalar(s.Radius)
+			var normal = hitRecord.P.Sub(s.Center).DivideScalar(s.Radius)
+			hitRecord.U, hitRecord.V = SphereUV(normal)
+			hitRecord.SetFaceNormal(ray, normal)
 			hitRecord.Material = s.Material
 			return true
 		}
@@ -58,9 +57,9 @@ func (s *Sphere) Hit(ray *vmath.Ray, tmin float64, tmax float64, hitRecord *mate
 		if temp < tmax && temp > tmin {
 			hitRecord.T = temp
 			hitRecord.P = ray.PointAtParameter(temp)
-			hitRecord.Normal = hitRecord.P.Clone()
-			hitRecord.Normal.Sub(s.Center)
-			hitRecord.Normal.DivideScalar(s.Radius)
+			var normal = hitRecord.P.Sub(s.Center).DivideScalar(s.Radius)
+			hitRecord.U, hitRecord.V = SphereUV(normal)
+			hitRecord.SetFaceNormal(ray, normal)
 			hitRecord.Material = s.Material
 			return true
 		}
@@ -70,10 +69,29 @@ func (s *Sphere) Hit(ray *vmath.Ray, tmin float64, tmax float64, hitRecord *mate
 	return false
 }
 
+// Calculate the texture coordinates of a point in a unit sphere centered at the origin.
+// The u coordinate is the angle around the Y axis starting at -X, the v coordinate is the angle from -Y to +Y.
+func SphereUV(p vmath.Vec3) (float64, float64) {
+	var theta = math.Acos(math.Max(-1.0, math.Min(1.0, -p.Y)))
+	var phi = math.Atan2(-p.Z, p.X) + math.Pi
+
+	return phi / (2.0 * math.Pi), theta / math.Pi
+}
+
+// Surface area of the sphere.
+func (s *Sphere) Area() float64 {
+	return 4.0 * math.Pi * s.Radius * s.Radius
+}
+
+func (s *Sphere) BoundingBox() *AABB {
+	var radius = vmath.NewVec3(s.Radius, s.Radius, s.Radius)
+	return NewAABB(s.Center.Sub(radius), s.Center.Add(radius))
+}
+
 func (o *Sphere) Clone() Hitable {
 	var s = new(Sphere)
 	s.Radius = o.Radius
-	s.Center = o.Center.Clone()
+	s.Center = o.Center
 	s.Material = o.Material.Clone()
 	return s
 }
@@ -3,22 +3,42 @@ package geometry
 import (
 	"gotracer/material"
 	"gotracer/vmath"
+	"math"
 )
 
 // Triangle is hittable object represented by three points.
 type Triangle struct {
-	A *vmath.Vector3
-	B *vmath.Vector3
-	C *vmath.Vector3
+	A vmath.Vec3
+	B vmath.Vec3
+	C vmath.Vec3
 
 	// Normal direction of the triangle plane
-	Normal *vmath.Vector3
+	Normal vmath.Vec3
+
+	// Normal of each vertex interpolated for smooth shading, only used if smooth is set.
+	NormalA vmath.Vec3
+	NormalB vmath.Vec3
+	NormalC vmath.Vec3
+
+	// If false the flat normal of the plane is used.
+	Smooth bool
+
+	// Texture coordinates of each vertex (only X and Y are used), only used if textured is set.
+	TexCoordA vmath.Vec3
+	TexCoordB vmath.Vec3
+	TexCoordC vmath.Vec3
+
+	// If false the barycentric coordinates are used as texture coordinates.
+	Textured bool
 
 	// Material used to render the sphere.
 	Material material.Material
+
+	// Faces ignored by the ray intersection, by default the back faces are culled.
+	Cull CullMode
 }
 
-func NewTriangle(a *vmath.Vector3, b *vmath.Vector3, c *vmath.Vector3, material material.Material) *Triangle {
+func NewTriangle(a vmath.Vec3, b vmath.Vec3, c vmath.Vec3, material material.Material) *Triangle {
 	var t = new(Triangle)
 	t.A = a
 	t.B = b
@@ -28,73 +48,145 @@ func NewTriangle(a *vmath.Vector3, b *vmath.Vector3, c *vmath.Vector3, material
 	return t
 }
 
+// Create a smooth shaded triangle, the vertex normals are interpolated across the surface.
+func NewSmoothTriangle(a vmath.Vec3, b vmath.Vec3, c vmath.Vec3, na vmath.Vec3, nb vmath.Vec3, nc vmath.Vec3, material material.Material) *Triangle {
+	var t = NewTriangle(a, b, c, material)
+	t.NormalA = na
+	t.NormalB = nb
+	t.NormalC = nc
+	t.Smooth = true
+	return t
+}
+
 func (triangle *Triangle) GetNormal() {
 
-	var c = triangle.C.Clone()
-	c.Sub(triangle.B)
+	var c = triangle.C.Sub(triangle.B)
+	var a = triangle.A.Sub(triangle.B)
 
-	var a = triangle.A.Clone()
-	a.Sub(triangle.B)
+	// Degenerated triangles keep a zero normal
+	triangle.Normal = c.Cross(a).Normalize()
+}
+
+func (triangle *Triangle) Hit(ray vmath.Ray, tmin float64, tmax float64, hitRecord *material.HitRecord) bool {
+	var t, u, v, front, ok = IntersectTriangle(ray, triangle.A, triangle.B, triangle.C, triangle.Cull, tmin, tmax)
+	if !ok {
+		return false
+	}
 
-	triangle.Normal = vmath.Cross(c, a)
+	hitRecord.T = t
+	hitRecord.P = ray.PointAtParameter(t)
 
-	var targetLengthSq = triangle.Normal.SquaredLength()
-	if targetLengthSq > 0 {
-		triangle.Normal.Normalize()
-		//triangle.Normal.MulScalar(1 / math.Sqrt(targetLengthSq))
+	if triangle.Textured {
+		var w = 1.0 - u - v
+		hitRecord.U = w*triangle.TexCoordA.X + u*triangle.TexCoordB.X + v*triangle.TexCoordC.X
+		hitRecord.V = w*triangle.TexCoordA.Y + u*triangle.TexCoordB.Y + v*triangle.TexCoordC.Y
 	} else {
-		triangle.Normal.Set(0.0, 0.0, 0.0)
+		hitRecord.U = u
+		hitRecord.V = v
 	}
-}
 
-// https://en.wikipedia.org/wiki/M%C3%B6ller%E2%80%93Trumbore_intersection_algorithm
-func (triangle *Triangle) Hit(ray *vmath.Ray, tmin float64, tmax float64, hitRecord *material.HitRecord) bool {
-	var v0v1 *vmath.Vector3 = triangle.B.Clone()
-	v0v1.Sub(triangle.A)
+	// The shading normal is flipped together with the face normal
+	var normal = triangle.Normal
+	if triangle.Smooth {
+		normal = triangle.InterpolateNormal(u, v)
+	}
 
-	var v0v2 *vmath.Vector3 = triangle.C.Clone()
-	v0v2.Sub(triangle.A)
+	hitRecord.FrontFace = front
+	if !front {
+		normal = normal.Negate()
+	}
+	hitRecord.Normal = normal
+	hitRecord.Material = triangle.Material
 
-	var pvec *vmath.Vector3 = vmath.Cross(ray.Direction, v0v2)
-	var det = vmath.Dot(v0v1, pvec)
-	if det < 0.000001 {
-		return false
+	return true
+}
+
+// Intersect a ray with the triangle abc, faces are ignored according to the cull mode.
+// Returns the distance, the barycentric coordinates of the hit relative to b and c, and if the ray hit the front face.
+//
+// https://en.wikipedia.org/wiki/M%C3%B6ller%E2%80%93Trumbore_intersection_algorithm
+func IntersectTriangle(ray vmath.Ray, a vmath.Vec3, b vmath.Vec3, c vmath.Vec3, cull CullMode, tmin float64, tmax float64) (float64, float64, float64, bool, bool) {
+	var v0v1 = b.Sub(a)
+	var v0v2 = c.Sub(a)
+	var pvec = ray.Direction.Cross(v0v2)
+
+	// The determinant is positive when the ray arrives from the front side
+	var det = v0v1.Dot(pvec)
+	if cull == CullBack && det < 0.000001 {
+		return 0, 0, 0, false, false
+	} else if cull == CullFront && det > -0.000001 {
+		return 0, 0, 0, false, false
+	} else if math.Abs(det) < 0.000001 {
+		return 0, 0, 0, false, false
 	}
 
 	var invDet = 1.0 / det
-	var tvec *vmath.Vector3 = ray.Origin.Clone()
-	tvec.Sub(triangle.A)
+	var tvec = ray.Origin.Sub(a)
 
-	var u = vmath.Dot(tvec, pvec) * invDet
+	var u = tvec.Dot(pvec) * invDet
 	if u < 0 || u > 1 {
-		return false
+		return 0, 0, 0, false, false
 	}
 
-	var qvec *vmath.Vector3 = vmath.Cross(tvec, v0v1)
-	var v = vmath.Dot(ray.Direction, qvec) * invDet
+	var qvec = tvec.Cross(v0v1)
+	var v = ray.Direction.Dot(qvec) * invDet
 	if v < 0 || u+v > 1 {
-		return false
+		return 0, 0, 0, false, false
 	}
 
-	var t = vmath.Dot(v0v2, qvec) * invDet
-
+	var t = v0v2.Dot(qvec) * invDet
 	if t < tmax && t > tmin {
-		hitRecord.T = t
-		hitRecord.P = ray.PointAtParameter(t)
-		hitRecord.Normal = triangle.Normal.Clone()
-		hitRecord.Material = triangle.Material
-		return true
+		return t, u, v, det > 0, true
 	}
 
-	return false
+	return 0, 0, 0, false, false
+}
+
+// Interpolate the vertex normals using the barycentric coordinates of the point.
+// Falls back to the flat normal if the interpolated normal is degenerated.
+func (triangle *Triangle) InterpolateNormal(u float64, v float64) vmath.Vec3 {
+	var w = 1.0 - u - v
+	var normal = vmath.NewVec3(
+		w*triangle.NormalA.X+u*triangle.NormalB.X+v*triangle.NormalC.X,
+		w*triangle.NormalA.Y+u*triangle.NormalB.Y+v*triangle.NormalC.Y,
+		w*triangle.NormalA.Z+u*triangle.NormalB.Z+v*triangle.NormalC.Z,
+	)
+
+	if normal.SquaredLength() == 0.0 {
+		return triangle.Normal
+	}
+
+	return normal.Normalize()
+}
+
+// Surface area of the triangle.
+func (triangle *Triangle) Area() float64 {
+	return triangle.B.Sub(triangle.A).Cross(triangle.C.Sub(triangle.A)).Length() / 2.0
+}
+
+func (triangle *Triangle) BoundingBox() *AABB {
+	var box = NewEmptyAABB()
+	box.ExpandByPoint(triangle.A)
+	box.ExpandByPoint(triangle.B)
+	box.ExpandByPoint(triangle.C)
+	return box
 }
 
 func (triangle *Triangle) Clone() Hitable {
 	var s = new(Triangle)
-	s.A = triangle.A.Clone()
-	s.B = triangle.B.Clone()
-	s.C = triangle.C.Clone()
-	s.Normal = triangle.Normal.Clone()
+	s.A = triangle.A
+	s.B = triangle.B
+	s.C = triangle.C
+	s.Normal = triangle.Normal
+	s.NormalA = triangle.NormalA
+	s.NormalB = triangle.NormalB
+	s.NormalC = triangle.NormalC
+	s.Smooth = triangle.Smooth
+	s.TexCoordA = triangle.TexCoordA
+	s.TexCoordB = triangle.TexCoordB
+	s.TexCoordC = triangle.TexCoordC
+	s.Textured = triangle.Textured
 	s.Material = triangle.Material.Clone()
+	s.Cull = triangle.Cull
 	return s
 }
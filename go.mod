@@ -4,6 +4,5 @@ go 1.21.6
 
 require (
 	github.com/gopxl/pixel/v2 v2.3.0
-	github.com/sheenobu/go-obj/obj v0.0.0-20190106231111-fb5ef7341b74
-	golang.org/x/image v0.15.0
+	golang.org/x/image v0.19.0
 )
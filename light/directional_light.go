@@ -0,0 +1,45 @@
+package light
+
+import (
+	"gotracer/sampler"
+	"gotracer/vmath"
+	"math"
+)
+
+// Directional light simulates a light very far away (e.g. the sun), all rays arrive in the same direction.
+type DirectionalLight struct {
+	// Direction in which the light travels.
+	Direction vmath.Vec3
+
+	// Color of the light.
+	Color vmath.Vec3
+
+	// Irradiance of the light on a surface perpendicular to the direction.
+	Intensity float64
+}
+
+func NewDirectionalLight(direction vmath.Vec3, color vmath.Vec3, intensity float64) *DirectionalLight {
+	var l = new(DirectionalLight)
+	l.Direction = direction
+	l.Color = color
+	l.Intensity = intensity
+	return l
+}
+
+func (l *DirectionalLight) Sample(point vmath.Vec3, sampler sampler.Sampler) (vmath.Vec3, float64, vmath.Vec3, float64) {
+	var direction = l.Direction.Normalize().Negate()
+
+	return direction, math.Inf(1), emission(l.Color, l.Intensity), 1.0
+}
+
+func (l *DirectionalLight) Intersect(ray vmath.Ray, tmin float64, tmax float64) (float64, vmath.Vec3, float64, bool) {
+	return 0.0, vmath.Vec3{}, 0.0, false
+}
+
+func (l *DirectionalLight) IsDelta() bool {
+	return true
+}
+
+func (o *DirectionalLight) Clone() Light {
+	return NewDirectionalLight(o.Direction, o.Color, o.Intensity)
+}
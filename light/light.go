@@ -0,0 +1,46 @@
+package light
+
+import (
+	"gotracer/sampler"
+	"gotracer/vmath"
+)
+
+// Light is a explicit light source that can be sampled directly by the integrator.
+//
+// Sampling the lights directly (next event estimation) is much more efficient than waiting for random bounces to hit a emitter.
+type Light interface {
+	// Sample a direction from the point towards the light, area lights use the sampler to choose a point in their surface.
+	// Returns the normalized direction, the distance to the sampled point in the light, the radiance arriving at the point and the probability density of the sample.
+	// The density is measured in solid angle for area lights and is 1 for delta lights (point, spot and directional).
+	// If the light does not illuminate the point the radiance is black or the density is zero.
+	Sample(point vmath.Vec3, sampler sampler.Sampler) (direction vmath.Vec3, distance float64, radiance vmath.Vec3, pdf float64)
+
+	// Intersect the ray with the light shape, used to account for lights hit by rays generated by the materials.
+	// Returns the distance, the radiance emitted towards the ray origin and the solid angle density of Sample generating that direction.
+	// Delta lights have no shape and are never hit.
+	Intersect(ray vmath.Ray, tmin float64, tmax float64) (distance float64, radiance vmath.Vec3, pdf float64, hit bool)
+
+	// Indicates if the light is described by a delta distribution (has no area) and cannot be hit by rays.
+	IsDelta() bool
+
+	// Clone object create a new object with the same properties.
+	Clone() Light
+}
+
+// Power heuristic (beta = 2) used to combine light sampling and material sampling with multiple importance sampling.
+// Receives the density of the strategy used to generate the sample and the density of the other strategy.
+func PowerHeuristic(pdf float64, other float64) float64 {
+	var a = pdf * pdf
+	var b = other * other
+
+	if a+b == 0 {
+		return 0.0
+	}
+
+	return a / (a + b)
+}
+
+// Create the radiance of a light from its color and intensity.
+func emission(color vmath.Vec3, intensity float64) vmath.Vec3 {
+	return color.MulScalar(intensity)
+}
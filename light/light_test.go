@@ -0,0 +1,128 @@
+package light
+
+import (
+	"gotracer/sampler"
+	"gotracer/vmath"
+	"math"
+	"testing"
+)
+
+// Number of samples taken from each light by the tests.
+const testSamples = 20000
+
+// Point where the lights are sampled from.
+var testPoint = vmath.NewVec3(0.3, -0.2, 0.1)
+
+// Area lights tested, all of them visible from the test point.
+var testAreaLights = map[string]Light{
+	"sphere":           NewSphereLight(vmath.NewVec3(0.0, 3.0, 0.5), 1.0, vmath.NewVec3(1.0, 0.9, 0.8), 5.0),
+	"sphere-close":     NewSphereLight(vmath.NewVec3(1.5, 0.0, 0.0), 1.0, vmath.NewVec3(1.0, 1.0, 1.0), 2.0),
+	"rectangle":        NewRectangleLight(vmath.NewVec3(-1.0, 2.0, -1.0), vmath.NewVec3(2.0, 0.0, 0.0), vmath.NewVec3(0.0, 0.0, 2.0), vmath.NewVec3(1.0, 1.0, 1.0), 4.0),
+	"rectangle-tilted": NewRectangleLight(vmath.NewVec3(-2.0, 1.0, -3.0), vmath.NewVec3(3.0, 0.0, 0.0), vmath.NewVec3(0.0, 2.0, 1.0), vmath.NewVec3(0.5, 0.7, 1.0), 3.0),
+}
+
+// Directions sampled from a area light hit the light with the same density and radiance returned by Intersect.
+func TestAreaLightIntersect(t *testing.T) {
+	for name, l := range testAreaLights {
+		t.Run(name, func(t *testing.T) {
+			var s = sampler.NewIndependentSampler(1)
+
+			for i := 0; i < 1000; i++ {
+				s.StartPixelSample(i, 0, 0)
+
+				var direction, distance, radiance, pdf = l.Sample(testPoint, s)
+				if pdf <= 0 {
+					t.Fatalf("direction %s sampled with density %f", direction.ToString(), pdf)
+				}
+
+				var hitDistance, hitRadiance, hitPdf, hit = l.Intersect(vmath.NewRay(testPoint, direction), 1e-6, math.MaxFloat64)
+				if !hit {
+					t.Fatalf("sampled direction %s does not hit the light", direction.ToString())
+				}
+				if math.Abs(hitDistance-distance) > 1e-6*distance || math.Abs(hitPdf-pdf) > 1e-6*pdf || hitRadiance != radiance {
+					t.Fatalf("direction %s sampled at %f with density %f, intersected at %f with density %f", direction.ToString(), distance, pdf, hitDistance, hitPdf)
+				}
+			}
+		})
+	}
+}
+
+// The density of the samples is measured in solid angle, the average of its inverse is the solid angle covered by the light.
+// The solid angle is measured by the fraction of uniform directions on the sphere that hit the light.
+func TestAreaLightSolidAngle(t *testing.T) {
+	for name, l := range testAreaLights {
+		t.Run(name, func(t *testing.T) {
+			var s = sampler.NewIndependentSampler(2)
+
+			var inverse = 0.0
+			var hits = 0
+
+			for i := 0; i < testSamples; i++ {
+				s.StartPixelSample(i, 0, 0)
+
+				var _, _, _, pdf = l.Sample(testPoint, s)
+				inverse += 1.0 / pdf
+
+				var u, v = s.Get2D()
+				var cosine = 1.0 - 2.0*u
+				var sine = math.Sqrt(math.Max(0.0, 1.0-cosine*cosine))
+				var direction = vmath.NewVec3(sine*math.Cos(2.0*math.Pi*v), sine*math.Sin(2.0*math.Pi*v), cosine)
+				if _, _, _, hit := l.Intersect(vmath.NewRay(testPoint, direction), 1e-6, math.MaxFloat64); hit {
+					hits++
+				}
+			}
+
+			var expected = 4.0 * math.Pi * float64(hits) / testSamples
+			var solidAngle = inverse / testSamples
+			if math.Abs(solidAngle-expected) > 0.05*expected {
+				t.Errorf("solid angle from the density is %f, expected %f", solidAngle, expected)
+			}
+		})
+	}
+}
+
+// The weights of the light and material sampling strategies of the same sample sum to one.
+func TestPowerHeuristic(t *testing.T) {
+	var densities = []float64{0.01, 0.25, 1.0, 3.0, 100.0}
+
+	for _, a := range densities {
+		for _, b := range densities {
+			if sum := PowerHeuristic(a, b) + PowerHeuristic(b, a); math.Abs(sum-1.0) > 1e-12 {
+				t.Errorf("weights of the densities %f and %f sum to %f", a, b, sum)
+			}
+		}
+	}
+
+	if w := PowerHeuristic(0.0, 0.0); w != 0.0 {
+		t.Errorf("weight without density is %f", w)
+	}
+	if w := PowerHeuristic(1.0, 0.0); w != 1.0 {
+		t.Errorf("weight when the other strategy cannot generate the sample is %f", w)
+	}
+}
+
+// The light of point and spot lights falls off with the square of the distance.
+func TestDeltaLightFalloff(t *testing.T) {
+	var lights = map[string]Light{
+		"point": NewPointLight(vmath.NewVec3(0.0, 4.0, 0.0), vmath.NewVec3(1.0, 0.5, 0.25), 10.0),
+		"spot":  NewSpotLight(vmath.NewVec3(0.0, 4.0, 0.0), vmath.NewVec3(0.0, -1.0, 0.0), vmath.NewVec3(1.0, 0.5, 0.25), 10.0, 30.0, 20.0),
+	}
+
+	for name, l := range lights {
+		var s = sampler.NewIndependentSampler(3)
+
+		for _, distance := range []float64{0.5, 1.0, 2.0, 3.0} {
+			var point = vmath.NewVec3(0.0, 4.0-distance, 0.0)
+
+			var direction, d, radiance, pdf = l.Sample(point, s)
+			if pdf != 1.0 || math.Abs(d-distance) > 1e-12 || direction.Sub(vmath.NewVec3(0.0, 1.0, 0.0)).Length() > 1e-12 {
+				t.Errorf("%s: sampled %s at %f with density %f from the distance %f", name, direction.ToString(), d, pdf, distance)
+			}
+
+			var expected = vmath.NewVec3(1.0, 0.5, 0.25).MulScalar(10.0 / (distance * distance))
+			if radiance.Sub(expected).Length() > 1e-12 {
+				t.Errorf("%s: light at the distance %f is %s, expected %s", name, distance, radiance.ToString(), expected.ToString())
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package light
+
+import (
+	"gotracer/sampler"
+	"gotracer/vmath"
+)
+
+// Point light emits light equally in all directions from a single point.
+// The light arriving to a point decreases with the square of the distance.
+type PointLight struct {
+	// Position of the light.
+	Position vmath.Vec3
+
+	// Color of the light.
+	Color vmath.Vec3
+
+	// Radiant intensity of the light (power per solid angle).
+	Intensity float64
+}
+
+func NewPointLight(position vmath.Vec3, color vmath.Vec3, intensity float64) *PointLight {
+	var l = new(PointLight)
+	l.Position = position
+	l.Color = color
+	l.Intensity = intensity
+	return l
+}
+
+func (l *PointLight) Sample(point vmath.Vec3, sampler sampler.Sampler) (vmath.Vec3, float64, vmath.Vec3, float64) {
+	var direction = l.Position.Sub(point)
+
+	var distanceSq = direction.SquaredLength()
+	var distance = direction.Length()
+	direction = direction.DivideScalar(distance)
+
+	var radiance = emission(l.Color, l.Intensity/distanceSq)
+
+	return direction, distance, radiance, 1.0
+}
+
+func (l *PointLight) Intersect(ray vmath.Ray, tmin float64, tmax float64) (float64, vmath.Vec3, float64, bool) {
+	return 0.0, vmath.Vec3{}, 0.0, false
+}
+
+func (l *PointLight) IsDelta() bool {
+	return true
+}
+
+func (o *PointLight) Clone() Light {
+	return NewPointLight(o.Position, o.Color, o.Intensity)
+}
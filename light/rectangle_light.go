@@ -0,0 +1,113 @@
+package light
+
+import (
+	"gotracer/sampler"
+	"gotracer/vmath"
+	"math"
+)
+
+// Rectangular area light, represented by a corner and two perpendicular edges.
+// Light is emitted only from the front face, the front is the side where the cross product of the edges (U x V) points.
+type RectangleLight struct {
+	// Corner of the rectangle.
+	Corner vmath.Vec3
+
+	// Edges of the rectangle starting in the corner.
+	U vmath.Vec3
+	V vmath.Vec3
+
+	// Color of the light.
+	Color vmath.Vec3
+
+	// Radiance emitted by the surface of the rectangle.
+	Intensity float64
+}
+
+func NewRectangleLight(corner vmath.Vec3, u vmath.Vec3, v vmath.Vec3, color vmath.Vec3, intensity float64) *RectangleLight {
+	var l = new(RectangleLight)
+	l.Corner = corner
+	l.U = u
+	l.V = v
+	l.Color = color
+	l.Intensity = intensity
+	return l
+}
+
+// Area of the rectangle.
+func (l *RectangleLight) Area() float64 {
+	return l.U.Cross(l.V).Length()
+}
+
+// Normal of the front face of the rectangle.
+func (l *RectangleLight) Normal() vmath.Vec3 {
+	return l.U.Cross(l.V).Normalize()
+}
+
+// Convert a area density into a solid angle density for a point in the light.
+// Returns zero if the point sees the back of the light.
+func (l *RectangleLight) solidAngle(direction vmath.Vec3, distance float64) float64 {
+	var cosine = -direction.Dot(l.Normal()) / direction.Length()
+	if cosine <= 0 {
+		return 0.0
+	}
+
+	return distance * distance / (cosine * l.Area())
+}
+
+// Sample a point uniformly in the area of the rectangle.
+func (l *RectangleLight) Sample(point vmath.Vec3, sampler sampler.Sampler) (vmath.Vec3, float64, vmath.Vec3, float64) {
+	var u, v = sampler.Get2D()
+
+	var direction = l.Corner.AddScaled(l.U, u).AddScaled(l.V, v).Sub(point)
+
+	var distance = direction.Length()
+	direction = direction.DivideScalar(distance)
+
+	var pdf = l.solidAngle(direction, distance)
+	if pdf == 0 {
+		return direction, distance, vmath.Vec3{}, 0.0
+	}
+
+	return direction, distance, emission(l.Color, l.Intensity), pdf
+}
+
+func (l *RectangleLight) Intersect(ray vmath.Ray, tmin float64, tmax float64) (float64, vmath.Vec3, float64, bool) {
+	var normal = l.U.Cross(l.V)
+	var denominator = normal.Dot(ray.Direction)
+	if math.Abs(denominator) < 1e-12 {
+		return 0.0, vmath.Vec3{}, 0.0, false
+	}
+
+	var offset = l.Corner.Sub(ray.Origin)
+
+	var t = normal.Dot(offset) / denominator
+	if t <= tmin || t >= tmax {
+		return 0.0, vmath.Vec3{}, 0.0, false
+	}
+
+	// Coordinates of the hit point in the rectangle plane
+	var p = ray.PointAtParameter(t).Sub(l.Corner)
+
+	var a = p.Dot(l.U) / l.U.SquaredLength()
+	var b = p.Dot(l.V) / l.V.SquaredLength()
+	if a < 0 || a > 1 || b < 0 || b > 1 {
+		return 0.0, vmath.Vec3{}, 0.0, false
+	}
+
+	var distance = t * ray.Direction.Length()
+	var pdf = l.solidAngle(ray.Direction, distance)
+	if pdf == 0 {
+		// Back face does not emit light but still blocks the ray
+		return t, vmath.Vec3{}, 0.0, true
+	}
+
+	return t, emission(l.Color, l.Intensity), pdf, true
+}
+
+func (l *RectangleLight) IsDelta() bool {
+	return false
+}
+
+func (o *RectangleLight) Clone() Light {
+	return NewRectangleLight(o.Corner, o.U, o.V, o.Color, o.Intensity)
+}
@@ -0,0 +1,120 @@
+package light
+
+import (
+	"gotracer/sampler"
+	"gotracer/vmath"
+	"math"
+)
+
+// Spherical area light, the surface of the sphere emits light uniformly in all directions.
+type SphereLight struct {
+	// Center of the sphere.
+	Center vmath.Vec3
+
+	// Radius of the sphere.
+	Radius float64
+
+	// Color of the light.
+	Color vmath.Vec3
+
+	// Radiance emitted by the surface of the sphere.
+	Intensity float64
+}
+
+func NewSphereLight(center vmath.Vec3, radius float64, color vmath.Vec3, intensity float64) *SphereLight {
+	var l = new(SphereLight)
+	l.Center = center
+	l.Radius = radius
+	l.Color = color
+	l.Intensity = intensity
+	return l
+}
+
+// Cosine of the half angle of the cone that contains the sphere as seen from a point.
+// Returns false if the point is inside the sphere.
+func (l *SphereLight) cone(point vmath.Vec3) (float64, bool) {
+	var toCenter = l.Center.Sub(point)
+
+	var distanceSq = toCenter.SquaredLength()
+	var radiusSq = l.Radius * l.Radius
+	if distanceSq <= radiusSq {
+		return 0.0, false
+	}
+
+	return math.Sqrt(1.0 - radiusSq/distanceSq), true
+}
+
+// Sample a direction uniformly inside of the cone that contains the sphere.
+func (l *SphereLight) Sample(point vmath.Vec3, sampler sampler.Sampler) (vmath.Vec3, float64, vmath.Vec3, float64) {
+	var r1, r2 = sampler.Get2D()
+
+	var cosMax, outside = l.cone(point)
+	if !outside {
+		return vmath.NewVec3(0.0, 1.0, 0.0), 0.0, vmath.Vec3{}, 0.0
+	}
+
+	var w = l.Center.Sub(point).Normalize()
+	var u, v = vmath.OrthonormalBasis(w)
+
+	var cosTheta = 1.0 + r1*(cosMax-1.0)
+	var sinTheta = math.Sqrt(math.Max(0.0, 1.0-cosTheta*cosTheta))
+	var phi = 2.0 * math.Pi * r2
+
+	var direction = u.MulScalar(math.Cos(phi)*sinTheta).AddScaled(v, math.Sin(phi)*sinTheta).AddScaled(w, cosTheta)
+
+	var distance, hit = l.distance(vmath.NewRay(point, direction), 0.0, math.MaxFloat64)
+	if !hit {
+		return direction, 0.0, vmath.Vec3{}, 0.0
+	}
+
+	return direction, distance, emission(l.Color, l.Intensity), 1.0 / (2.0 * math.Pi * (1.0 - cosMax))
+}
+
+// Distance from the ray origin to the surface of the sphere.
+func (l *SphereLight) distance(ray vmath.Ray, tmin float64, tmax float64) (float64, bool) {
+	var oc = ray.Origin.Sub(l.Center)
+
+	var a = ray.Direction.Dot(ray.Direction)
+	var b = oc.Dot(ray.Direction)
+	var c = oc.Dot(oc) - l.Radius*l.Radius
+	var discriminant = b*b - a*c
+
+	if discriminant < 0 {
+		return 0.0, false
+	}
+
+	var t = (-b - math.Sqrt(discriminant)) / a
+	if t < tmax && t > tmin {
+		return t, true
+	}
+
+	t = (-b + math.Sqrt(discriminant)) / a
+	if t < tmax && t > tmin {
+		return t, true
+	}
+
+	return 0.0, false
+}
+
+func (l *SphereLight) Intersect(ray vmath.Ray, tmin float64, tmax float64) (float64, vmath.Vec3, float64, bool) {
+	var t, hit = l.distance(ray, tmin, tmax)
+	if !hit {
+		return 0.0, vmath.Vec3{}, 0.0, false
+	}
+
+	var pdf = 0.0
+	var cosMax, outside = l.cone(ray.Origin)
+	if outside {
+		pdf = 1.0 / (2.0 * math.Pi * (1.0 - cosMax))
+	}
+
+	return t, emission(l.Color, l.Intensity), pdf, true
+}
+
+func (l *SphereLight) IsDelta() bool {
+	return false
+}
+
+func (o *SphereLight) Clone() Light {
+	return NewSphereLight(o.Center, o.Radius, o.Color, o.Intensity)
+}
@@ -0,0 +1,83 @@
+package light
+
+import (
+	"gotracer/sampler"
+	"gotracer/vmath"
+	"math"
+)
+
+// Spot light emits light from a point in a cone of directions.
+// The intensity decreases smoothly from the falloff angle to the total angle of the cone.
+type SpotLight struct {
+	// Position of the light.
+	Position vmath.Vec3
+
+	// Direction where the light is pointing.
+	Direction vmath.Vec3
+
+	// Color of the light.
+	Color vmath.Vec3
+
+	// Radiant intensity of the light in the center of the cone.
+	Intensity float64
+
+	// Angle in degrees between the direction and the border of the cone.
+	Angle float64
+
+	// Angle in degrees where the intensity starts to decrease.
+	FalloffAngle float64
+}
+
+func NewSpotLight(position vmath.Vec3, direction vmath.Vec3, color vmath.Vec3, intensity float64, angle float64, falloffAngle float64) *SpotLight {
+	var l = new(SpotLight)
+	l.Position = position
+	l.Direction = direction
+	l.Color = color
+	l.Intensity = intensity
+	l.Angle = angle
+	l.FalloffAngle = falloffAngle
+	return l
+}
+
+func (l *SpotLight) Sample(point vmath.Vec3, sampler sampler.Sampler) (vmath.Vec3, float64, vmath.Vec3, float64) {
+	var direction = l.Position.Sub(point)
+
+	var distanceSq = direction.SquaredLength()
+	var distance = direction.Length()
+	direction = direction.DivideScalar(distance)
+
+	// Angle between the spot direction and the direction from the light to the point
+	var cosine = -direction.Dot(l.Direction.Normalize())
+	var radiance = emission(l.Color, l.Intensity*l.falloff(cosine)/distanceSq)
+
+	return direction, distance, radiance, 1.0
+}
+
+// Calculate the intensity factor for a direction with a angle cosine to the direction of the light.
+func (l *SpotLight) falloff(cosine float64) float64 {
+	var cosTotal = math.Cos(l.Angle * math.Pi / 180.0)
+	var cosFalloff = math.Cos(math.Min(l.FalloffAngle, l.Angle) * math.Pi / 180.0)
+
+	if cosine < cosTotal {
+		return 0.0
+	}
+	if cosine >= cosFalloff {
+		return 1.0
+	}
+
+	// Smoothstep between the two angles
+	var t = (cosine - cosTotal) / (cosFalloff - cosTotal)
+	return t * t * (3.0 - 2.0*t)
+}
+
+func (l *SpotLight) Intersect(ray vmath.Ray, tmin float64, tmax float64) (float64, vmath.Vec3, float64, bool) {
+	return 0.0, vmath.Vec3{}, 0.0, false
+}
+
+func (l *SpotLight) IsDelta() bool {
+	return true
+}
+
+func (o *SpotLight) Clone() Light {
+	return NewSpotLight(o.Position, o.Direction, o.Color, o.Intensity, o.Angle, o.FalloffAngle)
+}
@@ -1,51 +1,35 @@
 package main
 
 import (
-	"bytes"
+	"bufio"
+	"context"
+	"flag"
 	"gotracer/camera"
+	"gotracer/framebuffer"
 	"gotracer/geometry"
+	"gotracer/light"
 	"gotracer/material"
+	"gotracer/objfile"
+	"gotracer/sampler"
+	"gotracer/scheduler"
+	"gotracer/spectrum"
 	"gotracer/vmath"
-	"io/ioutil"
+	"image/jpeg"
+	"image/png"
 	"log"
 	"math"
-	"math/rand"
 	"os"
 	"strconv"
 	"sync"
-	"time"
 
 	"github.com/gopxl/pixel/v2"
-	"github.com/gopxl/pixel/v2/pixelgl"
-	"github.com/sheenobu/go-obj/obj"
-	"golang.org/x/image/colornames"
 )
 
-// Render size
-const Width float64 = 640.0
-const Height float64 = 480.0
-const Upscale float64 = 1.0
+// Relative distance to the light ignored by the shadow rays, avoids the light hitting itself
+const ShadowEpsilon = 1e-4
 
-// Max raytracing recursive depth
-const MaxDepth int64 = 50
-
-// Minimum distance to be considerd for ray collision
-const MinDistance float64 = 1e-5
-
-// If true multiple rays are casted and blended for each pixel
-const Antialiasing = false
-
-// If true the last n Frames are blended
-const TemporalFilter = true
-const TemporalFilterSamples = 32
-
-// If true splits the image generation into threads
-const Multithreaded = true
-const MultithreadedTheads = 4
-const MultithreadDataCopies = false
-
-// Temporal acomulation buffers
-var Frames []*pixel.PictureData
+// Accumulation buffer, samples are accumulated while the camera is not moved
+var Buffer *framebuffer.Framebuffer
 
 // Scene and camera copies for threads
 var SceneCopies []*geometry.Scene
@@ -53,382 +37,700 @@ var CameraCopies []*camera.CameraDefocus
 
 func main() {
 	//runtime.GOMAXPROCS(8)
-	pixelgl.Run(run)
+
+	// Headless render subcommand, does not create any window
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		var err = RenderCommand(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var flags = flag.NewFlagSet("gotracer", flag.ExitOnError)
+	var sceneFile = flags.String("scene", "", "Scene description file to render, the default scene is used if empty.")
+
+	var settings, err = ParseRenderSettings(flags, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = RunWindow(settings, *sceneFile)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
-func run() {
-	var bounds = pixel.R(0, 0, Width, Height)
-	var windowBounds = pixel.R(0, 0, Width*Upscale, Height*Upscale)
+// Create the demo scene rendered by default.
+// The objects are placed randomly, the same seed always creates the same scene.
+func CreateScene(seed int64) *geometry.Scene {
+	var random = sampler.NewRandom(uint64(seed))
 
 	// Prepare the scene
 	var scene = geometry.NewScene()
-	scene.Add(geometry.NewSphere(500.0, vmath.NewVector3(0.0, -500.5, -1.0), material.NewLightMaterial(vmath.NewVector3(0.4, 0.7, 0.0))))
-	scene.Add(geometry.NewSphere(0.5, vmath.NewVector3(-1.0, 0.0, -3.0), material.NewNormalMaterial()))
-	scene.Add(geometry.NewSphere(1.5, vmath.NewVector3(5.0, 1.0, -6.0), material.NewDieletricMaterial(1.3, vmath.NewVector3(0.90, 0.90, 0.90))))
-	scene.Add(geometry.NewSphere(1.5, vmath.NewVector3(-1.0, 1.0, -3.0), material.NewMetalMaterial(vmath.NewVector3(0.6, 0.6, 0.6), 0.1)))
+	scene.Add(geometry.NewSphere(500.0, vmath.NewVec3(0.0, -500.5, -1.0), material.NewLambertMaterial(vmath.NewVec3(0.4, 0.7, 0.0))))
+	scene.Add(geometry.NewSphere(0.5, vmath.NewVec3(-1.0, 0.0, -3.0), material.NewNormalMaterial()))
+	scene.Add(geometry.NewSphere(1.5, vmath.NewVec3(5.0, 1.0, -6.0), material.NewDieletricMaterial(1.3, vmath.NewVec3(0.90, 0.90, 0.90))))
+	scene.Add(geometry.NewSphere(1.5, vmath.NewVec3(-1.0, 1.0, -3.0), material.NewMetalMaterial(vmath.NewVec3(0.6, 0.6, 0.6), 0.1)))
 
 	var min = 15.0
 	var distance = 30.0
 
-	//LoadOBJ(scene, "bunny.obj", material.NewLightMaterial(vmath.NewVector3(0.90, 0.9, 0.9)))
+	//CheckError(LoadOBJ(scene, "bunny.obj", material.NewLambertMaterial(vmath.NewVec3(0.90, 0.9, 0.9))))
 
 	// Place random sphere objects
 	for i := 0; i < 40; i++ {
-		var radius = 0.4 + rand.Float64()*0.2
-		var position = vmath.NewVector3(rand.Float64()*distance-min, radius-0.5, rand.Float64()*distance-min)
-		scene.Add(geometry.NewSphere(radius, position, material.NewLightMaterial(vmath.NewRandomVector3(0.1, 1))))
+		var radius = 0.4 + random.Float64()*0.2
+		var position = vmath.NewVec3(random.Float64()*distance-min, radius-0.5, random.Float64()*distance-min)
+		scene.Add(geometry.NewSphere(radius, position, material.NewLambertMaterial(vmath.RandomVec3(random, 0.1, 1))))
 
-		radius = 0.4 + rand.Float64()*0.2
-		position = vmath.NewVector3(rand.Float64()*distance-min, radius-0.5, rand.Float64()*distance-min)
-		scene.Add(geometry.NewSphere(radius, position, material.NewMetalMaterial(vmath.NewRandomVector3(0.1, 1), rand.Float64())))
+		radius = 0.4 + random.Float64()*0.2
+		position = vmath.NewVec3(random.Float64()*distance-min, radius-0.5, random.Float64()*distance-min)
+		scene.Add(geometry.NewSphere(radius, position, material.NewMetalMaterial(vmath.RandomVec3(random, 0.1, 1), random.Float64())))
 
-		radius = 0.4 + rand.Float64()*0.2
-		position = vmath.NewVector3(rand.Float64()*distance-min, radius-0.5, rand.Float64()*distance-min)
-		scene.Add(geometry.NewSphere(radius, position, material.NewDieletricMaterial(2.0*rand.Float64(), vmath.NewRandomVector3(0.95, 1.0))))
+		radius = 0.4 + random.Float64()*0.2
+		position = vmath.NewVec3(random.Float64()*distance-min, radius-0.5, random.Float64()*distance-min)
+		scene.Add(geometry.NewSphere(radius, position, material.NewDieletricMaterial(2.0*random.Float64(), vmath.RandomVec3(random, 0.95, 1.0))))
 	}
 
 	// Random triangles
 	for i := 0; i < 0; i++ {
 		var size float64 = 1.0
-		var position = vmath.NewVector3(rand.Float64()*distance-min, size/2.0-0.5, rand.Float64()*distance-min)
+		var position = vmath.NewVec3(random.Float64()*distance-min, size/2.0-0.5, random.Float64()*distance-min)
 
-		var a = position.Clone()
-		a.Add(vmath.NewVector3(0.0, size, 0.0))
-		var b = position.Clone()
-		b.Add(vmath.NewVector3(-size/1.5, 0, 0.0))
-		var c = position.Clone()
-		c.Add(vmath.NewVector3(size/1.5, 0, 0.0))
+		var a = position.Add(vmath.NewVec3(0.0, size, 0.0))
+		var b = position.Add(vmath.NewVec3(-size/1.5, 0, 0.0))
+		var c = position.Add(vmath.NewVec3(size/1.5, 0, 0.0))
 
-		scene.Add(geometry.NewTriangle(a, b, c, material.NewLightMaterial(vmath.NewRandomVector3(0.1, 1))))
+		scene.Add(geometry.NewTriangle(a, b, c, material.NewLambertMaterial(vmath.RandomVec3(random, 0.1, 1))))
 	}
 
-	var halfSize = vmath.NewVector3(0.5, 0.5, 0.5)
+	var halfSize = vmath.NewVec3(0.5, 0.5, 0.5)
 
 	//Place random box objects
 	for i := 0; i < 10; i++ {
-		var position = vmath.NewVector3(rand.Float64()*distance-min, halfSize.Y-0.5, rand.Float64()*distance-min)
-		var bmin = position.Clone()
-		bmin.Sub(halfSize)
-		var bmax = position.Clone()
-		bmax.Add(halfSize)
-		scene.Add(geometry.NewBox(bmin, bmax, material.NewLightMaterial(vmath.NewRandomVector3(0.1, 1))))
+		var position = vmath.NewVec3(random.Float64()*distance-min, halfSize.Y-0.5, random.Float64()*distance-min)
+		scene.Add(geometry.NewBox(position.Sub(halfSize), position.Add(halfSize), material.NewLambertMaterial(vmath.RandomVec3(random, 0.1, 1))))
 
-		position = vmath.NewVector3(rand.Float64()*distance-min, halfSize.Y-0.5, rand.Float64()*distance-min)
-		bmin = position.Clone()
-		bmin.Sub(halfSize)
-		bmax = position.Clone()
-		bmax.Add(halfSize)
-		scene.Add(geometry.NewBox(bmin, bmax, material.NewMetalMaterial(vmath.NewRandomVector3(0.6, 1), 0.0)))
+		position = vmath.NewVec3(random.Float64()*distance-min, halfSize.Y-0.5, random.Float64()*distance-min)
+		scene.Add(geometry.NewBox(position.Sub(halfSize), position.Add(halfSize), material.NewMetalMaterial(vmath.RandomVec3(random, 0.6, 1), 0.0)))
 	}
 
-	var camera = camera.NewCameraDefocusBounds(bounds)
+	return scene
+}
+
+// Create the scene and camera copies used by each thread if the settings require them.
+func CreateCopies(scene *geometry.Scene, camera *camera.CameraDefocus, settings *RenderSettings) {
+	SceneCopies = nil
+	CameraCopies = nil
 
-	if Multithreaded && MultithreadDataCopies {
-		for i := 0; i < MultithreadedTheads; i++ {
+	if settings.Multithreaded && settings.MultithreadDataCopies {
+		for i := 0; i < settings.Threads; i++ {
 			SceneCopies = append(SceneCopies, scene.Clone())
 			CameraCopies = append(CameraCopies, camera.Clone())
 		}
 	}
+}
 
-	var config = pixelgl.WindowConfig{
-		Resizable:   false,
-		Undecorated: false,
-		VSync:       false,
-		Title:       "Gotracer",
-		Bounds:      windowBounds}
+// Update the camera viewport
+func UpdateCamera(camera *camera.CameraDefocus, settings *RenderSettings) {
 
-	var window, err = pixelgl.NewWindow(config)
+	if Buffer != nil {
+		Buffer.Reset()
+	}
 
-	CheckError(err)
+	if settings.Multithreaded && settings.MultithreadDataCopies {
+		for i := 0; i < len(CameraCopies); i++ {
+			CameraCopies[i].Copy(camera)
+			CameraCopies[i].UpdateViewport()
+		}
+	} else {
+		camera.UpdateViewport()
+	}
+}
 
-	var delta time.Duration
+// Render one pass of the scene, adding one sample per pixel (or the antialiasing samples) to the accumulation buffer.
+// The progress is reported to the callback after each tile, the callback can be nil.
+//
+// The render stops when the context is cancelled and returns the context error.
+// The pixels rendered before the cancellation are kept in the buffer, it should be reset before the next render.
+func Render(ctx context.Context, buffer *framebuffer.Framebuffer, scene *geometry.Scene, camera *camera.CameraDefocus, sampler sampler.Sampler, settings *RenderSettings, progress ProgressFunc) error {
+	return RenderPasses(ctx, buffer, scene, camera, sampler, settings, 1, progress)
+}
 
-	for !window.Closed() {
+// Render multiple passes of the scene, the progress reported includes the tiles of every pass.
+func RenderPasses(ctx context.Context, buffer *framebuffer.Framebuffer, scene *geometry.Scene, camera *camera.CameraDefocus, sampler sampler.Sampler, settings *RenderSettings, passes int, progress ProgressFunc) error {
+	var tiles, err = scheduler.Tiles(buffer.Width, buffer.Height, settings.TileSize, settings.TileOrder)
+	if err != nil {
+		return err
+	}
 
-		var start = time.Now()
+	var tracker = NewProgressTracker(progress, len(tiles), passes, settings)
 
-		window.Clear(colornames.Black)
+	for i := 0; i < passes; i++ {
+		err = RenderPass(ctx, buffer, scene, camera, sampler, settings, tiles, tracker)
+		if err != nil {
+			return err
+		}
+	}
 
-		var picture *pixel.PictureData = Render(bounds, scene, camera)
-		var sprite *pixel.Sprite
+	return nil
+}
 
-		if TemporalFilter {
+// Render a pass of the tiles and add the result to the accumulation buffer.
+// The tiles are rendered by a pool of workers that pull them from a shared queue, each worker uses a clone of the sampler.
+//
+//go:norace
+func RenderPass(ctx context.Context, buffer *framebuffer.Framebuffer, scene *geometry.Scene, camera *camera.CameraDefocus, sampler sampler.Sampler, settings *RenderSettings, tiles []scheduler.Tile, tracker *ProgressTracker) error {
+	var queue = scheduler.NewQueue(tiles)
+	var wg sync.WaitGroup
 
-			// Add new frame to the list
-			Frames = append(Frames, picture)
-			if len(Frames) > TemporalFilterSamples {
-				Frames = Frames[1:]
-			}
+	if settings.Multithreaded {
+		wg.Add(settings.Threads)
 
-			var final = pixel.MakePictureData(bounds)
+		if settings.MultithreadDataCopies && len(SceneCopies) >= settings.Threads {
+			for i := 0; i < settings.Threads; i++ {
+				go RaytraceThread(ctx, &wg, buffer, SceneCopies[i], CameraCopies[i], sampler.Clone(), settings, queue, tracker)
+			}
+		} else {
+			for i := 0; i < settings.Threads; i++ {
+				go RaytraceThread(ctx, &wg, buffer, scene, camera, sampler.Clone(), settings, queue, tracker)
+			}
+		}
 
-			// Average the Frames in the list
-			for i := 0; i < len(final.Pix); i++ {
+		wg.Wait()
+	} else {
+		wg.Add(1)
+		RaytraceThread(ctx, &wg, buffer, scene, camera, sampler.Clone(), settings, queue, tracker)
+	}
 
-				var r, g, b int
+	// The pass only counts if all the tiles were rendered
+	if queue.Finished() < queue.Len() {
+		return ctx.Err()
+	}
 
-				for j := 0; j < len(Frames); j++ {
-					r += (int)(Frames[j].Pix[i].R)
-					g += (int)(Frames[j].Pix[i].G)
-					b += (int)(Frames[j].Pix[i].B)
-				}
+	buffer.Passes++
+	tracker.PassDone(buffer)
+	return nil
+}
 
-				final.Pix[i].R = (uint8)(r / len(Frames))
-				final.Pix[i].G = (uint8)(g / len(Frames))
-				final.Pix[i].B = (uint8)(b / len(Frames))
-			}
+// Trace state holds the data reused by a thread for every ray it traces, so the hot loop does not allocate.
+// Each thread must use its own state.
+type TraceState struct {
+	// Hit record of the current path vertex, overwritten at each bounce.
+	HitRecord *material.HitRecord
 
-			sprite = pixel.NewSprite(final, final.Bounds())
-		} else {
-			sprite = pixel.NewSprite(picture, picture.Bounds())
-		}
-		sprite.Draw(window, pixel.IM.Moved(window.Bounds().Center()).Scaled(window.Bounds().Center(), Upscale))
+	// Hit record used by the shadow rays of the direct lighting.
+	ShadowRecord *material.HitRecord
 
-		delta = time.Since(start)
-		log.Printf("Frame time %s", delta)
+	// Sampler of the thread, started for each pixel sample before tracing it.
+	Sampler sampler.Sampler
 
-		var speed = 1.0 * delta.Seconds()
+	// Media of the dielectric objects where the current path is, reset for each path.
+	Media material.MediumStack
 
-		//Keyboard input
-		if window.Pressed(pixelgl.KeyRight) {
-			camera.Position.X += speed
-			UpdateCamera(camera)
-		}
-		if window.Pressed(pixelgl.KeyLeft) {
-			camera.Position.X -= speed
-			UpdateCamera(camera)
-		}
-		if window.Pressed(pixelgl.KeyUp) {
-			camera.Position.Z -= speed
-			UpdateCamera(camera)
-		}
-		if window.Pressed(pixelgl.KeyDown) {
-			camera.Position.Z += speed
-			UpdateCamera(camera)
-		}
-		if window.Pressed(pixelgl.KeyLeftControl) || window.Pressed(pixelgl.KeyRightControl) {
-			camera.Position.Y -= speed
-			UpdateCamera(camera)
-		}
-		if window.Pressed(pixelgl.KeySpace) {
-			camera.Position.Y += speed
-			UpdateCamera(camera)
-		}
-		if window.Pressed(pixelgl.KeyW) {
-			camera.Aperture += 0.1
-			UpdateCamera(camera)
-		}
-		if window.Pressed(pixelgl.KeyS) {
-			camera.Aperture -= 0.1
-			UpdateCamera(camera)
-		}
+	// Media crossed by the shadow rays, copied from the media of the path for each shadow ray.
+	ShadowMedia material.MediumStack
 
-		window.Update()
-	}
+	// Wavelengths of the current path when rendering in spectral mode.
+	Wavelengths spectrum.Wavelengths
 }
 
-// Update the camera viewport
-func UpdateCamera(camera *camera.CameraDefocus) {
-
-	if TemporalFilter {
-		Frames = nil
-	}
-
-	if Multithreaded && MultithreadDataCopies {
-		for i := 0; i < MultithreadedTheads; i++ {
-			CameraCopies[i].Copy(camera)
-			CameraCopies[i].UpdateViewport()
-		}
-	} else {
-		camera.UpdateViewport()
-	}
+// Create a new trace state for a thread, the sampler must not be used by other threads.
+func NewTraceState(sampler sampler.Sampler) *TraceState {
+	var s = new(TraceState)
+	s.HitRecord = material.NewHitRecord()
+	s.ShadowRecord = material.NewHitRecord()
+	s.Sampler = sampler
+	return s
 }
 
-// Render image the image
+// Ray trace tiles of the picture in a thread until the queue is empty and add the result to the accumulation buffer.
+// This method is intended to be called multiple threads, the tiles of the queue are not rendered by more than one thread.
+// The thread stops when the context is cancelled.
 //
 //go:norace
-func Render(bounds pixel.Rect, scene *geometry.Scene, camera *camera.CameraDefocus) *pixel.PictureData {
-	var size = bounds.Size()
-	var picture *pixel.PictureData = pixel.MakePictureData(bounds)
-	var nx = int(size.X)
-	var ny = int(size.Y)
-	var wg sync.WaitGroup
+func RaytraceThread(ctx context.Context, wg *sync.WaitGroup, buffer *framebuffer.Framebuffer, scene *geometry.Scene, camera *camera.CameraDefocus, sampler sampler.Sampler, settings *RenderSettings, queue *scheduler.Queue, tracker *ProgressTracker) {
+	var state = NewTraceState(sampler)
 
-	if Multithreaded {
-		wg.Add(MultithreadedTheads)
-		var wtx = nx / MultithreadedTheads
-		var itx = 0
-
-		if MultithreadDataCopies {
-			for i := 0; i < MultithreadedTheads; i++ {
-				go RaytraceThread(&wg, picture, SceneCopies[i], CameraCopies[i], MaxDepth, TemporalFilter, Antialiasing, size.X, size.Y, itx, 0, itx+wtx, ny)
-				itx += wtx
-			}
-		} else {
-			for i := 0; i < MultithreadedTheads; i++ {
-				go RaytraceThread(&wg, picture, scene, camera, MaxDepth, TemporalFilter, Antialiasing, size.X, size.Y, itx, 0, itx+wtx, ny)
-				itx += wtx
-			}
+	for tile, ok := queue.Next(); ok; tile, ok = queue.Next() {
+		if !RaytraceTile(ctx, buffer, scene, camera, settings, state, tile) {
+			break
 		}
 
-		wg.Wait()
-	} else {
-		wg.Add(1)
-		RaytraceThread(&wg, picture, scene, camera, MaxDepth, TemporalFilter, Antialiasing, size.X, size.Y, 0, 0, nx, ny)
+		queue.Finish()
+		tracker.TileDone(buffer)
 	}
 
-	return picture
+	wg.Done()
 }
 
-// Ray trace the picture in a thread and write it to the output object.
-// The result is written to the picture object passed as argument.
-// This method is intended to be called multiple threads.
+// Ray trace a tile of the picture and add the result to the accumulation buffer.
+// The context is checked before each row of the tile, returns false if the tile was not finished because the context was cancelled.
+//
+// The sampler is started from the pixel and sample index, the result does not depend on which thread renders the pixel.
+// The sample index continues from the samples taken by the pixel, so the samples of a cancelled pass are not repeated by the next pass.
+// The first dimensions of each sample are used for the position in the pixel and in the camera lens.
 //
 //go:norace
-func RaytraceThread(wg *sync.WaitGroup, picture *pixel.PictureData, scene *geometry.Scene, camera *camera.CameraDefocus, depth int64, jitter bool, antialiasing bool, width float64, height float64, ix int, iy int, nx int, ny int) {
-	for j := iy; j < ny; j++ {
-		for i := ix; i < nx; i++ {
-			var color *vmath.Vector3
+func RaytraceTile(ctx context.Context, buffer *framebuffer.Framebuffer, scene *geometry.Scene, camera *camera.CameraDefocus, settings *RenderSettings, state *TraceState, tile scheduler.Tile) bool {
+	var sampler = state.Sampler
+	var width = float64(buffer.Width)
+	var height = float64(buffer.Height)
+	var done = ctx.Done()
+
+	for j := tile.Y; j < tile.Y+tile.Height; j++ {
+		select {
+		case <-done:
+			return false
+		default:
+		}
+
+		for i := tile.X; i < tile.X+tile.Width; i++ {
+			var color vmath.Vec3
+			var taken = int(buffer.Taken[buffer.Index(i, j)])
 
 			//If using antialiasing jitter the UV and cast multiple rays
-			if antialiasing {
-				var samples = 4
-				color = vmath.NewVector3(0, 0, 0)
+			if settings.Antialiasing {
+				var samples = settings.AntialiasingSamples
 
 				for k := 0; k < samples; k++ {
-					var u = (float64(i) + rand.Float64()) / width
-					var v = (float64(j) + rand.Float64()) / height
-					color.Add(RaytraceScene(scene, camera.GetRay(u, v), depth))
+					sampler.StartPixelSample(i, j, taken*samples+k)
+
+					var jx, jy = sampler.Get2D()
+					var u = (float64(i) + jx) / width
+					var v = (float64(j) + jy) / height
+					color = color.Add(RaytraceScene(scene, camera.GetRay(u, v, sampler), settings.MaxDepth, settings, state))
 				}
 
-				color.DivideScalar(float64(samples))
+				color = color.DivideScalar(float64(samples))
 			} else {
 				var u float64
 				var v float64
 
-				if jitter {
-					u = (float64(i) + rand.Float64()) / width
-					v = (float64(j) + rand.Float64()) / height
+				sampler.StartPixelSample(i, j, taken)
+
+				// The pixel dimensions are used even without jitter so the following dimensions do not change
+				var jx, jy = sampler.Get2D()
+
+				if settings.TemporalFilter {
+					u = (float64(i) + jx) / width
+					v = (float64(j) + jy) / height
 				} else {
 					u = float64(i) / width
 					v = float64(j) / height
 				}
 
-				color = RaytraceScene(scene, camera.GetRay(u, v), depth)
+				color = RaytraceScene(scene, camera.GetRay(u, v, sampler), settings.MaxDepth, settings, state)
 			}
 
-			//Apply gamma
-			//color.DivideScalar(1.0);
-			color.Sqrt()
-
-			color.MulScalar(255)
-
-			//Write to picture
-			var index = picture.Index(pixel.Vec{X: float64(i), Y: float64(j)})
-			picture.Pix[index].R = uint8(color.X)
-			picture.Pix[index].G = uint8(color.Y)
-			picture.Pix[index].B = uint8(color.Z)
+			//Write to buffer
+			buffer.AddSample(i, j, color)
 		}
 	}
 
-	wg.Done()
+	return true
 }
 
 // Render the scene to calculate the color for a ray.
 // Receives the scene and the initial ray to be casted.
 // It is called recursively until the ray does not hit anything, it is absorbed of depth reaches 0.
+// The color is the light emitted by the surfaces hit plus the light scattered by them.
+//
+// In spectral mode the wavelengths of the path are sampled from the next dimension of the sample, the path is traced for them and converted back to RGB.
 //
 //go:norace
-func RaytraceScene(scene *geometry.Scene, ray *vmath.Ray, depth int64) *vmath.Vector3 {
-	var hitRecord = material.NewHitRecord()
+func RaytraceScene(scene *geometry.Scene, ray vmath.Ray, depth int64, settings *RenderSettings, state *TraceState) vmath.Vec3 {
+	// Camera rays start outside of every object
+	state.Media.Reset()
 
-	if scene.Hit(ray, MinDistance, math.MaxFloat64, hitRecord) {
+	if !settings.Spectral {
+		state.HitRecord.Wavelengths = nil
+		return RaytracePath(scene, ray, depth, settings, state, 0.0, true)
+	}
 
-		var scattered = vmath.NewEmptyRay()
-		var attenuation = vmath.NewVector3(0, 0, 0)
+	state.Wavelengths.Sample(state.Sampler.Get1D())
+	state.HitRecord.Wavelengths = &state.Wavelengths
 
-		if depth > 0 && hitRecord.Material.Scatter(ray, hitRecord, attenuation, scattered) {
-			var color = attenuation.Clone()
-			color.Mul(RaytraceScene(scene, scattered.Clone(), depth-1))
-			return color
-		} else {
-			// Ray was absorved return black
-			//return vmath.NewVector3(0, 0, 0);
+	return state.Wavelengths.ToRGB(RaytracePath(scene, ray, depth, settings, state, 0.0, true))
+}
+
+// Calculate the color for a ray that is part of a path.
+// The pdf is the density of the material sampling that generated the ray, specular indicates if the ray was generated by a specular material (or the camera).
+// These are used to weight the light hit by the ray with multiple importance sampling, since the light was also sampled directly in the previous hit.
+//
+// The light arriving trough a dielectric object is attenuated by the absorption of its medium along the distance traveled inside of it.
+//
+// Inside of a participating medium (fog, smoke or the atmosphere) the distance to the next particle is sampled from the density of the medium (free flight).
+// The atmosphere only fills a sphere around the scene, the distance is sampled from where the ray is inside of the sphere.
+// If the particle is closer than the surface the ray is scattered there by the material of the medium, otherwise the ray continues to the surface.
+// The probability of reaching the surface is the transmittance of the medium, so it is not applied to the light.
+//
+//go:norace
+func RaytracePath(scene *geometry.Scene, ray vmath.Ray, depth int64, settings *RenderSettings, state *TraceState, pdf float64, specular bool) vmath.Vec3 {
+	var hitRecord = state.HitRecord
+	var media = &state.Media
+	var tmax = math.MaxFloat64
+	var length = ray.Direction.Length()
+
+	var hit = scene.Hit(ray, settings.MinDistance, tmax, hitRecord)
+	if hit {
+		tmax = hitRecord.T
+	}
+
+	// Area lights closer than the objects
+	var lightDistance, radiance, lightPdf, lightHit = HitLights(scene, ray, settings.MinDistance, tmax)
+	if lightHit {
+		tmax = lightDistance
+	}
+
+	// Particle of the participating medium closer than the surface
+	var volume, participating = media.Participating(scene.Atmosphere)
+	if participating {
+		var u = state.Sampler.Get1D()
 
-			// The ray was absorved use the last value
-			return attenuation.Clone()
+		// The atmosphere only fills a sphere, rays that leave it without hitting a particle see the background
+		var start, end = 0.0, tmax
+		if volume.Material == material.Material(scene.Atmosphere) {
+			start, end, participating = scene.AtmosphereInterval(ray, 0.0, tmax)
 		}
 
+		var t = start - math.Log(1.0-u)/(volume.Density*length)
+		participating = participating && t < end
+
+		if participating {
+			hit = true
+			lightHit = false
+
+			hitRecord.T = t
+			hitRecord.P = ray.PointAtParameter(t)
+			hitRecord.Normal = ray.Direction.DivideScalar(-length)
+			hitRecord.FrontFace = true
+			hitRecord.U = 0.0
+			hitRecord.V = 0.0
+			hitRecord.Material = volume.Material
+		}
+	}
+
+	if lightHit {
+		radiance = LightColor(radiance, hitRecord)
+		if settings.DirectLighting && !specular {
+			radiance = radiance.MulScalar(light.PowerHeuristic(pdf, lightPdf))
+		}
+		return radiance.Mul(media.Transmittance(lightDistance * length))
+	}
+
+	if !hit {
+		return LightColor(BackgroundColor(ray), hitRecord)
+	}
+
+	var transmittance = media.Transmittance(hitRecord.T * length)
+
+	// The refractive indice on the outside of the surface is the one of the medium where the ray is
+	hitRecord.OutsideIOR = media.RefractiveIndice()
+
+	// Particles of a medium are not surfaces
+	var refractive, isRefractive = hitRecord.Material.(material.Refractive)
+	var medium material.Medium
+	if isRefractive && !participating {
+		medium, isRefractive = refractive.Medium(hitRecord)
 	} else {
+		isRefractive = false
+	}
+
+	if isRefractive {
+		var outside, visible = media.Interface(medium, hitRecord.FrontFace)
+
+		// Surfaces of nested dielectrics inside of a medium with higher priority are crossed without changing the ray
+		if !visible {
+			media.Cross(medium, hitRecord.FrontFace)
+			return transmittance.Mul(RaytracePath(scene, vmath.NewRay(hitRecord.P, ray.Direction), depth, settings, state, pdf, specular))
+		}
+
+		hitRecord.OutsideIOR = outside
+	}
+
+	// Light emitted by the surface
+	var color = hitRecord.Material.Emitted(ray, hitRecord)
+
+	if depth <= 0 {
+		return color.Mul(transmittance)
+	}
+
+	var bsdf, evaluable = hitRecord.Material.(material.BSDF)
+
+	// Direct lighting from the explicit light sources
+	if evaluable && settings.DirectLighting && len(scene.Lights) > 0 {
+		color = color.Add(SampleLights(scene, ray, hitRecord, bsdf, settings, state))
+	}
+
+	var attenuation, scattered, ok = hitRecord.Material.Scatter(ray, hitRecord, state.Sampler)
+	if ok {
+		var scatteredPdf = 0.0
+		if evaluable {
+			scatteredPdf = bsdf.PDF(ray, hitRecord, scattered.Direction)
+		}
+
+		// Refracted rays enter or leave the medium of the object
+		if isRefractive && scattered.Direction.Dot(hitRecord.Normal) < 0 {
+			media.Cross(medium, hitRecord.FrontFace)
+		}
+
+		// The hit record is reused by the next bounce, it is not used after this point
+		color = color.Add(attenuation.Mul(RaytracePath(scene, scattered, depth-1, settings, state, scatteredPdf, !evaluable)))
+	}
+
+	// If the ray was absorbed only the emitted and direct light are returned
+	return color.Mul(transmittance)
+}
+
+// Sample the light arriving directly from each light source to the hit point.
+// Shadow rays are used to check if the light is visible, the contribution of area lights is weighted by multiple importance sampling.
+//
+//go:norace
+func SampleLights(scene *geometry.Scene, ray vmath.Ray, hitRecord *material.HitRecord, bsdf material.BSDF, settings *RenderSettings, state *TraceState) vmath.Vec3 {
+	var color vmath.Vec3
+
+	for i := 0; i < len(scene.Lights); i++ {
+		var l = scene.Lights[i]
+
+		var direction, distance, radiance, pdf = l.Sample(hitRecord.P, state.Sampler)
+		if pdf <= 0 || radiance.SquaredLength() == 0 {
+			continue
+		}
+
+		var f = bsdf.Evaluate(ray, hitRecord, direction)
+		if f.SquaredLength() == 0 {
+			continue
+		}
+
+		// Check if there is any object between the point and the light
+		var shadow = vmath.NewRay(hitRecord.P, direction)
+		var visibility = ShadowTransmittance(scene, shadow, distance*(1.0-ShadowEpsilon), l, settings, state)
+		if visibility.IsZero() {
+			continue
+		}
+
+		var weight = 1.0
+		if !l.IsDelta() {
+			weight = light.PowerHeuristic(pdf, bsdf.PDF(ray, hitRecord, direction))
+		}
+
+		color = color.AddScaled(f.Mul(LightColor(radiance, hitRecord)).Mul(visibility), weight/pdf)
+	}
+
+	return color
+}
+
+// Fraction of the light transmitted along a shadow ray until the distance, zero if a surface blocks the light.
+// The shadow ray crosses the boundaries of the participating media and is attenuated by the media where it travels.
+// Area lights other than the source light sampled are opaque and block the shadow ray, the same way they are hit by the camera rays.
+//
+//go:norace
+func ShadowTransmittance(scene *geometry.Scene, ray vmath.Ray, tmax float64, source light.Light, settings *RenderSettings, state *TraceState) vmath.Vec3 {
+	var shadowRecord = state.ShadowRecord
+	var media = &state.ShadowMedia
+	var length = ray.Direction.Length()
+
+	for i := 0; i < len(scene.Lights); i++ {
+		if scene.Lights[i] == source {
+			continue
+		}
+		if _, _, _, hit := scene.Lights[i].Intersect(ray, settings.MinDistance, tmax); hit {
+			return vmath.Vec3{}
+		}
+	}
+
+	// The shadow ray starts in the media of the path
+	*media = state.Media
+
+	var transmittance = vmath.NewVec3(1.0, 1.0, 1.0)
+	var start = 0.0
+	var tmin = settings.MinDistance
+
+	for {
+		var hit = scene.Hit(ray, tmin, tmax, shadowRecord)
+		var end = tmax
+		if hit {
+			end = shadowRecord.T
+		}
+
+		// Attenuation along the segment until the next surface, the atmosphere only attenuates the part of the segment inside of its sphere
+		var distance = (end - start) * length
+		var volume, participating = media.Participating(scene.Atmosphere)
+		if participating {
+			var from, to = start, end
+			if volume.Material == material.Material(scene.Atmosphere) {
+				from, to, participating = scene.AtmosphereInterval(ray, start, end)
+			}
+			if participating {
+				transmittance = transmittance.MulScalar(math.Exp(-volume.Density * (to - from) * length))
+			}
+		}
+		transmittance = transmittance.Mul(media.Transmittance(distance))
+
+		if !hit || transmittance.IsZero() {
+			return transmittance
+		}
 
-		return BackgroundColor(ray)
+		// Only the boundaries of participating media let the light pass
+		var refractive, isRefractive = shadowRecord.Material.(material.Refractive)
+		if !isRefractive {
+			return vmath.Vec3{}
+		}
+
+		var medium, ok = refractive.Medium(shadowRecord)
+		if !ok || !medium.IndexMatched {
+			return vmath.Vec3{}
+		}
+
+		media.Cross(medium, shadowRecord.FrontFace)
+		start = end
+		tmin = end + settings.MinDistance
 	}
 }
 
+// Find the closest area light hit by the ray.
+// Returns the distance, the radiance emitted and the solid angle density of the light sampling the direction.
+//
+//go:norace
+func HitLights(scene *geometry.Scene, ray vmath.Ray, tmin float64, tmax float64) (float64, vmath.Vec3, float64, bool) {
+	var closest = tmax
+	var radiance vmath.Vec3
+	var pdf = 0.0
+	var hit = false
+
+	for i := 0; i < len(scene.Lights); i++ {
+		var t, r, p, h = scene.Lights[i].Intersect(ray, tmin, closest)
+		if h {
+			closest = t
+			radiance = r
+			pdf = p
+			hit = true
+		}
+	}
+
+	return closest, radiance, pdf, hit
+}
+
+// Convert the color of a light source into the values used by the path.
+// In spectral mode the color is converted into its emission spectrum at the wavelengths of the path.
+//
+//go:norace
+func LightColor(color vmath.Vec3, hitRecord *material.HitRecord) vmath.Vec3 {
+	if hitRecord.Wavelengths != nil {
+		return hitRecord.Wavelengths.Illuminant(color)
+	}
+	return color
+}
+
 // Calculate the background color from ray.
 // This method is used for multi threading.
 //
 //go:norace
-func BackgroundColor(r *vmath.Ray) *vmath.Vector3 {
-	var unitDirection = r.Direction.UnitVector()
+func BackgroundColor(r vmath.Ray) vmath.Vec3 {
+	var unitDirection = r.Direction.Normalize()
 	var t = 0.5 * (unitDirection.Y + 1.0)
 
-	var a = vmath.NewVector3(1.0, 1.0, 1.0)
-	a.MulScalar(1.0 - t)
-
-	var b = vmath.NewVector3(0.5, 0.7, 1.0)
-	b.MulScalar(t)
+	var a = vmath.NewVec3(1.0, 1.0, 1.0)
+	var b = vmath.NewVec3(0.5, 0.7, 1.0)
 
-	a.Add(b)
-
-	return a
+	return a.MulScalar(1.0-t).AddScaled(b, t)
 }
 
-// Load obj file triangle into the scene.
+// Load a OBJ file into the scene as a single mesh, faces without a MTL material use the material provided.
+// Smooth normals are generated for the faces without normals.
 //
 //go:norace
-func LoadOBJ(scene *geometry.Scene, fname string, material material.Material) {
-	var file, _ = os.Open(fname)
+func LoadOBJ(scene *geometry.Scene, fname string, material material.Material) error {
+	var model, err = objfile.Load(fname)
+	if err != nil {
+		return err
+	}
 
-	var data, _ = ioutil.ReadAll(file)
+	for i := 0; i < len(model.Warnings); i++ {
+		log.Printf("Warning: %s", model.Warnings[i])
+	}
 
-	var reader = obj.NewReader(bytes.NewBuffer(data))
-	var object, _ = reader.Read()
+	model.GenerateNormals(objfile.DefaultCreaseAngle)
 
-	for i := 0; i < len(object.Faces); i++ {
-		var points = object.Faces[i].Points
-		var a = vmath.NewVector3(points[0].Vertex.X, points[0].Vertex.Y, points[0].Vertex.Z)
-		var b = vmath.NewVector3(points[1].Vertex.X, points[1].Vertex.Y, points[1].Vertex.Z)
-		var c = vmath.NewVector3(points[2].Vertex.X, points[2].Vertex.Y, points[2].Vertex.Z)
-		scene.Add(geometry.NewTriangle(a, b, c, material))
+	var mesh *geometry.Mesh
+	mesh, err = model.Mesh(material)
+	if err != nil {
+		return err
 	}
+
+	mesh.File = fname
+	mesh.CreaseAngle = objfile.DefaultCreaseAngle
+	scene.Add(mesh)
+	return nil
 }
 
-// Write the frame to a PPM file string.
+// Write the frame to a PPM file.
 //
 //go:norace
-func WritePPM(picture *pixel.PictureData, fname string) {
+func WritePPM(picture *pixel.PictureData, fname string) error {
 	var size = picture.Rect.Size()
 
 	var nx = int(size.X)
 	var ny = int(size.Y)
 
 	var file, err = os.Create(fname)
-	CheckError(err)
+	if err != nil {
+		return err
+	}
 
-	_, _ = file.WriteString("P3\n" + strconv.Itoa(nx) + " " + strconv.Itoa(ny) + "\n255\n")
+	// The buffered writer keeps the first write error and returns it when flushed
+	var writer = bufio.NewWriter(file)
+	_, _ = writer.WriteString("P3\n" + strconv.Itoa(nx) + " " + strconv.Itoa(ny) + "\n255\n")
 
-	for j := 0; j < ny; j++ {
+	// PPM rows are written from top to bottom
+	for j := ny - 1; j >= 0; j-- {
 		for i := 0; i < nx; i++ {
-			//Write to file
 			var index = picture.Index(pixel.Vec{X: float64(i), Y: float64(j)})
-			_, _ = file.WriteString(strconv.Itoa(int(picture.Pix[index].R)) + " " + strconv.Itoa(int(picture.Pix[index].G)) + " " + strconv.Itoa(int(picture.Pix[index].B)) + "\n")
+			_, _ = writer.WriteString(strconv.Itoa(int(picture.Pix[index].R)) + " " + strconv.Itoa(int(picture.Pix[index].G)) + " " + strconv.Itoa(int(picture.Pix[index].B)) + "\n")
 		}
 	}
 
-	//Close file
-	_ = file.Sync()
-	_ = file.Close()
+	err = writer.Flush()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Write the frame to a PNG image file.
+func WritePNG(picture *pixel.PictureData, fname string) error {
+	var file, err = os.Create(fname)
+	if err != nil {
+		return err
+	}
+
+	err = png.Encode(file, picture.Image())
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Write the frame to a JPEG image file, quality ranges from 1 to 100.
+func WriteJPEG(picture *pixel.PictureData, fname string, quality int) error {
+	var file, err = os.Create(fname)
+	if err != nil {
+		return err
+	}
+
+	err = jpeg.Encode(file, picture.Image(), &jpeg.Options{Quality: quality})
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
 }
 
 // CheckError an error.
@@ -0,0 +1,28 @@
+package material
+
+import (
+	"gotracer/vmath"
+)
+
+// BSDF is implemented by materials that can be evaluated for any pair of directions.
+//
+// Materials that implement it can be used with explicit light sampling, the light arriving from a light source is weighted by the Evaluate method.
+// Materials with perfectly specular reflection (mirrors, glass) cannot be evaluated and should not implement it.
+type BSDF interface {
+	Material
+
+	// Evaluate the material for light arriving from a direction and leaving in the opposite direction of the ray.
+	// Returns the BSDF value multiplied by the cosine between the direction and the surface normal.
+	Evaluate(ray vmath.Ray, hitRecord *HitRecord, direction vmath.Vec3) vmath.Vec3
+
+	// Probability density (in solid angle) of the Scatter method generating a ray with the direction.
+	PDF(ray vmath.Ray, hitRecord *HitRecord, direction vmath.Vec3) float64
+}
+
+// Get the surface normal facing the side from where the ray arrived.
+func FacingNormal(ray vmath.Ray, hitRecord *HitRecord) vmath.Vec3 {
+	if ray.Direction.Dot(hitRecord.Normal) > 0 {
+		return hitRecord.Normal.Negate()
+	}
+	return hitRecord.Normal
+}
@@ -0,0 +1,299 @@
+package material
+
+import (
+	"gotracer/sampler"
+	"gotracer/spectrum"
+	"gotracer/texture"
+	"gotracer/vmath"
+	"math"
+	"testing"
+)
+
+// Number of directions sampled from each material by the tests.
+const testDirections = 4096
+
+// Surface used by the tests, facing the positive Z axis at the origin.
+var testNormal = vmath.NewVec3(0.0, 0.0, 1.0)
+
+// Create a hit record of a ray arriving with the direction at the test surface, from the front or the back face.
+func testHit(direction vmath.Vec3) (vmath.Ray, *HitRecord) {
+	var ray = vmath.NewRay(direction.Negate(), direction)
+	var hitRecord = NewHitRecord()
+	hitRecord.T = 1.0
+	hitRecord.U, hitRecord.V = 0.5, 0.5
+	hitRecord.SetFaceNormal(ray, testNormal)
+	return ray, hitRecord
+}
+
+// Directions arriving at the surface used by the tests, from grazing to normal incidence.
+var testIncoming = []vmath.Vec3{
+	vmath.NewVec3(0.0, 0.0, -1.0),
+	vmath.NewVec3(0.5, 0.0, -0.8).Normalize(),
+	vmath.NewVec3(-0.6, 0.7, -0.3).Normalize(),
+	vmath.NewVec3(0.2, -0.95, -0.08).Normalize(),
+}
+
+// Materials with a BSDF tested for consistency, transmissive materials are also hit from the back face.
+var testBSDFs = []struct {
+	name     string
+	material BSDF
+	back     bool
+}{
+	{"lambert", NewLambertMaterial(vmath.NewVec3(0.8, 0.5, 0.2)), false},
+	{"conductor", NewConductorMaterial(ConductorPresets["gold"].Eta, ConductorPresets["gold"].K, 0.3, 0.0), false},
+	{"conductor-anisotropic", NewConductorMaterial(ConductorPresets["copper"].Eta, ConductorPresets["copper"].K, 0.5, 0.7), false},
+	{"rough-dielectric", NewRoughDielectricMaterial(1.5, 0.3, vmath.NewVec3(1.0, 1.0, 1.0)), true},
+	{"principled", NewPrincipledMaterial(vmath.NewVec3(0.8, 0.3, 0.2)), false},
+	{"principled-metal", testPrincipled(map[string]float64{"metallic": 1.0, "roughness": 0.35}), false},
+	{"principled-coated", testPrincipled(map[string]float64{"roughness": 0.7, "sheen": 1.0, "clearcoat": 1.0, "clearcoatGloss": 0.5}), false},
+	{"principled-glass", testPrincipled(map[string]float64{"transmission": 1.0, "roughness": 0.3}), true},
+	{"volume-isotropic", NewVolumeMaterial(1.0, vmath.NewVec3(0.9, 0.8, 0.7), NewIsotropicPhaseFunction()), true},
+	{"volume-forward", NewVolumeMaterial(1.0, vmath.NewVec3(0.9, 0.8, 0.7), NewHenyeyGreensteinPhaseFunction(0.7)), true},
+	{"volume-backward", NewVolumeMaterial(1.0, vmath.NewVec3(0.9, 0.8, 0.7), NewHenyeyGreensteinPhaseFunction(-0.4)), true},
+}
+
+// Create a principled material with a white base color and the parameters provided.
+func testPrincipled(parameters map[string]float64) *PrincipledMaterial {
+	var m = NewPrincipledMaterial(vmath.NewVec3(1.0, 1.0, 1.0))
+	var textures = map[string]*texture.Texture{
+		"metallic":       &m.Metallic,
+		"roughness":      &m.Roughness,
+		"sheen":          &m.Sheen,
+		"clearcoat":      &m.Clearcoat,
+		"clearcoatGloss": &m.ClearcoatGloss,
+		"transmission":   &m.Transmission,
+	}
+
+	for name, value := range parameters {
+		*textures[name] = texture.NewSolidValue(value)
+	}
+	return m
+}
+
+// Call a function for each incoming direction tested, from the front and the back faces if requested.
+func forEachIncoming(back bool, f func(ray vmath.Ray, hitRecord *HitRecord)) {
+	for _, direction := range testIncoming {
+		f(testHit(direction))
+		if back {
+			f(testHit(direction.Negate()))
+		}
+	}
+}
+
+// The attenuation of the scattered rays is the value of the BSDF divided by the density of the direction.
+func TestBSDFConsistency(t *testing.T) {
+	for _, test := range testBSDFs {
+		t.Run(test.name, func(t *testing.T) {
+			var s = sampler.NewIndependentSampler(1)
+
+			forEachIncoming(test.back, func(ray vmath.Ray, hitRecord *HitRecord) {
+				var scattered = 0
+
+				for i := 0; i < testDirections; i++ {
+					s.StartPixelSample(i, 0, 0)
+
+					var attenuation, r, ok = test.material.Scatter(ray, hitRecord, s)
+					if !ok {
+						continue
+					}
+					scattered++
+
+					var f = test.material.Evaluate(ray, hitRecord, r.Direction)
+					var pdf = test.material.PDF(ray, hitRecord, r.Direction)
+					if pdf <= 0 {
+						t.Fatalf("direction %s scattered with density %f", r.Direction.ToString(), pdf)
+					}
+
+					var expected = f.DivideScalar(pdf)
+					if attenuation.Sub(expected).Length() > 1e-6*math.Max(1.0, expected.Length()) {
+						t.Fatalf("direction %s attenuation %s, expected %s", r.Direction.ToString(), attenuation.ToString(), expected.ToString())
+					}
+				}
+
+				if scattered == 0 {
+					t.Errorf("no ray scattered from %s", ray.Direction.ToString())
+				}
+			})
+		})
+	}
+}
+
+// Stratified uniform directions on the sphere, used to integrate functions of the directions.
+func sphereDirections() []vmath.Vec3 {
+	var s = sampler.NewIndependentSampler(2)
+	var directions = make([]vmath.Vec3, 0, 64*testDirections)
+
+	for i := 0; i < cap(directions); i++ {
+		s.StartPixelSample(i, 0, 0)
+		var u, v = s.Get2D()
+		var cosine = 1.0 - 2.0*(float64(i/256)+u)/float64(cap(directions)/256)
+		var phi = 2.0 * math.Pi * (float64(i%256) + v) / 256.0
+		var sine = math.Sqrt(math.Max(0.0, 1.0-cosine*cosine))
+		directions = append(directions, vmath.NewVec3(sine*math.Cos(phi), sine*math.Sin(phi), cosine))
+	}
+
+	return directions
+}
+
+// The density of sampling the directions integrates to at most one over the sphere.
+// Materials that never discard samples integrate to exactly one.
+func TestBSDFDensity(t *testing.T) {
+	var directions = sphereDirections()
+	var exact = map[string]bool{"lambert": true, "volume-isotropic": true, "volume-forward": true, "volume-backward": true}
+
+	for _, test := range testBSDFs {
+		t.Run(test.name, func(t *testing.T) {
+			forEachIncoming(test.back, func(ray vmath.Ray, hitRecord *HitRecord) {
+				var integral = 0.0
+				for _, direction := range directions {
+					integral += test.material.PDF(ray, hitRecord, direction)
+				}
+				integral *= 4.0 * math.Pi / float64(len(directions))
+
+				if integral > 1.05 || (exact[test.name] && integral < 0.95) {
+					t.Errorf("density integrates to %f for the ray %s", integral, ray.Direction.ToString())
+				}
+			})
+		})
+	}
+}
+
+// The directions scattered by the materials are distributed with the density returned by PDF.
+// The average of the scattered directions (discarded samples count as zero) is compared with the integral of the direction times the density.
+func TestBSDFSampling(t *testing.T) {
+	var directions = sphereDirections()
+
+	for _, test := range testBSDFs {
+		t.Run(test.name, func(t *testing.T) {
+			var s = sampler.NewIndependentSampler(3)
+
+			forEachIncoming(test.back, func(ray vmath.Ray, hitRecord *HitRecord) {
+				var expected vmath.Vec3
+				for _, direction := range directions {
+					expected = expected.AddScaled(direction, test.material.PDF(ray, hitRecord, direction))
+				}
+				expected = expected.MulScalar(4.0 * math.Pi / float64(len(directions)))
+
+				var mean vmath.Vec3
+				var samples = 4 * testDirections
+				for i := 0; i < samples; i++ {
+					s.StartPixelSample(i, 0, 0)
+					if _, r, ok := test.material.Scatter(ray, hitRecord, s); ok {
+						mean = mean.Add(r.Direction.Normalize())
+					}
+				}
+				mean = mean.DivideScalar(float64(samples))
+
+				if mean.Sub(expected).Length() > 0.03 {
+					t.Errorf("average scattered direction for the ray %s is %s, the density gives %s", ray.Direction.ToString(), mean.ToString(), expected.ToString())
+				}
+			})
+		})
+	}
+}
+
+// The reflectance of a conductor is computed with the refractive indice relative to the medium on the outside of the surface.
+func TestConductorOutsideMedium(t *testing.T) {
+	var preset = ConductorPresets["gold"]
+	var m = NewConductorMaterial(preset.Eta, preset.K, 0.0, 0.0)
+
+	for _, outside := range []float64{1.0, 1.33, 1.5} {
+		var _, hitRecord = testHit(vmath.NewVec3(0.0, 0.0, -1.0))
+		hitRecord.OutsideIOR = outside
+
+		var fresnel = m.fresnel(1.0, hitRecord)
+		var expected = FresnelConductor(1.0, preset.Eta.DivideScalar(outside), preset.K.DivideScalar(outside))
+		if fresnel.Sub(expected).Length() > 1e-9 {
+			t.Errorf("reflectance %s with outside indice %.2f, expected %s", fresnel.ToString(), outside, expected.ToString())
+		}
+	}
+
+	// Water reduces the reflectance of metals
+	var _, air = testHit(vmath.NewVec3(0.0, 0.0, -1.0))
+	var _, water = testHit(vmath.NewVec3(0.0, 0.0, -1.0))
+	water.OutsideIOR = 1.33
+	if Luminance(m.fresnel(1.0, water)) >= Luminance(m.fresnel(1.0, air)) {
+		t.Errorf("reflectance under water is not lower than in air")
+	}
+}
+
+// A white principled surface lit uniformly from every direction reflects at most the light it receives.
+// Only directions close to the normal are tested, the Burley retro-reflection adds light at grazing angles by design.
+func TestPrincipledWhiteFurnace(t *testing.T) {
+	var tests = map[string]*PrincipledMaterial{
+		"default":  testPrincipled(nil),
+		"rough":    testPrincipled(map[string]float64{"roughness": 1.0}),
+		"smooth":   testPrincipled(map[string]float64{"roughness": 0.1}),
+		"metallic": testPrincipled(map[string]float64{"metallic": 1.0}),
+	}
+
+	for name, material := range tests {
+		t.Run(name, func(t *testing.T) {
+			var s = sampler.NewIndependentSampler(4)
+
+			for _, cosine := range []float64{1.0, 0.9, 0.8} {
+				var ray, hitRecord = testHit(vmath.NewVec3(math.Sqrt(1.0-cosine*cosine), 0.0, -cosine))
+
+				var sum vmath.Vec3
+				var samples = 16 * testDirections
+				for i := 0; i < samples; i++ {
+					s.StartPixelSample(i, 0, 0)
+					if attenuation, _, ok := material.Scatter(ray, hitRecord, s); ok {
+						sum = sum.Add(attenuation)
+					}
+				}
+
+				if albedo := Luminance(sum.DivideScalar(float64(samples))); albedo > 1.01 {
+					t.Errorf("reflects %f of the light arriving with a cosine of %.1f", albedo, cosine)
+				}
+			}
+		})
+	}
+}
+
+// In spectral mode the lobes are weighted by the values sampled at the wavelengths of the path, the attenuation stays consistent with the BSDF.
+func TestPrincipledSpectral(t *testing.T) {
+	// The sheen and the clearcoat add light by design, the reflected light is only checked without them
+	var tests = []struct {
+		name     string
+		material *PrincipledMaterial
+		furnace  bool
+	}{
+		{"colored", NewPrincipledMaterial(vmath.NewVec3(0.9, 0.1, 0.05)), true},
+		{"metallic", testPrincipled(map[string]float64{"metallic": 1.0, "roughness": 0.35}), true},
+		{"coated", testPrincipled(map[string]float64{"roughness": 0.7, "sheen": 1.0, "clearcoat": 1.0}), false},
+	}
+
+	for _, test := range tests {
+		var material = test.material
+		t.Run(test.name, func(t *testing.T) {
+			var s = sampler.NewIndependentSampler(5)
+
+			for _, u := range []float64{0.1, 0.5, 0.9} {
+				var ray, hitRecord = testHit(testIncoming[1])
+				hitRecord.Wavelengths = spectrum.NewWavelengths(u)
+
+				var sum vmath.Vec3
+				for i := 0; i < testDirections; i++ {
+					s.StartPixelSample(i, 0, 0)
+
+					var attenuation, r, ok = material.Scatter(ray, hitRecord, s)
+					if !ok {
+						continue
+					}
+					sum = sum.Add(attenuation)
+
+					var expected = material.Evaluate(ray, hitRecord, r.Direction).DivideScalar(material.PDF(ray, hitRecord, r.Direction))
+					if attenuation.Sub(expected).Length() > 1e-6*math.Max(1.0, expected.Length()) {
+						t.Fatalf("direction %s attenuation %s, expected %s", r.Direction.ToString(), attenuation.ToString(), expected.ToString())
+					}
+				}
+
+				var albedo = sum.DivideScalar(testDirections)
+				if test.furnace && (albedo.X > 1.01 || albedo.Y > 1.01 || albedo.Z > 1.01) {
+					t.Errorf("reflects %s of the light at the wavelengths %v", albedo.ToString(), hitRecord.Wavelengths.Lambda)
+				}
+			}
+		})
+	}
+}
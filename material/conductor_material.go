@@ -0,0 +1,146 @@
+package material
+
+import (
+	"fmt"
+	"gotracer/sampler"
+	"gotracer/vmath"
+	"sort"
+)
+
+// Complex refractive indice of a conductor for the red, green and blue channels.
+type ConductorPreset struct {
+	Eta vmath.Vec3
+	K   vmath.Vec3
+}
+
+// Measured metals sampled at the wavelengths of the red, green and blue channels (relative to air).
+var ConductorPresets = map[string]ConductorPreset{
+	"gold":      {Eta: vmath.NewVec3(0.143119, 0.374957, 1.44248), K: vmath.NewVec3(3.98316, 2.38572, 1.60322)},
+	"copper":    {Eta: vmath.NewVec3(0.200438, 0.924033, 1.10221), K: vmath.NewVec3(3.91295, 2.45285, 2.14219)},
+	"aluminium": {Eta: vmath.NewVec3(1.65746, 0.880369, 0.521229), K: vmath.NewVec3(9.22387, 6.26952, 4.837)},
+	"silver":    {Eta: vmath.NewVec3(0.155265, 0.116723, 0.138342), K: vmath.NewVec3(4.82835, 3.12225, 2.14696)},
+}
+
+// Get the names of the conductor presets sorted alphabetically.
+func ConductorPresetNames() []string {
+	var names = make([]string, 0, len(ConductorPresets))
+	for name := range ConductorPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Conductor material is a rough metal surface described by a microfacet model (Cook-Torrance).
+//
+// The surface is made of tiny mirrors with normals distributed by the GGX distribution, with Smith masking-shadowing between them.
+// The color comes from the Fresnel reflectance of the complex refractive indice, that changes with the angle like real metals.
+type ConductorMaterial struct {
+	// Real part of the refractive indice for each color channel, relative to vacuum.
+	Eta vmath.Vec3
+
+	// Imaginary part of the refractive indice (absorption coefficient) for each color channel.
+	K vmath.Vec3
+
+	// Perceptual roughness in the range [0, 1], zero is a perfect mirror.
+	Roughness float64
+
+	// Stretches the reflections along the surface tangent, in the range [0, 1], zero is isotropic.
+	Anisotropy float64
+}
+
+func NewConductorMaterial(eta vmath.Vec3, k vmath.Vec3, roughness float64, anisotropy float64) *ConductorMaterial {
+	var m = new(ConductorMaterial)
+	m.Eta = eta
+	m.K = k
+	m.Roughness = roughness
+	m.Anisotropy = anisotropy
+	return m
+}
+
+// Create a conductor material from one of the presets (gold, copper, aluminium and silver).
+func NewConductorMaterialPreset(name string, roughness float64, anisotropy float64) (*ConductorMaterial, error) {
+	var preset, ok = ConductorPresets[name]
+	if !ok {
+		return nil, fmt.Errorf("material: unknown conductor preset %q", name)
+	}
+
+	return NewConductorMaterial(preset.Eta, preset.K, roughness, anisotropy), nil
+}
+
+// Fresnel reflectance of the conductor, in spectral mode the refractive indice is interpolated for the wavelengths of the path.
+// The indice is divided by the refractive indice of the medium on the outside of the surface (e.g. metal under water).
+func (m *ConductorMaterial) fresnel(cosine float64, hitRecord *HitRecord) vmath.Vec3 {
+	var eta, k = m.Eta, m.K
+	if hitRecord.Wavelengths != nil {
+		eta, k = hitRecord.Wavelengths.Interpolate(m.Eta), hitRecord.Wavelengths.Interpolate(m.K)
+	}
+
+	var outside = 1.0 / hitRecord.OutsideIOR
+	return FresnelConductor(cosine, eta.MulScalar(outside), k.MulScalar(outside))
+}
+
+// Scatter the ray by reflecting it on a microfacet normal sampled from the normals visible from the ray.
+// The attenuation is the Fresnel reflectance multiplied by the fraction of the reflected light that is not shadowed by other microfacets.
+func (m *ConductorMaterial) Scatter(ray vmath.Ray, hitRecord *HitRecord, sampler sampler.Sampler) (attenuation vmath.Vec3, scattered vmath.Ray, ok bool) {
+	var frame = newShadingFrame(ray, hitRecord)
+	var distribution = newGGX(m.Roughness, m.Anisotropy)
+
+	var u, v = sampler.Get2D()
+
+	var wo = frame.toLocal(ray.Direction.Normalize().Negate())
+	if wo.Z <= 0 {
+		return attenuation, scattered, false
+	}
+
+	var wm = distribution.SampleVisible(wo, u, v)
+	var wi = reflectLocal(wo, wm)
+	if wi.Z <= 0 {
+		return attenuation, scattered, false
+	}
+
+	var fresnel = m.fresnel(wo.Dot(wm), hitRecord)
+	attenuation = fresnel.MulScalar(distribution.G2(wo, wi) / distribution.G1(wo))
+
+	return attenuation, vmath.NewRay(hitRecord.P, frame.toWorld(wi)), true
+}
+
+func (m *ConductorMaterial) Evaluate(ray vmath.Ray, hitRecord *HitRecord, direction vmath.Vec3) vmath.Vec3 {
+	var frame = newShadingFrame(ray, hitRecord)
+	var distribution = newGGX(m.Roughness, m.Anisotropy)
+
+	var wo = frame.toLocal(ray.Direction.Normalize().Negate())
+	var wi = frame.toLocal(direction.Normalize())
+	if wo.Z <= 0 || wi.Z <= 0 {
+		return vmath.Vec3{}
+	}
+
+	var wm = wo.Add(wi).Normalize()
+	var fresnel = m.fresnel(wo.Dot(wm), hitRecord)
+
+	// The cosine of the incoming direction cancels out with the denominator of the Cook-Torrance BRDF
+	return fresnel.MulScalar(distribution.D(wm) * distribution.G2(wo, wi) / (4.0 * wo.Z))
+}
+
+func (m *ConductorMaterial) PDF(ray vmath.Ray, hitRecord *HitRecord, direction vmath.Vec3) float64 {
+	var frame = newShadingFrame(ray, hitRecord)
+	var distribution = newGGX(m.Roughness, m.Anisotropy)
+
+	var wo = frame.toLocal(ray.Direction.Normalize().Negate())
+	var wi = frame.toLocal(direction.Normalize())
+
+	return distribution.ReflectionPDF(wo, wi)
+}
+
+func (m *ConductorMaterial) Emitted(ray vmath.Ray, hitRecord *HitRecord) vmath.Vec3 {
+	return vmath.Vec3{}
+}
+
+func (o *ConductorMaterial) Clone() Material {
+	var m = new(ConductorMaterial)
+	m.Eta = o.Eta
+	m.K = o.K
+	m.Roughness = o.Roughness
+	m.Anisotropy = o.Anisotropy
+	return m
+}
@@ -1,8 +1,10 @@
 package material
 
 import (
+	"gotracer/sampler"
+	"gotracer/spectrum"
+	"gotracer/texture"
 	"gotracer/vmath"
-	"math/rand"
 )
 
 // Dielectric material allow light to pass trough them.
@@ -13,67 +15,116 @@ type DieletricMaterial struct {
 	// Used to calculate the ray refraction using the snell law.
 	RefractiveIndice float64
 
+	// Change of the refractive indice with the wavelength of the light, only used in spectral mode.
+	// Dispersion splits white light into its colors (e.g. prisms and gemstones), if nil the refractive indice is the same for every wavelength.
+	Dispersion spectrum.Dispersion
+
 	// Albedo represents the color of the material.
-	Albedo *vmath.Vector3
+	// The albedo tints the light at each interaction with the surface, use the absorption for a color that depends on the thickness of the object.
+	Albedo texture.Texture
+
+	// Color of the light after traveling the absorption distance inside of the material (Beer-Lambert law).
+	// There is no absorption if the distance is zero.
+	AbsorptionColor    vmath.Vec3
+	AbsorptionDistance float64
+
+	// Priority of the material where dielectric objects overlap, see Medium.
+	Priority int
 }
 
-func NewDieletricMaterial(refractiveIndice float64, albedo *vmath.Vector3) *DieletricMaterial {
+func NewDieletricMaterial(refractiveIndice float64, albedo vmath.Vec3) *DieletricMaterial {
+	return NewDieletricMaterialTexture(refractiveIndice, texture.NewSolidColor(albedo))
+}
+
+func NewDieletricMaterialTexture(refractiveIndice float64, albedo texture.Texture) *DieletricMaterial {
 	var m = new(DieletricMaterial)
 	m.RefractiveIndice = refractiveIndice
 	m.Albedo = albedo
+	m.AbsorptionColor = vmath.NewVec3(1.0, 1.0, 1.0)
+	m.AbsorptionDistance = 0.0
+	m.Priority = 0
 	return m
 }
 
+// Create a dispersive dielectric material, the refractive indice used when rendering in RGB is the one of the sodium D line.
+func NewDieletricMaterialDispersion(dispersion spectrum.Dispersion, albedo vmath.Vec3) *DieletricMaterial {
+	var m = NewDieletricMaterial(dispersion.RefractiveIndice(spectrum.SodiumDLine), albedo)
+	m.Dispersion = dispersion
+	return m
+}
+
+// Refractive indice of the material for the path.
+// In spectral mode dispersive materials use the refractive indice of the hero wavelength.
+func (m *DieletricMaterial) refractiveIndice(hitRecord *HitRecord) float64 {
+	if m.Dispersion != nil && hitRecord.Wavelengths != nil {
+		return m.Dispersion.RefractiveIndice(hitRecord.Wavelengths.Hero())
+	}
+	return m.RefractiveIndice
+}
+
 // Refractive indice of the air is 1.0
 var AirRefractiveIndice = 1.0
 
-func (m *DieletricMaterial) Scatter(ray *vmath.Ray, hitRecord *HitRecord, attenuation *vmath.Vector3, scattered *vmath.Ray) bool {
+func (m *DieletricMaterial) Scatter(ray vmath.Ray, hitRecord *HitRecord, sampler sampler.Sampler) (attenuation vmath.Vec3, scattered vmath.Ray, ok bool) {
 
-	var outwardNormal = vmath.NewEmptyVector3()
-	var refracted = vmath.NewEmptyVector3()
 	var reflected = vmath.Reflect(ray.Direction, hitRecord.Normal)
 	var refractionRatio float64
 	var reflectionProbe float64
 	var cosine float64
 
-	//attenuation.Set(1.0, 1.0, 1.0);
-	attenuation.Copy(m.Albedo)
+	//attenuation = vmath.NewVec3(1.0, 1.0, 1.0);
+	attenuation = SampleTexture(m.Albedo, hitRecord)
 
-	var dot = vmath.Dot(ray.Direction, hitRecord.Normal)
+	// The direction of the ray depends on the wavelength, only the hero wavelength can follow it
+	if m.Dispersion != nil && hitRecord.Wavelengths != nil {
+		attenuation = attenuation.Mul(hitRecord.Wavelengths.TerminateSecondary())
+	}
+
+	// The normal points against the ray, the front face flag indicates if the ray is entering or exiting the material
+	cosine = -ray.Direction.Dot(hitRecord.Normal) / ray.Direction.Length()
+
+	// Refractive indice relative to the medium on the outside of the surface
+	var relativeIndice = m.refractiveIndice(hitRecord) / hitRecord.OutsideIOR
 
-	if dot > 0 {
-		outwardNormal.Copy(hitRecord.Normal)
-		outwardNormal.MulScalar(-1.0)
-		refractionRatio = m.RefractiveIndice
-		cosine = m.RefractiveIndice * dot / ray.Direction.Length()
+	if hitRecord.FrontFace {
+		refractionRatio = 1.0 / relativeIndice
 	} else {
-		outwardNormal.Copy(hitRecord.Normal)
-		refractionRatio = AirRefractiveIndice / m.RefractiveIndice
-		cosine = -dot / ray.Direction.Length()
+		refractionRatio = relativeIndice
+		cosine *= relativeIndice
 	}
 
-	if vmath.Refract(ray.Direction, outwardNormal, refractionRatio, refracted) {
-		reflectionProbe = vmath.Schlick(cosine, m.RefractiveIndice)
-	} else {
-		reflectionProbe = 1.0
-		scattered.Set(hitRecord.P, reflected)
-		return true
+	var refracted, refracts = vmath.Refract(ray.Direction, hitRecord.Normal, refractionRatio)
+	if !refracts {
+		return attenuation, vmath.NewRay(hitRecord.P, reflected), true
 	}
 
+	reflectionProbe = vmath.Schlick(cosine, relativeIndice)
+
 	// TODO <SUPPORT MULTIPLE SCATERED RAYS>
 	// Return reflected of refracted randomly with reflection probe probability.
-	if rand.Float64() < reflectionProbe {
-		scattered.Set(hitRecord.P, reflected)
-	} else {
-		scattered.Set(hitRecord.P, refracted)
+	if sampler.Get1D() < reflectionProbe {
+		return attenuation, vmath.NewRay(hitRecord.P, reflected), true
 	}
 
-	return true
+	return attenuation, vmath.NewRay(hitRecord.P, refracted), true
+}
+
+// Get the medium inside of the object, dielectric materials always let light inside.
+func (m *DieletricMaterial) Medium(hitRecord *HitRecord) (Medium, bool) {
+	return Medium{Material: m, RefractiveIndice: m.refractiveIndice(hitRecord), Priority: m.Priority, Absorption: AbsorptionCoefficient(reflectance(m.AbsorptionColor, hitRecord), m.AbsorptionDistance)}, true
+}
+
+func (m *DieletricMaterial) Emitted(ray vmath.Ray, hitRecord *HitRecord) vmath.Vec3 {
+	return vmath.Vec3{}
 }
 
 func (o *DieletricMaterial) Clone() Material {
 	var m = new(DieletricMaterial)
-	m.Albedo = o.Albedo.Clone()
+	m.Albedo = o.Albedo
 	m.RefractiveIndice = o.RefractiveIndice
+	m.Dispersion = o.Dispersion
+	m.AbsorptionColor = o.AbsorptionColor
+	m.AbsorptionDistance = o.AbsorptionDistance
+	m.Priority = o.Priority
 	return m
 }
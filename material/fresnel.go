@@ -0,0 +1,75 @@
+package material
+
+import (
+	"gotracer/vmath"
+	"math"
+)
+
+// Fresnel reflectance of a conductor for each color channel.
+// The cosine is between the direction and the surface normal, eta and k are the real and imaginary parts of the complex refractive indice relative to the outside medium.
+func FresnelConductor(cosine float64, eta vmath.Vec3, k vmath.Vec3) vmath.Vec3 {
+	return vmath.Vec3{
+		X: fresnelConductor(cosine, eta.X, k.X),
+		Y: fresnelConductor(cosine, eta.Y, k.Y),
+		Z: fresnelConductor(cosine, eta.Z, k.Z),
+	}
+}
+
+// Fresnel reflectance of unpolarized light at the interface with a conductor, average of the parallel and perpendicular reflectance.
+func fresnelConductor(cosine float64, eta float64, k float64) float64 {
+	cosine = math.Min(math.Max(cosine, 0.0), 1.0)
+
+	var cos2 = cosine * cosine
+	var sin2 = 1.0 - cos2
+	var eta2 = eta * eta
+	var k2 = k * k
+
+	var t0 = eta2 - k2 - sin2
+	var a2b2 = math.Sqrt(t0*t0 + 4.0*eta2*k2)
+	var t1 = a2b2 + cos2
+	var a = math.Sqrt(math.Max(0.0, 0.5*(a2b2+t0)))
+	var t2 = 2.0 * cosine * a
+	var rs = (t1 - t2) / (t1 + t2)
+
+	var t3 = cos2*a2b2 + sin2*sin2
+	var t4 = t2 * sin2
+	var rp = rs * (t3 - t4) / (t3 + t4)
+
+	return 0.5 * (rp + rs)
+}
+
+// Fresnel reflectance of unpolarized light at the interface between two dielectrics.
+// Eta is the refractive indice of the side opposite to the direction relative to the side of the direction, a negative cosine swaps the sides.
+// Returns one for total internal reflection.
+func FresnelDielectric(cosine float64, eta float64) float64 {
+	cosine = math.Min(math.Max(cosine, -1.0), 1.0)
+	if cosine < 0 {
+		eta = 1.0 / eta
+		cosine = -cosine
+	}
+
+	var sin2i = 1.0 - cosine*cosine
+	var sin2t = sin2i / (eta * eta)
+	if sin2t >= 1.0 {
+		return 1.0
+	}
+
+	var cost = math.Sqrt(1.0 - sin2t)
+	var parallel = (eta*cosine - cost) / (eta*cosine + cost)
+	var perpendicular = (cosine - eta*cost) / (cosine + eta*cost)
+
+	return (parallel*parallel + perpendicular*perpendicular) * 0.5
+}
+
+// Schlick approximation of the Fresnel reflectance for each color channel from the reflectance at normal incidence.
+func FresnelSchlick(cosine float64, f0 vmath.Vec3) vmath.Vec3 {
+	var w = schlickWeight(cosine)
+	return f0.AddScaled(vmath.NewVec3(1.0, 1.0, 1.0).Sub(f0), w)
+}
+
+// Weight of the Schlick approximation, (1 - cosine)^5.
+func schlickWeight(cosine float64) float64 {
+	var m = math.Min(math.Max(1.0-cosine, 0.0), 1.0)
+	var m2 = m * m
+	return m2 * m2 * m
+}
@@ -1,6 +1,7 @@
 package material
 
 import (
+	"gotracer/spectrum"
 	"gotracer/vmath"
 )
 
@@ -10,28 +11,56 @@ type HitRecord struct {
 	T float64
 
 	// Point of collision.
-	P *vmath.Vector3
+	P vmath.Vec3
 
-	// Normal of the surface where the ray collided.
-	Normal *vmath.Vector3
+	// Normal of the surface where the ray collided, always points against the ray direction.
+	Normal vmath.Vec3
+
+	// True if the ray hit the front (outward) side of the surface.
+	FrontFace bool
+
+	// Texture coordinates of the surface where the ray collided.
+	U float64
+	V float64
 
 	// Material in the surface where the ray collided.
 	Material Material
+
+	// Refractive indice of the medium on the outside of the surface, set by the integrator from the media where the ray is.
+	OutsideIOR float64
+
+	// Wavelengths of the path in spectral mode, nil when rendering in RGB.
+	// In spectral mode the colors returned by the materials are the values of the spectrum at each wavelength instead of RGB.
+	Wavelengths *spectrum.Wavelengths
 }
 
 // Create new hitable list
 func NewHitRecord() *HitRecord {
 	var hr = new(HitRecord)
 	hr.T = 0.0
-	hr.P = vmath.NewVector3(0.0, 0.0, 0.0)
-	hr.Normal = vmath.NewVector3(0.0, 0.0, 0.0)
+	hr.OutsideIOR = AirRefractiveIndice
 	return hr
 }
 
 // Copy the content of another hit record to this one.
 func (a *HitRecord) Copy(b *HitRecord) {
 	a.T = b.T
-	a.P.Copy(b.P)
-	a.Normal.Copy(b.Normal)
+	a.P = b.P
+	a.Normal = b.Normal
+	a.FrontFace = b.FrontFace
+	a.U = b.U
+	a.V = b.V
 	a.Material = b.Material
+	a.OutsideIOR = b.OutsideIOR
+	a.Wavelengths = b.Wavelengths
+}
+
+// Set the normal from the outward normal of the surface.
+// The normal is flipped if the ray arrives from the back side and the front face flag is set accordingly.
+func (hr *HitRecord) SetFaceNormal(ray vmath.Ray, outwardNormal vmath.Vec3) {
+	hr.FrontFace = ray.Direction.Dot(outwardNormal) < 0
+	hr.Normal = outwardNormal
+	if !hr.FrontFace {
+		hr.Normal = outwardNormal.Negate()
+	}
 }
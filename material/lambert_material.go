@@ -1,7 +1,10 @@
 package material
 
 import (
+	"gotracer/sampler"
+	"gotracer/texture"
 	"gotracer/vmath"
+	"math"
 )
 
 // Lambert material materials are diffuse objects that don’t emit light merely take on the color of their surroundings.
@@ -9,27 +12,52 @@ import (
 // They also might be absorbed rather than reflected. The darker the surface, the more likely  absorption is.
 type LambertMaterial struct {
 	// Albedo represents the base color of the material.
-	Albedo *vmath.Vector3
+	Albedo texture.Texture
 }
 
-func NewLambertMaterial(albedo *vmath.Vector3) *LambertMaterial {
+func NewLambertMaterial(albedo vmath.Vec3) *LambertMaterial {
+	return NewLambertMaterialTexture(texture.NewSolidColor(albedo))
+}
+
+func NewLambertMaterialTexture(albedo texture.Texture) *LambertMaterial {
 	var m = new(LambertMaterial)
 	m.Albedo = albedo
 	return m
 }
 
-func (m *LambertMaterial) Scatter(ray *vmath.Ray, hitRecord *HitRecord, attenuation *vmath.Vector3, scattered *vmath.Ray) bool {
-	var direction = hitRecord.Normal.Clone()
-	direction.Add(vmath.RandomInUnitSphere())
+// Scatter the ray in a cosine weighted direction around the normal.
+// The attenuation is the albedo since the cosine and the density of the direction cancel out.
+func (m *LambertMaterial) Scatter(ray vmath.Ray, hitRecord *HitRecord, sampler sampler.Sampler) (attenuation vmath.Vec3, scattered vmath.Ray, ok bool) {
+	var normal = FacingNormal(ray, hitRecord)
+
+	var direction = normal.Add(vmath.RandomUnitVector(sampler))
 
-	scattered.Set(hitRecord.P, direction)
-	attenuation.Copy(m.Albedo)
+	// Random vector opposite to the normal
+	if direction.SquaredLength() < 1e-12 {
+		direction = normal
+	}
+
+	return SampleTexture(m.Albedo, hitRecord), vmath.NewRay(hitRecord.P, direction), true
+}
+
+func (m *LambertMaterial) Evaluate(ray vmath.Ray, hitRecord *HitRecord, direction vmath.Vec3) vmath.Vec3 {
+	return SampleTexture(m.Albedo, hitRecord).MulScalar(m.PDF(ray, hitRecord, direction))
+}
+
+func (m *LambertMaterial) PDF(ray vmath.Ray, hitRecord *HitRecord, direction vmath.Vec3) float64 {
+	var cosine = FacingNormal(ray, hitRecord).Dot(direction) / direction.Length()
+	if cosine <= 0 {
+		return 0.0
+	}
+	return cosine / math.Pi
+}
 
-	return true
+func (m *LambertMaterial) Emitted(ray vmath.Ray, hitRecord *HitRecord) vmath.Vec3 {
+	return vmath.Vec3{}
 }
 
 func (o *LambertMaterial) Clone() Material {
 	var m = new(LambertMaterial)
-	m.Albedo = o.Albedo.Clone()
+	m.Albedo = o.Albedo
 	return m
 }
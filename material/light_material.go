@@ -1,31 +1,75 @@
 package material
 
 import (
+	"gotracer/sampler"
+	"gotracer/texture"
 	"gotracer/vmath"
+	"math"
 )
 
 // Light material emits light. The color of the object is the solid color of the light.
+//
+// The surface is a lambertian emitter, the radiance emitted is the same in all directions and is given by the color multiplied by the intensity.
+// The surface does not reflect any light.
 type LightMaterial struct {
 	// Color of the light.
-	Color *vmath.Vector3
+	Color texture.Texture
+
+	// Radiance emitted by the surface (W/sr/m2) for a white color.
+	Intensity float64
+
+	// If true light is only emitted from the front face of the surface (the side where the normal points).
+	OneSided bool
+}
+
+func NewLightMaterial(color vmath.Vec3) *LightMaterial {
+	return NewLightMaterialTexture(texture.NewSolidColor(color))
 }
 
-func NewLightMaterial(color *vmath.Vector3) *LightMaterial {
+func NewLightMaterialTexture(color texture.Texture) *LightMaterial {
 	var m = new(LightMaterial)
 	m.Color = color
+	m.Intensity = 1.0
+	m.OneSided = false
 	return m
 }
 
-func (m *LightMaterial) Scatter(ray *vmath.Ray, hitRecord *HitRecord, attenuation *vmath.Vector3, scattered *vmath.Ray) bool {
+// Create a light material from the total power emitted (W) and the area of the surface.
+// The power is converted into the radiance of a lambertian emitter.
+func NewLightMaterialPower(color vmath.Vec3, power float64, area float64, oneSided bool) *LightMaterial {
+	var m = NewLightMaterial(color)
+	m.Intensity = PowerToRadiance(power, area, oneSided)
+	m.OneSided = oneSided
+	return m
+}
+
+// Convert the total power (W) of a lambertian emitter with a area into radiance.
+// Two sided emitters split the power between both faces.
+func PowerToRadiance(power float64, area float64, oneSided bool) float64 {
+	if !oneSided {
+		area *= 2.0
+	}
+
+	return power / (math.Pi * area)
+}
+
+// Light materials absorb all the light that hits them.
+func (m *LightMaterial) Scatter(ray vmath.Ray, hitRecord *HitRecord, sampler sampler.Sampler) (attenuation vmath.Vec3, scattered vmath.Ray, ok bool) {
+	return vmath.Vec3{}, vmath.Ray{}, false
+}
 
-	scattered.Set(hitRecord.P, hitRecord.Normal.Clone())
-	attenuation.Add(m.Color)
+func (m *LightMaterial) Emitted(ray vmath.Ray, hitRecord *HitRecord) vmath.Vec3 {
+	if m.OneSided && !hitRecord.FrontFace {
+		return vmath.Vec3{}
+	}
 
-	return true
+	return SampleEmission(m.Color, hitRecord).MulScalar(m.Intensity)
 }
 
 func (o *LightMaterial) Clone() Material {
 	var m = new(LightMaterial)
-	m.Color = o.Color.Clone()
+	m.Color = o.Color
+	m.Intensity = o.Intensity
+	m.OneSided = o.OneSided
 	return m
 }
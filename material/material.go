@@ -1,6 +1,8 @@
 package material
 
 import (
+	"gotracer/sampler"
+	"gotracer/texture"
 	"gotracer/vmath"
 )
 
@@ -9,9 +11,37 @@ import (
 type Material interface {
 	// Calculate a scattered ray based on the input ray (that hit the surface).
 	// Produce a scattered ray (or say it absorbed the incident ray), if scattered, say how much the ray should be attenuated.
-	// The return value indicates if if the ray was scatered, if returned false we assume that the ray was absorved.
-	Scatter(ray *vmath.Ray, hitRecord *HitRecord, attenuation *vmath.Vector3, scattered *vmath.Ray) bool
+	// The ok value indicates if if the ray was scatered, if returned false we assume that the ray was absorved.
+	Scatter(ray vmath.Ray, hitRecord *HitRecord, sampler sampler.Sampler) (attenuation vmath.Vec3, scattered vmath.Ray, ok bool)
+
+	// Light emitted by the surface towards the origin of the ray (radiance).
+	// Emission is independent from scattering, materials that do not emit light return black.
+	Emitted(ray vmath.Ray, hitRecord *HitRecord) vmath.Vec3
 
 	// Clone object create a new object with the same properties.
 	Clone() Material
 }
+
+// Get the color of a texture in the surface point of a hit record.
+// In spectral mode the color is converted into the values of its reflectance spectrum at the wavelengths of the path.
+func SampleTexture(t texture.Texture, hitRecord *HitRecord) vmath.Vec3 {
+	return reflectance(t.Value(hitRecord.U, hitRecord.V, hitRecord.P), hitRecord)
+}
+
+// Get the color of a light emitting texture in the surface point of a hit record.
+// In spectral mode the color is converted into the values of its emission spectrum at the wavelengths of the path.
+func SampleEmission(t texture.Texture, hitRecord *HitRecord) vmath.Vec3 {
+	var color = t.Value(hitRecord.U, hitRecord.V, hitRecord.P)
+	if hitRecord.Wavelengths != nil {
+		return hitRecord.Wavelengths.Illuminant(color)
+	}
+	return color
+}
+
+// Convert a reflectance color into the values used by the path, the color is only changed in spectral mode.
+func reflectance(color vmath.Vec3, hitRecord *HitRecord) vmath.Vec3 {
+	if hitRecord.Wavelengths != nil {
+		return hitRecord.Wavelengths.Reflectance(color)
+	}
+	return color
+}
@@ -0,0 +1,201 @@
+package material
+
+import (
+	"gotracer/vmath"
+	"math"
+)
+
+// Medium is the inside of a refractive object, the space filled by the material of the object.
+type Medium struct {
+	// Material of the object that contains the medium, used to find the medium when the ray leaves the object.
+	Material Material
+
+	// Refractive indice of the medium.
+	RefractiveIndice float64
+
+	// Priority of the medium where objects overlap, the medium with the highest priority fills the overlapping space.
+	// Used to model nested dielectrics (e.g. liquid inside a glass), the container should have a higher priority than the liquid.
+	Priority int
+
+	// Absorption coefficient of each color channel per unit of distance, light is attenuated by the Beer-Lambert law.
+	Absorption vmath.Vec3
+
+	// Density of the particles that scatter light inside of the medium (extinction coefficient per unit of distance).
+	// The rays are scattered at the particles by the material of the medium, that must implement BSDF if the density is not zero.
+	Density float64
+
+	// True if the surface of the medium does not refract or reflect light (e.g. the boundary of fog), rays cross it without changing direction.
+	// These media are ignored when looking for the refractive indice and the absorption where the ray is.
+	IndexMatched bool
+}
+
+// Refractive is implemented by materials that refract light to the inside of the objects (dielectrics).
+//
+// The integrator keeps track of the media where the ray is, to know the refractive indice on the other side of the surface and the absorption along the ray.
+// The refractive indice on the outside of the surface is provided to the material in the hit record.
+type Refractive interface {
+	Material
+
+	// Get the medium inside of the object at the hit point, returns false if the surface does not let light inside.
+	Medium(hitRecord *HitRecord) (Medium, bool)
+}
+
+// Calculate the absorption coefficient of a medium from the color remaining after light travels a distance trough it.
+// Returns zero (no absorption) if the distance is not positive.
+func AbsorptionCoefficient(color vmath.Vec3, distance float64) vmath.Vec3 {
+	if distance <= 0 {
+		return vmath.Vec3{}
+	}
+
+	return vmath.Vec3{
+		X: absorptionCoefficient(color.X, distance),
+		Y: absorptionCoefficient(color.Y, distance),
+		Z: absorptionCoefficient(color.Z, distance),
+	}
+}
+
+// Absorption coefficient of a single color channel, black colors are limited to a finite coefficient.
+func absorptionCoefficient(color float64, distance float64) float64 {
+	return -math.Log(math.Min(math.Max(color, 1e-6), 1.0)) / distance
+}
+
+// Maximum number of nested media tracked by the medium stack, media entered when the stack is full are ignored.
+const MaxNestedMedia = 8
+
+// Medium stack tracks the media of the refractive objects where a ray is, used to handle nested and overlapping dielectrics.
+//
+// The ray is assumed to start outside of every object, in air.
+// Surfaces of media with a lower priority than the current medium are not real interfaces and are crossed without refraction.
+type MediumStack struct {
+	media [MaxNestedMedia]Medium
+	count int
+}
+
+// Remove all the media of the stack, used when a new path starts.
+func (s *MediumStack) Reset() {
+	s.count = 0
+}
+
+// Index of the medium filling the space where the ray is, the one with the highest priority (the most recent among equals).
+// The medium at the index skip and the index matched media are ignored, returns -1 if the ray is outside of every medium.
+func (s *MediumStack) current(skip int) int {
+	var index = -1
+	for i := 0; i < s.count; i++ {
+		if i != skip && !s.media[i].IndexMatched && (index == -1 || s.media[i].Priority >= s.media[index].Priority) {
+			index = i
+		}
+	}
+	return index
+}
+
+// Index of the most recent entry of the material in the stack, -1 if the ray is not inside of it.
+func (s *MediumStack) find(material Material) int {
+	for i := s.count - 1; i >= 0; i-- {
+		if s.media[i].Material == material {
+			return i
+		}
+	}
+	return -1
+}
+
+// Refractive indice of the medium where the ray is, the refractive indice of the air if it is outside of every medium.
+func (s *MediumStack) RefractiveIndice() float64 {
+	var index = s.current(-1)
+	if index == -1 {
+		return AirRefractiveIndice
+	}
+	return s.media[index].RefractiveIndice
+}
+
+// Fraction of the light transmitted along a distance trough the medium where the ray is.
+func (s *MediumStack) Transmittance(distance float64) vmath.Vec3 {
+	var index = s.current(-1)
+	if index == -1 || s.media[index].Absorption.IsZero() {
+		return vmath.NewVec3(1.0, 1.0, 1.0)
+	}
+
+	var a = s.media[index].Absorption
+	return vmath.Vec3{X: transmittance(a.X, distance), Y: transmittance(a.Y, distance), Z: transmittance(a.Z, distance)}
+}
+
+// Fraction of the light of a single channel transmitted along a distance.
+// Channels without absorption transmit all the light even along an infinite distance (e.g. towards a directional light).
+func transmittance(absorption float64, distance float64) float64 {
+	if absorption == 0.0 {
+		return 1.0
+	}
+	return math.Exp(-absorption * distance)
+}
+
+// Check if the surface of a medium hit by the ray is a real interface between two media.
+// Returns the refractive indice of the medium on the outside of the surface, and false if the ray should cross the surface without interacting with it.
+func (s *MediumStack) Interface(medium Medium, frontFace bool) (float64, bool) {
+	if medium.IndexMatched {
+		return 0.0, false
+	}
+
+	if frontFace {
+		// Entering a medium with lower priority than the current one
+		var index = s.current(-1)
+		if index == -1 {
+			return AirRefractiveIndice, true
+		}
+		if medium.Priority < s.media[index].Priority {
+			return 0.0, false
+		}
+		return s.media[index].RefractiveIndice, true
+	}
+
+	// Leaving a medium, the ray can only leave the medium that fills the space
+	var self = s.find(medium.Material)
+	if self != -1 && s.current(-1) != self {
+		return 0.0, false
+	}
+
+	var outside = s.current(self)
+	if outside == -1 {
+		return AirRefractiveIndice, true
+	}
+	return s.media[outside].RefractiveIndice, true
+}
+
+// Participating medium where the ray is, the medium with particles that scatter the ray.
+// The most recent index matched medium with particles is used, otherwise the medium filling the space if it has particles.
+// Outside of every medium the atmosphere is used, it can be nil if the scene has no atmosphere. Returns false if the ray is not in a participating medium.
+func (s *MediumStack) Participating(atmosphere *VolumeMaterial) (Medium, bool) {
+	for i := s.count - 1; i >= 0; i-- {
+		if s.media[i].IndexMatched && s.media[i].Density > 0 {
+			return s.media[i], true
+		}
+	}
+
+	var index = s.current(-1)
+	if index != -1 {
+		return s.media[index], s.media[index].Density > 0
+	}
+
+	if atmosphere != nil && atmosphere.Density > 0 {
+		return Medium{Material: atmosphere, RefractiveIndice: AirRefractiveIndice, Density: atmosphere.Density, IndexMatched: true}, true
+	}
+
+	return Medium{}, false
+}
+
+// Update the stack when the ray crosses the surface of a medium, entering it if the surface is the front face or leaving it otherwise.
+func (s *MediumStack) Cross(medium Medium, frontFace bool) {
+	if frontFace {
+		if s.count < MaxNestedMedia {
+			s.media[s.count] = medium
+			s.count++
+		}
+		return
+	}
+
+	var index = s.find(medium.Material)
+	if index == -1 {
+		return
+	}
+
+	copy(s.media[index:s.count], s.media[index+1:s.count])
+	s.count--
+}
@@ -0,0 +1,146 @@
+package material
+
+import (
+	"gotracer/vmath"
+	"math"
+	"testing"
+)
+
+// Surface crossed by a ray in the medium stack tests.
+type testCrossing struct {
+	name      string
+	medium    Medium
+	frontFace bool
+
+	// Refractive indice on the outside of the surface and if it is a interface, expected from the stack before crossing.
+	outside  float64
+	refracts bool
+
+	// Refractive indice where the ray is after crossing the surface.
+	inside float64
+}
+
+func TestMediumStack(t *testing.T) {
+	var glass = Medium{Material: NewDieletricMaterial(1.5, vmath.NewVec3(1.0, 1.0, 1.0)), RefractiveIndice: 1.5, Priority: 2}
+	var water = Medium{Material: NewDieletricMaterial(1.33, vmath.NewVec3(1.0, 1.0, 1.0)), RefractiveIndice: 1.33, Priority: 1}
+	var fog = Medium{Material: NewVolumeMaterial(1.0, vmath.NewVec3(1.0, 1.0, 1.0), NewIsotropicPhaseFunction()), RefractiveIndice: 1.0, Density: 1.0, IndexMatched: true}
+
+	var tests = []struct {
+		name      string
+		crossings []testCrossing
+	}{
+		{
+			"single object",
+			[]testCrossing{
+				{"enter glass", glass, true, 1.0, true, 1.5},
+				{"leave glass", glass, false, 1.0, true, 1.0},
+			},
+		},
+		{
+			// Water inside of a glass, the water overlaps the walls of the glass that has a higher priority
+			"nested",
+			[]testCrossing{
+				{"enter glass", glass, true, 1.0, true, 1.5},
+				{"enter water inside of the wall", water, true, 0.0, false, 1.5},
+				{"leave glass into the water", glass, false, 1.33, true, 1.33},
+				{"leave water", water, false, 1.0, true, 1.0},
+			},
+		},
+		{
+			"index matched",
+			[]testCrossing{
+				{"enter fog", fog, true, 0.0, false, 1.0},
+				{"enter glass inside of the fog", glass, true, 1.0, true, 1.5},
+				{"leave fog inside of the glass", fog, false, 0.0, false, 1.5},
+				{"leave glass", glass, false, 1.0, true, 1.0},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stack MediumStack
+
+			for _, c := range test.crossings {
+				var outside, refracts = stack.Interface(c.medium, c.frontFace)
+				if refracts != c.refracts || (refracts && outside != c.outside) {
+					t.Errorf("%s: interface returned %f %t, expected %f %t", c.name, outside, refracts, c.outside, c.refracts)
+				}
+
+				stack.Cross(c.medium, c.frontFace)
+				if n := stack.RefractiveIndice(); n != c.inside {
+					t.Errorf("%s: refractive indice after crossing is %f, expected %f", c.name, n, c.inside)
+				}
+			}
+
+			if stack.count != 0 {
+				t.Errorf("%d media left in the stack after leaving every object", stack.count)
+			}
+		})
+	}
+}
+
+func TestMediumStackParticipating(t *testing.T) {
+	var atmosphere = NewVolumeMaterial(0.1, vmath.NewVec3(1.0, 1.0, 1.0), NewIsotropicPhaseFunction())
+	var smoke = NewVolumeMaterial(2.0, vmath.NewVec3(1.0, 1.0, 1.0), NewIsotropicPhaseFunction())
+	var glass = NewDieletricMaterial(1.5, vmath.NewVec3(1.0, 1.0, 1.0))
+
+	var stack MediumStack
+
+	if medium, ok := stack.Participating(atmosphere); !ok || medium.Material != atmosphere {
+		t.Errorf("outside of every object the atmosphere is not used")
+	}
+	if _, ok := stack.Participating(nil); ok {
+		t.Errorf("participating medium found without atmosphere")
+	}
+
+	// Inside of a clear dielectric there are no particles, the atmosphere does not enter
+	var hitRecord = NewHitRecord()
+	var glassMedium, _ = glass.Medium(hitRecord)
+	stack.Cross(glassMedium, true)
+	if _, ok := stack.Participating(atmosphere); ok {
+		t.Errorf("participating medium found inside of a clear dielectric")
+	}
+
+	var smokeMedium, _ = smoke.Medium(hitRecord)
+	stack.Cross(smokeMedium, true)
+	if medium, ok := stack.Participating(atmosphere); !ok || medium.Material != smoke || medium.Density != 2.0 {
+		t.Errorf("smoke inside of the glass is not the participating medium")
+	}
+}
+
+func TestMediumTransmittance(t *testing.T) {
+	var color = vmath.NewVec3(0.8, 0.5, 0.1)
+	var absorption = AbsorptionCoefficient(color, 2.0)
+
+	var stack MediumStack
+	stack.Cross(Medium{Material: NewDieletricMaterial(1.5, color), RefractiveIndice: 1.5, Absorption: absorption}, true)
+
+	// The color remains after the absorption distance, and the transmittance of two distances is the product of both
+	var tests = map[float64]vmath.Vec3{
+		0.0: vmath.NewVec3(1.0, 1.0, 1.0),
+		2.0: color,
+		4.0: color.Mul(color),
+	}
+
+	for distance, expected := range tests {
+		var transmittance = stack.Transmittance(distance)
+		if transmittance.Sub(expected).Length() > 1e-9 {
+			t.Errorf("transmittance at %f is %s, expected %s", distance, transmittance.ToString(), expected.ToString())
+		}
+	}
+
+	// Channels without absorption transmit all the light along an infinite distance
+	var partial MediumStack
+	partial.Cross(Medium{Material: NewDieletricMaterial(1.5, color), RefractiveIndice: 1.5, Absorption: AbsorptionCoefficient(vmath.NewVec3(1.0, 0.5, 1.0), 2.0)}, true)
+	if transmittance := partial.Transmittance(math.Inf(1)); transmittance != vmath.NewVec3(1.0, 0.0, 1.0) {
+		t.Errorf("transmittance at an infinite distance is %s, expected (1, 0, 1)", transmittance.ToString())
+	}
+
+	if a := AbsorptionCoefficient(color, 0.0); !a.IsZero() {
+		t.Errorf("absorption without distance is %s", a.ToString())
+	}
+	if a := AbsorptionCoefficient(vmath.Vec3{}, 1.0); math.IsInf(a.X, 0) || a.X <= 0 {
+		t.Errorf("absorption of black is %s", a.ToString())
+	}
+}
@@ -1,46 +1,51 @@
 package material
 
 import (
+	"gotracer/sampler"
+	"gotracer/texture"
 	"gotracer/vmath"
 )
 
 // Metalic object type reflect the rays that hit the object surface.
 type MetalMaterial struct {
 	// Albedo represents the base color of the material.
-	Albedo *vmath.Vector3
+	Albedo texture.Texture
 
 	// Fuzz indicates the roughness of the metallic surface.
 	// The more fuzz there is the more the ray are reflected with an offset applied.
 	Fuzz float64
 }
 
-func NewMetalMaterial(albedo *vmath.Vector3, fuzz float64) *MetalMaterial {
+func NewMetalMaterial(albedo vmath.Vec3, fuzz float64) *MetalMaterial {
+	return NewMetalMaterialTexture(texture.NewSolidColor(albedo), fuzz)
+}
+
+func NewMetalMaterialTexture(albedo texture.Texture, fuzz float64) *MetalMaterial {
 	var m = new(MetalMaterial)
 	m.Albedo = albedo
 	m.Fuzz = fuzz
 	return m
 }
 
-func (m *MetalMaterial) Scatter(ray *vmath.Ray, hitRecord *HitRecord, attenuation *vmath.Vector3, scattered *vmath.Ray) bool {
+func (m *MetalMaterial) Scatter(ray vmath.Ray, hitRecord *HitRecord, sampler sampler.Sampler) (attenuation vmath.Vec3, scattered vmath.Ray, ok bool) {
 
-	var unit = ray.Direction.UnitVector()
+	var unit = ray.Direction.Normalize()
 	var reflected = vmath.Reflect(unit, hitRecord.Normal)
 
 	if m.Fuzz != 0 {
-		var fuzzOffset = vmath.RandomInUnitSphere()
-		fuzzOffset.MulScalar(m.Fuzz)
-		reflected.Add(fuzzOffset)
+		reflected = reflected.AddScaled(vmath.RandomInUnitSphere(sampler), m.Fuzz)
 	}
 
-	scattered.Set(hitRecord.P, reflected)
-	attenuation.Copy(m.Albedo)
+	return SampleTexture(m.Albedo, hitRecord), vmath.NewRay(hitRecord.P, reflected), reflected.Dot(hitRecord.Normal) > 0
+}
 
-	return vmath.Dot(scattered.Direction, hitRecord.Normal) > 0
+func (m *MetalMaterial) Emitted(ray vmath.Ray, hitRecord *HitRecord) vmath.Vec3 {
+	return vmath.Vec3{}
 }
 
 func (o *MetalMaterial) Clone() Material {
 	var m = new(MetalMaterial)
-	m.Albedo = o.Albedo.Clone()
+	m.Albedo = o.Albedo
 	m.Fuzz = o.Fuzz
 	return m
 }
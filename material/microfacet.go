@@ -0,0 +1,213 @@
+package material
+
+import (
+	"gotracer/vmath"
+	"math"
+)
+
+// Smallest roughness (alpha) of the microfacet distribution.
+// Smoother surfaces are close to a perfect mirror and the distribution values become too large to be represented accurately.
+const MinMicrofacetAlpha = 1e-3
+
+// Local shading frame, the normal is the Z axis and the tangents are the X and Y axis.
+type shadingFrame struct {
+	tangent   vmath.Vec3
+	bitangent vmath.Vec3
+	normal    vmath.Vec3
+}
+
+// Create a shading frame around the normal facing the side from where the ray arrived.
+//
+// The geometries do not provide tangents, the tangent is an arbitrary direction perpendicular to the normal.
+// The direction of anisotropic materials follows this tangent and is consistent along a flat surface but not across different objects.
+func newShadingFrame(ray vmath.Ray, hitRecord *HitRecord) shadingFrame {
+	var f shadingFrame
+	f.normal = FacingNormal(ray, hitRecord)
+	f.tangent, f.bitangent = vmath.OrthonormalBasis(f.normal)
+	return f
+}
+
+// Convert a world direction into the local frame.
+func (f shadingFrame) toLocal(v vmath.Vec3) vmath.Vec3 {
+	return vmath.Vec3{X: v.Dot(f.tangent), Y: v.Dot(f.bitangent), Z: v.Dot(f.normal)}
+}
+
+// Convert a local direction into world space.
+func (f shadingFrame) toWorld(v vmath.Vec3) vmath.Vec3 {
+	return f.tangent.MulScalar(v.X).AddScaled(f.bitangent, v.Y).AddScaled(f.normal, v.Z)
+}
+
+// GGX (Trowbridge-Reitz) microfacet distribution with Smith masking-shadowing.
+//
+// The directions used are in the local shading frame and normalized, the alpha values are the roughness along the tangent and bitangent.
+type ggx struct {
+	alphaX float64
+	alphaY float64
+}
+
+// Create a GGX distribution from the perceptual roughness and anisotropy (both in the range [0, 1]).
+// The alpha is the squared roughness, the anisotropy stretches the highlight along the tangent using the mapping of the Disney BRDF.
+func newGGX(roughness float64, anisotropy float64) ggx {
+	var alpha = roughness * roughness
+	var aspect = math.Sqrt(1.0 - 0.9*anisotropy)
+
+	var d ggx
+	d.alphaX = math.Max(MinMicrofacetAlpha, alpha/aspect)
+	d.alphaY = math.Max(MinMicrofacetAlpha, alpha*aspect)
+	return d
+}
+
+// Density of microfacets with the normal wm.
+func (d ggx) D(wm vmath.Vec3) float64 {
+	if wm.Z <= 0 {
+		return 0.0
+	}
+
+	var x = wm.X / d.alphaX
+	var y = wm.Y / d.alphaY
+	var e = x*x + y*y + wm.Z*wm.Z
+
+	return 1.0 / (math.Pi * d.alphaX * d.alphaY * e * e)
+}
+
+// Smith auxiliary function, the ratio between the area of the microfacets hidden and visible from the direction.
+func (d ggx) lambda(w vmath.Vec3) float64 {
+	var z2 = w.Z * w.Z
+	if z2 == 0 {
+		return math.Inf(1)
+	}
+
+	var x = d.alphaX * w.X
+	var y = d.alphaY * w.Y
+
+	return (math.Sqrt(1.0+(x*x+y*y)/z2) - 1.0) * 0.5
+}
+
+// Fraction of the microfacets visible from the direction w.
+func (d ggx) G1(w vmath.Vec3) float64 {
+	return 1.0 / (1.0 + d.lambda(w))
+}
+
+// Fraction of the microfacets visible from both directions, height correlated masking-shadowing.
+func (d ggx) G2(wo vmath.Vec3, wi vmath.Vec3) float64 {
+	return 1.0 / (1.0 + d.lambda(wo) + d.lambda(wi))
+}
+
+// Sample a microfacet normal from the distribution of normals visible from wo (Heitz 2018).
+// The density of the normal is G1(wo) * max(0, wo.wm) * D(wm) / wo.z.
+func (d ggx) SampleVisible(wo vmath.Vec3, u float64, v float64) vmath.Vec3 {
+	// Stretch the view direction to the hemisphere configuration with unit roughness
+	var wh = vmath.Vec3{X: d.alphaX * wo.X, Y: d.alphaY * wo.Y, Z: wo.Z}.Normalize()
+
+	// Orthonormal basis around the view direction
+	var t1 = vmath.Vec3{X: 1.0}
+	var length2 = wh.X*wh.X + wh.Y*wh.Y
+	if length2 > 0 {
+		t1 = vmath.Vec3{X: -wh.Y, Y: wh.X}.DivideScalar(math.Sqrt(length2))
+	}
+	var t2 = wh.Cross(t1)
+
+	// Uniform point in the disk, warped to the projection of the visible hemisphere
+	var r = math.Sqrt(u)
+	var phi = 2.0 * math.Pi * v
+	var p1 = r * math.Cos(phi)
+	var p2 = r * math.Sin(phi)
+	var s = 0.5 * (1.0 + wh.Z)
+	p2 = (1.0-s)*math.Sqrt(1.0-p1*p1) + s*p2
+
+	// Project back to the hemisphere and unstretch the normal
+	var nh = t1.MulScalar(p1).AddScaled(t2, p2).AddScaled(wh, math.Sqrt(math.Max(0.0, 1.0-p1*p1-p2*p2)))
+
+	return vmath.Vec3{X: d.alphaX * nh.X, Y: d.alphaY * nh.Y, Z: math.Max(1e-6, nh.Z)}.Normalize()
+}
+
+// Density (in solid angle) of sampling the reflected direction wi from wo with visible normal sampling.
+func (d ggx) ReflectionPDF(wo vmath.Vec3, wi vmath.Vec3) float64 {
+	if wo.Z <= 0 || wi.Z <= 0 {
+		return 0.0
+	}
+
+	var wm = wo.Add(wi).Normalize()
+
+	return d.G1(wo) * d.D(wm) / (4.0 * wo.Z)
+}
+
+// Reflect the direction w around the microfacet normal wm, both directions point away from the surface.
+func reflectLocal(w vmath.Vec3, wm vmath.Vec3) vmath.Vec3 {
+	return wm.MulScalar(2.0 * w.Dot(wm)).Sub(w)
+}
+
+// Refract the direction w through the microfacet normal wm, both directions point away from the surface.
+// Eta is the refractive indice of the side opposite to w relative to the side of w, returns false for total internal reflection.
+func refractLocal(w vmath.Vec3, wm vmath.Vec3, eta float64) (vmath.Vec3, bool) {
+	var cosi = w.Dot(wm)
+	if cosi < 0 {
+		eta = 1.0 / eta
+		cosi = -cosi
+		wm = wm.Negate()
+	}
+
+	var sin2t = (1.0 - cosi*cosi) / (eta * eta)
+	if sin2t >= 1.0 {
+		return vmath.Vec3{}, false
+	}
+
+	var cost = math.Sqrt(1.0 - sin2t)
+
+	return w.Negate().DivideScalar(eta).AddScaled(wm, cosi/eta-cost), true
+}
+
+// Half vector of a refraction between wo and wi, oriented to the side of the normal.
+// Returns false if the directions are not on opposite sides of the microfacet or the microfacet faces away from wo.
+func refractionHalfVector(wo vmath.Vec3, wi vmath.Vec3, eta float64) (vmath.Vec3, bool) {
+	var wm = wi.MulScalar(eta).Add(wo)
+	if wm.SquaredLength() == 0 {
+		return wm, false
+	}
+
+	wm = wm.Normalize()
+	if wm.Z < 0 {
+		wm = wm.Negate()
+	}
+
+	if wm.Dot(wi) >= 0 || wm.Dot(wo) <= 0 {
+		return wm, false
+	}
+
+	return wm, true
+}
+
+// Density (in solid angle) of sampling the refracted direction wi from wo with visible normal sampling.
+// The density does not include the probability of choosing the refraction over the reflection.
+func (d ggx) RefractionPDF(wo vmath.Vec3, wi vmath.Vec3, eta float64) float64 {
+	if wo.Z <= 0 || wi.Z >= 0 {
+		return 0.0
+	}
+
+	var wm, ok = refractionHalfVector(wo, wi, eta)
+	if !ok {
+		return 0.0
+	}
+
+	var denom = wi.Dot(wm) + wo.Dot(wm)/eta
+	var jacobian = math.Abs(wi.Dot(wm)) / (denom * denom)
+
+	return d.G1(wo) * wo.Dot(wm) * d.D(wm) / wo.Z * jacobian
+}
+
+// Transmitted BTDF of the microfacets multiplied by the cosine of wi, without the Fresnel transmittance.
+// The radiance is scaled by the squared ratio of the refractive indices since the light is compressed into a smaller solid angle.
+func (d ggx) Transmission(wo vmath.Vec3, wi vmath.Vec3, eta float64) float64 {
+	if wo.Z <= 0 || wi.Z >= 0 {
+		return 0.0
+	}
+
+	var wm, ok = refractionHalfVector(wo, wi, eta)
+	if !ok {
+		return 0.0
+	}
+
+	var denom = wi.Dot(wm) + wo.Dot(wm)/eta
+
+	return d.D(wm) * d.G2(wo, wi) * math.Abs(wi.Dot(wm)*wo.Dot(wm)/(denom*denom*wo.Z)) / (eta * eta)
+}
@@ -1,6 +1,7 @@
 package material
 
 import (
+	"gotracer/sampler"
 	"gotracer/vmath"
 )
 
@@ -11,18 +12,16 @@ func NewNormalMaterial() *NormalMaterial {
 	return new(NormalMaterial)
 }
 
-func (m *NormalMaterial) Scatter(ray *vmath.Ray, hitRecord *HitRecord, attenuation *vmath.Vector3, scattered *vmath.Ray) bool {
+func (m *NormalMaterial) Scatter(ray vmath.Ray, hitRecord *HitRecord, sampler sampler.Sampler) (attenuation vmath.Vec3, scattered vmath.Ray, ok bool) {
 
-	var target = hitRecord.Normal.Clone()
-	target.Add(vmath.RandomInUnitSphere())
+	var target = hitRecord.Normal.Add(vmath.RandomInUnitSphere(sampler))
+	var color = reflectance(vmath.NewVec3(hitRecord.Normal.X+1.0, hitRecord.Normal.Y+1.0, hitRecord.Normal.Z+1.0).MulScalar(0.5), hitRecord)
 
-	var color = vmath.NewVector3(hitRecord.Normal.X+1.0, hitRecord.Normal.Y+1.0, hitRecord.Normal.Z+1.0)
-	color.MulScalar(0.5)
-
-	scattered.Set(hitRecord.P, target)
-	attenuation.Copy(color)
+	return color, vmath.NewRay(hitRecord.P, target), true
+}
 
-	return true
+func (m *NormalMaterial) Emitted(ray vmath.Ray, hitRecord *HitRecord) vmath.Vec3 {
+	return vmath.Vec3{}
 }
 
 func (o *NormalMaterial) Clone() Material {
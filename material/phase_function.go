@@ -0,0 +1,75 @@
+package material
+
+import (
+	"gotracer/vmath"
+	"math"
+)
+
+// Phase function describes the distribution of the directions where light is scattered by the particles of a participating medium.
+// It is the equivalent of the BSDF for volumes, the directions are normalized and the direction is the one where the light was traveling.
+type PhaseFunction interface {
+	// Density of the light traveling in the direction being scattered into the scattered direction, integrates to one over the sphere.
+	Evaluate(direction vmath.Vec3, scattered vmath.Vec3) float64
+
+	// Sample a scattered direction, the density of the sample is the value of the phase function.
+	Sample(direction vmath.Vec3, u float64, v float64) vmath.Vec3
+}
+
+// Isotropic phase function scatters light equally in every direction.
+type IsotropicPhaseFunction struct{}
+
+func NewIsotropicPhaseFunction() *IsotropicPhaseFunction {
+	return new(IsotropicPhaseFunction)
+}
+
+func (p *IsotropicPhaseFunction) Evaluate(direction vmath.Vec3, scattered vmath.Vec3) float64 {
+	return 1.0 / (4.0 * math.Pi)
+}
+
+func (p *IsotropicPhaseFunction) Sample(direction vmath.Vec3, u float64, v float64) vmath.Vec3 {
+	return sphericalDirection(direction, 1.0-2.0*u, 2.0*math.Pi*v)
+}
+
+// Henyey-Greenstein phase function, the anisotropy controls the preferred direction of the scattering.
+//
+// The anisotropy G is the average cosine of the scattering angle, in the range (-1, 1).
+// Positive values scatter forward (e.g. fog, clouds), negative values scatter back to the light and zero is isotropic.
+type HenyeyGreensteinPhaseFunction struct {
+	G float64
+}
+
+func NewHenyeyGreensteinPhaseFunction(g float64) *HenyeyGreensteinPhaseFunction {
+	var p = new(HenyeyGreensteinPhaseFunction)
+	p.G = g
+	return p
+}
+
+func (p *HenyeyGreensteinPhaseFunction) Evaluate(direction vmath.Vec3, scattered vmath.Vec3) float64 {
+	return henyeyGreenstein(direction.Dot(scattered), p.G)
+}
+
+// Sample the cosine of the scattering angle by inverting the cumulative distribution of the phase function.
+func (p *HenyeyGreensteinPhaseFunction) Sample(direction vmath.Vec3, u float64, v float64) vmath.Vec3 {
+	var g = p.G
+	var cosine = 1.0 - 2.0*u
+
+	if math.Abs(g) >= 1e-3 {
+		var s = (1.0 - g*g) / (1.0 - g + 2.0*g*u)
+		cosine = (1.0 + g*g - s*s) / (2.0 * g)
+	}
+
+	return sphericalDirection(direction, math.Min(math.Max(cosine, -1.0), 1.0), 2.0*math.Pi*v)
+}
+
+// Value of the Henyey-Greenstein phase function for the cosine of the scattering angle.
+func henyeyGreenstein(cosine float64, g float64) float64 {
+	var denom = 1.0 + g*g - 2.0*g*cosine
+	return (1.0 - g*g) / (4.0 * math.Pi * denom * math.Sqrt(denom))
+}
+
+// Direction with the cosine and azimuth angle relative to the axis.
+func sphericalDirection(axis vmath.Vec3, cosine float64, phi float64) vmath.Vec3 {
+	var sine = math.Sqrt(math.Max(0.0, 1.0-cosine*cosine))
+	var tangent, bitangent = vmath.OrthonormalBasis(axis)
+	return tangent.MulScalar(sine*math.Cos(phi)).AddScaled(bitangent, sine*math.Sin(phi)).AddScaled(axis, cosine)
+}
@@ -0,0 +1,387 @@
+package material
+
+import (
+	"gotracer/sampler"
+	"gotracer/texture"
+	"gotracer/vmath"
+	"math"
+)
+
+// Principled material combines diffuse, metallic, glossy, sheen, clearcoat and glass surfaces in a single material (based on the Disney BSDF).
+//
+// The material is made of lobes weighted by the parameters, the lobes are sampled proportionally to their estimated contribution.
+// Every parameter is a texture, scalar parameters use the first channel of the texture.
+type PrincipledMaterial struct {
+	// Base color of the diffuse surface, the reflection color of metals and the tint of the transmitted light.
+	BaseColor texture.Texture
+
+	// Blends between a dielectric (0) and a metallic surface (1) colored by the base color.
+	Metallic texture.Texture
+
+	// Microfacet roughness of the specular reflection and transmission, also used by the diffuse retro-reflection.
+	Roughness texture.Texture
+
+	// Amount of specular reflection of the dielectric surface, 0.5 is a reflectance of 4% at normal incidence.
+	Specular texture.Texture
+
+	// Tints the dielectric specular reflection towards the base color.
+	SpecularTint texture.Texture
+
+	// Extra reflection at grazing angles for cloth-like surfaces.
+	Sheen texture.Texture
+
+	// Tints the sheen towards the base color.
+	SheenTint texture.Texture
+
+	// Amount of a second white specular layer on top of the surface.
+	Clearcoat texture.Texture
+
+	// Glossiness of the clearcoat layer, zero is a satin finish and one is a gloss finish.
+	ClearcoatGloss texture.Texture
+
+	// Blends between an opaque (0) and a fully transmissive glass surface (1).
+	Transmission texture.Texture
+
+	// Refractive indice of the transmissive surface.
+	IOR texture.Texture
+
+	// Priority of the material where transmissive objects overlap, see Medium.
+	Priority int
+}
+
+func NewPrincipledMaterial(baseColor vmath.Vec3) *PrincipledMaterial {
+	return NewPrincipledMaterialTexture(texture.NewSolidColor(baseColor))
+}
+
+// Create a principled material with the default parameters, a rough dielectric with a white clearcoat gloss and a sheen tint of 0.5.
+func NewPrincipledMaterialTexture(baseColor texture.Texture) *PrincipledMaterial {
+	var m = new(PrincipledMaterial)
+	m.BaseColor = baseColor
+	m.Metallic = texture.NewSolidValue(0.0)
+	m.Roughness = texture.NewSolidValue(0.5)
+	m.Specular = texture.NewSolidValue(0.5)
+	m.SpecularTint = texture.NewSolidValue(0.0)
+	m.Sheen = texture.NewSolidValue(0.0)
+	m.SheenTint = texture.NewSolidValue(0.5)
+	m.Clearcoat = texture.NewSolidValue(0.0)
+	m.ClearcoatGloss = texture.NewSolidValue(1.0)
+	m.Transmission = texture.NewSolidValue(0.0)
+	m.IOR = texture.NewSolidValue(1.5)
+	m.Priority = 0
+	return m
+}
+
+// Parameters of the principled material in a surface point, with the directions in the local shading frame.
+type principledSurface struct {
+	frame shadingFrame
+	wo    vmath.Vec3
+
+	color     vmath.Vec3
+	roughness float64
+	sheen     vmath.Vec3
+	clearcoat float64
+
+	// Reflectance at normal incidence of the opaque dielectric specular reflection
+	specular vmath.Vec3
+
+	// Refractive indice of the inside relative to the side of wo
+	eta float64
+
+	// True in spectral mode, the components of the colors are the values of the spectrum at the wavelengths of the path
+	spectral bool
+
+	// Weights of the metallic, opaque dielectric and transmissive parts of the surface
+	metal  float64
+	opaque float64
+	glass  float64
+
+	distribution          ggx
+	clearcoatDistribution gtr1
+
+	// Probability of sampling the diffuse, specular, clearcoat and transmission lobes
+	diffuseProbability      float64
+	specularProbability     float64
+	clearcoatProbability    float64
+	transmissionProbability float64
+}
+
+// Sample the textures of the material in the hit point and prepare the lobes of the surface.
+func (m *PrincipledMaterial) surface(ray vmath.Ray, hitRecord *HitRecord) principledSurface {
+	var s principledSurface
+	s.frame = newShadingFrame(ray, hitRecord)
+	s.wo = s.frame.toLocal(ray.Direction.Normalize().Negate())
+	s.spectral = hitRecord.Wavelengths != nil
+
+	s.color = SampleTexture(m.BaseColor, hitRecord)
+	s.roughness = sampleValue(m.Roughness, hitRecord)
+
+	var metallic = sampleValue(m.Metallic, hitRecord)
+	var transmission = sampleValue(m.Transmission, hitRecord)
+	var ior = sampleValue(m.IOR, hitRecord)
+
+	// Base color normalized by its luminance, used to tint the specular reflection and the sheen
+	var tint = vmath.NewVec3(1.0, 1.0, 1.0)
+	var luminance = s.weight(s.color)
+	if luminance > 0 {
+		tint = s.color.DivideScalar(luminance)
+	}
+
+	s.specular = lerpColor(vmath.NewVec3(1.0, 1.0, 1.0), tint, sampleValue(m.SpecularTint, hitRecord)).MulScalar(0.08 * sampleValue(m.Specular, hitRecord))
+	s.sheen = lerpColor(vmath.NewVec3(1.0, 1.0, 1.0), tint, sampleValue(m.SheenTint, hitRecord)).MulScalar(sampleValue(m.Sheen, hitRecord))
+	s.clearcoat = sampleValue(m.Clearcoat, hitRecord)
+
+	s.metal = metallic
+	s.opaque = (1.0 - metallic) * (1.0 - transmission)
+	s.glass = (1.0 - metallic) * transmission
+
+	s.eta = ior / hitRecord.OutsideIOR
+	if !hitRecord.FrontFace {
+		s.eta = hitRecord.OutsideIOR / ior
+
+		// Inside of a transmissive object only the glass interface is visible
+		if transmission > 0 {
+			s.metal, s.opaque, s.glass = 0.0, 0.0, 1.0
+			s.sheen = vmath.Vec3{}
+			s.clearcoat = 0.0
+		}
+	}
+
+	s.distribution = newGGX(s.roughness, 0.0)
+	s.clearcoatDistribution = newGTR1(sampleValue(m.ClearcoatGloss, hitRecord))
+
+	// Lobes are sampled proportionally to the light they reflect in the direction of the ray
+	var cosine = math.Max(s.wo.Z, 0.0)
+	s.specularProbability = s.weight(s.fresnel(cosine))
+	s.diffuseProbability = s.opaque * (luminance + s.weight(s.sheen)) * (1.0 - s.specularProbability)
+	s.clearcoatProbability = 0.25 * s.clearcoat * (0.04 + 0.96*schlickWeight(cosine))
+	s.transmissionProbability = s.glass * s.weight(s.color.Sqrt()) * (1.0 - FresnelDielectric(cosine, s.eta))
+
+	var total = s.diffuseProbability + s.specularProbability + s.clearcoatProbability + s.transmissionProbability
+	if total > 0 {
+		s.diffuseProbability /= total
+		s.specularProbability /= total
+		s.clearcoatProbability /= total
+		s.transmissionProbability /= total
+	}
+
+	return s
+}
+
+// Scalar weight of a color used to choose between the lobes.
+// In RGB mode it is the luminance, in spectral mode the average of the values sampled at the wavelengths of the path.
+func (s *principledSurface) weight(color vmath.Vec3) float64 {
+	if s.spectral {
+		return (color.X + color.Y + color.Z) / 3.0
+	}
+	return Luminance(color)
+}
+
+// Fraction of the light that is not reflected by the specular lobe and reaches the diffuse and sheen lobes.
+// In spectral mode each wavelength uses its own reflectance, in RGB mode the luminance of the reflectance is used to keep the diffuse color.
+func (s *principledSurface) transmitted(cosine float64) vmath.Vec3 {
+	var fresnel = s.fresnel(cosine)
+	if s.spectral {
+		return vmath.NewVec3(1.0-fresnel.X, 1.0-fresnel.Y, 1.0-fresnel.Z)
+	}
+
+	var t = 1.0 - Luminance(fresnel)
+	return vmath.NewVec3(t, t, t)
+}
+
+// Fresnel reflectance of the specular lobe, mix of the metallic, opaque dielectric and transmissive surfaces.
+func (s *principledSurface) fresnel(cosine float64) vmath.Vec3 {
+	var f = FresnelSchlick(cosine, s.color).MulScalar(s.metal)
+	f = f.AddScaled(FresnelSchlick(cosine, s.specular), s.opaque)
+
+	var dielectric = FresnelDielectric(cosine, s.eta) * s.glass
+	return f.Add(vmath.NewVec3(dielectric, dielectric, dielectric))
+}
+
+// Evaluate the BSDF multiplied by the cosine and the density of sampling the direction, wi is in the local frame and normalized.
+func (s *principledSurface) evaluate(wi vmath.Vec3) (vmath.Vec3, float64) {
+	var wo = s.wo
+	if wo.Z <= 0 || wi.Z == 0 {
+		return vmath.Vec3{}, 0.0
+	}
+
+	var f vmath.Vec3
+	var pdf = 0.0
+
+	if wi.Z < 0 {
+		if s.transmissionProbability > 0 {
+			var wm, ok = refractionHalfVector(wo, wi, s.eta)
+			if ok {
+				var transmittance = (1.0 - FresnelDielectric(wo.Dot(wm), s.eta)) * s.glass
+				f = s.color.Sqrt().MulScalar(transmittance * s.distribution.Transmission(wo, wi, s.eta))
+				pdf = s.transmissionProbability * s.distribution.RefractionPDF(wo, wi, s.eta)
+			}
+		}
+		return f, pdf
+	}
+
+	var wm = wo.Add(wi).Normalize()
+	var cosd = wi.Dot(wm)
+
+	// Burley diffuse with retro-reflection at grazing angles and sheen, only the light not reflected by the specular lobe reaches them
+	if s.opaque > 0 {
+		var fl = schlickWeight(wi.Z)
+		var fv = schlickWeight(wo.Z)
+		var fd90 = 0.5 + 2.0*s.roughness*cosd*cosd
+		var diffuse = (1.0 + (fd90-1.0)*fl) * (1.0 + (fd90-1.0)*fv) / math.Pi
+
+		var transmitted = s.transmitted(cosd)
+
+		f = s.color.MulScalar(diffuse).AddScaled(s.sheen, schlickWeight(cosd)).Mul(transmitted).MulScalar(s.opaque * wi.Z)
+		pdf += s.diffuseProbability * wi.Z / math.Pi
+	}
+
+	// Specular microfacet reflection
+	var d = s.distribution.D(wm)
+	f = f.AddScaled(s.fresnel(wo.Dot(wm)), d*s.distribution.G2(wo, wi)/(4.0*wo.Z))
+	pdf += s.specularProbability * s.distribution.ReflectionPDF(wo, wi)
+
+	// Clearcoat layer with a fixed reflectance of 4% at normal incidence
+	if s.clearcoat > 0 {
+		var dc = s.clearcoatDistribution.D(wm)
+		var fc = 0.04 + 0.96*schlickWeight(wo.Dot(wm))
+		var gc = clearcoatGeometry.G2(wo, wi)
+
+		var value = 0.25 * s.clearcoat * fc * dc * gc / (4.0 * wo.Z)
+		f = f.Add(vmath.NewVec3(value, value, value))
+		pdf += s.clearcoatProbability * dc * wm.Z / (4.0 * wo.Dot(wm))
+	}
+
+	return f, pdf
+}
+
+// Scatter the ray by choosing one of the lobes of the surface and sampling a direction from it.
+// The attenuation is the BSDF of all the lobes divided by the combined density.
+func (m *PrincipledMaterial) Scatter(ray vmath.Ray, hitRecord *HitRecord, sampler sampler.Sampler) (attenuation vmath.Vec3, scattered vmath.Ray, ok bool) {
+	var s = m.surface(ray, hitRecord)
+
+	var lobe = sampler.Get1D()
+	var u, v = sampler.Get2D()
+
+	if s.wo.Z <= 0 {
+		return attenuation, scattered, false
+	}
+
+	var wi vmath.Vec3
+
+	if lobe < s.diffuseProbability {
+		var r = math.Sqrt(u)
+		var phi = 2.0 * math.Pi * v
+		wi = vmath.Vec3{X: r * math.Cos(phi), Y: r * math.Sin(phi), Z: math.Sqrt(math.Max(0.0, 1.0-u))}
+	} else if lobe < s.diffuseProbability+s.specularProbability {
+		wi = reflectLocal(s.wo, s.distribution.SampleVisible(s.wo, u, v))
+	} else if lobe < s.diffuseProbability+s.specularProbability+s.clearcoatProbability {
+		wi = reflectLocal(s.wo, s.clearcoatDistribution.Sample(u, v))
+	} else {
+		var refracted, refracts = refractLocal(s.wo, s.distribution.SampleVisible(s.wo, u, v), s.eta)
+		if !refracts || refracted.Z >= 0 {
+			return attenuation, scattered, false
+		}
+		wi = refracted
+	}
+
+	// Reflections below the surface are not part of the density of the reflection lobes
+	if lobe < 1.0-s.transmissionProbability && wi.Z <= 0 {
+		return attenuation, scattered, false
+	}
+
+	var f, pdf = s.evaluate(wi)
+	if pdf <= 0 {
+		return attenuation, scattered, false
+	}
+
+	return f.DivideScalar(pdf), vmath.NewRay(hitRecord.P, s.frame.toWorld(wi)), true
+}
+
+func (m *PrincipledMaterial) Evaluate(ray vmath.Ray, hitRecord *HitRecord, direction vmath.Vec3) vmath.Vec3 {
+	var s = m.surface(ray, hitRecord)
+	var f, _ = s.evaluate(s.frame.toLocal(direction.Normalize()))
+	return f
+}
+
+func (m *PrincipledMaterial) PDF(ray vmath.Ray, hitRecord *HitRecord, direction vmath.Vec3) float64 {
+	var s = m.surface(ray, hitRecord)
+	var _, pdf = s.evaluate(s.frame.toLocal(direction.Normalize()))
+	return pdf
+}
+
+// Get the medium inside of the object, only transmissive surfaces let light inside.
+func (m *PrincipledMaterial) Medium(hitRecord *HitRecord) (Medium, bool) {
+	if sampleValue(m.Transmission, hitRecord) <= 0 {
+		return Medium{}, false
+	}
+	return Medium{Material: m, RefractiveIndice: sampleValue(m.IOR, hitRecord), Priority: m.Priority}, true
+}
+
+func (m *PrincipledMaterial) Emitted(ray vmath.Ray, hitRecord *HitRecord) vmath.Vec3 {
+	return vmath.Vec3{}
+}
+
+func (o *PrincipledMaterial) Clone() Material {
+	var m = new(PrincipledMaterial)
+	m.BaseColor = o.BaseColor
+	m.Metallic = o.Metallic
+	m.Roughness = o.Roughness
+	m.Specular = o.Specular
+	m.SpecularTint = o.SpecularTint
+	m.Sheen = o.Sheen
+	m.SheenTint = o.SheenTint
+	m.Clearcoat = o.Clearcoat
+	m.ClearcoatGloss = o.ClearcoatGloss
+	m.Transmission = o.Transmission
+	m.IOR = o.IOR
+	m.Priority = o.Priority
+	return m
+}
+
+// Masking-shadowing of the clearcoat layer, uses a fixed GGX roughness.
+var clearcoatGeometry = ggx{alphaX: 0.25, alphaY: 0.25}
+
+// Generalized Trowbridge-Reitz distribution with exponent one (GTR1), has longer tails than GGX and is used for the clearcoat layer.
+type gtr1 struct {
+	alpha2 float64
+}
+
+// Create a GTR1 distribution from the clearcoat glossiness, alpha goes from 0.1 (satin) to 0.001 (gloss).
+func newGTR1(gloss float64) gtr1 {
+	var alpha = 0.1 + (0.001-0.1)*gloss
+	return gtr1{alpha2: alpha * alpha}
+}
+
+// Density of microfacets with the normal wm.
+func (d gtr1) D(wm vmath.Vec3) float64 {
+	if wm.Z <= 0 {
+		return 0.0
+	}
+	var t = 1.0 + (d.alpha2-1.0)*wm.Z*wm.Z
+	return (d.alpha2 - 1.0) / (math.Pi * math.Log(d.alpha2) * t)
+}
+
+// Sample a microfacet normal with density D(wm) * wm.z.
+func (d gtr1) Sample(u float64, v float64) vmath.Vec3 {
+	var cos2 = (1.0 - math.Pow(d.alpha2, 1.0-u)) / (1.0 - d.alpha2)
+	var cosTheta = math.Sqrt(math.Max(0.0, cos2))
+	var sinTheta = math.Sqrt(math.Max(0.0, 1.0-cos2))
+	var phi = 2.0 * math.Pi * v
+	return vmath.Vec3{X: sinTheta * math.Cos(phi), Y: sinTheta * math.Sin(phi), Z: cosTheta}
+}
+
+// Relative luminance of a linear color (Rec. 709 primaries).
+func Luminance(color vmath.Vec3) float64 {
+	return 0.2126*color.X + 0.7152*color.Y + 0.0722*color.Z
+}
+
+// Get the value of a scalar parameter texture in the surface point of a hit record.
+// Parameters are not colors and are never converted into spectra.
+func sampleValue(t texture.Texture, hitRecord *HitRecord) float64 {
+	return t.Value(hitRecord.U, hitRecord.V, hitRecord.P).X
+}
+
+// Linear interpolation between two colors.
+func lerpColor(a vmath.Vec3, b vmath.Vec3, t float64) vmath.Vec3 {
+	return a.AddScaled(b.Sub(a), t)
+}
@@ -0,0 +1,166 @@
+package material
+
+import (
+	"gotracer/sampler"
+	"gotracer/texture"
+	"gotracer/vmath"
+)
+
+// Rough dielectric material is a frosted glass surface described by a microfacet model.
+//
+// The surface is made of tiny smooth interfaces with normals distributed by the GGX distribution, light is reflected or refracted by each one of them.
+// Unlike the smooth dielectric material it can be evaluated for any pair of directions and used with explicit light sampling.
+type RoughDielectricMaterial struct {
+	// Refractive indice of the material.
+	RefractiveIndice float64
+
+	// Perceptual roughness in the range [0, 1], use the smooth dielectric material for perfectly smooth surfaces.
+	Roughness float64
+
+	// Albedo tints the light at each interaction with the surface.
+	Albedo texture.Texture
+
+	// Color of the light after traveling the absorption distance inside of the material (Beer-Lambert law).
+	// There is no absorption if the distance is zero.
+	AbsorptionColor    vmath.Vec3
+	AbsorptionDistance float64
+
+	// Priority of the material where dielectric objects overlap, see Medium.
+	Priority int
+}
+
+func NewRoughDielectricMaterial(refractiveIndice float64, roughness float64, albedo vmath.Vec3) *RoughDielectricMaterial {
+	return NewRoughDielectricMaterialTexture(refractiveIndice, roughness, texture.NewSolidColor(albedo))
+}
+
+func NewRoughDielectricMaterialTexture(refractiveIndice float64, roughness float64, albedo texture.Texture) *RoughDielectricMaterial {
+	var m = new(RoughDielectricMaterial)
+	m.RefractiveIndice = refractiveIndice
+	m.Roughness = roughness
+	m.Albedo = albedo
+	m.AbsorptionColor = vmath.NewVec3(1.0, 1.0, 1.0)
+	m.AbsorptionDistance = 0.0
+	m.Priority = 0
+	return m
+}
+
+// Refractive indice of the side opposite to the ray relative to the side of the ray.
+func (m *RoughDielectricMaterial) eta(hitRecord *HitRecord) float64 {
+	if hitRecord.FrontFace {
+		return m.RefractiveIndice / hitRecord.OutsideIOR
+	}
+	return hitRecord.OutsideIOR / m.RefractiveIndice
+}
+
+// Scatter the ray on a microfacet normal sampled from the normals visible from the ray.
+// The ray is reflected with the probability given by the Fresnel reflectance of the microfacet and refracted otherwise.
+func (m *RoughDielectricMaterial) Scatter(ray vmath.Ray, hitRecord *HitRecord, sampler sampler.Sampler) (attenuation vmath.Vec3, scattered vmath.Ray, ok bool) {
+	var frame = newShadingFrame(ray, hitRecord)
+	var distribution = newGGX(m.Roughness, 0.0)
+	var eta = m.eta(hitRecord)
+
+	var choice = sampler.Get1D()
+	var u, v = sampler.Get2D()
+
+	var wo = frame.toLocal(ray.Direction.Normalize().Negate())
+	if wo.Z <= 0 {
+		return attenuation, scattered, false
+	}
+
+	var wm = distribution.SampleVisible(wo, u, v)
+	var wi vmath.Vec3
+	var scale = 1.0
+
+	if choice < FresnelDielectric(wo.Dot(wm), eta) {
+		wi = reflectLocal(wo, wm)
+		if wi.Z <= 0 {
+			return attenuation, scattered, false
+		}
+	} else {
+		var refracts bool
+		wi, refracts = refractLocal(wo, wm, eta)
+		if !refracts || wi.Z >= 0 {
+			return attenuation, scattered, false
+		}
+
+		// Radiance is scaled by the change of solid angle
+		scale = 1.0 / (eta * eta)
+	}
+
+	// The Fresnel term and the density of the microfacet cancel out
+	attenuation = SampleTexture(m.Albedo, hitRecord).MulScalar(scale * distribution.G2(wo, wi) / distribution.G1(wo))
+
+	return attenuation, vmath.NewRay(hitRecord.P, frame.toWorld(wi)), true
+}
+
+func (m *RoughDielectricMaterial) Evaluate(ray vmath.Ray, hitRecord *HitRecord, direction vmath.Vec3) vmath.Vec3 {
+	var frame = newShadingFrame(ray, hitRecord)
+	var distribution = newGGX(m.Roughness, 0.0)
+	var eta = m.eta(hitRecord)
+
+	var wo = frame.toLocal(ray.Direction.Normalize().Negate())
+	var wi = frame.toLocal(direction.Normalize())
+	if wo.Z <= 0 || wi.Z == 0 {
+		return vmath.Vec3{}
+	}
+
+	var albedo = SampleTexture(m.Albedo, hitRecord)
+
+	if wi.Z > 0 {
+		var wm = wo.Add(wi).Normalize()
+		var fresnel = FresnelDielectric(wo.Dot(wm), eta)
+		return albedo.MulScalar(fresnel * distribution.D(wm) * distribution.G2(wo, wi) / (4.0 * wo.Z))
+	}
+
+	var wm, ok = refractionHalfVector(wo, wi, eta)
+	if !ok {
+		return vmath.Vec3{}
+	}
+
+	var fresnel = FresnelDielectric(wo.Dot(wm), eta)
+	return albedo.MulScalar((1.0 - fresnel) * distribution.Transmission(wo, wi, eta))
+}
+
+func (m *RoughDielectricMaterial) PDF(ray vmath.Ray, hitRecord *HitRecord, direction vmath.Vec3) float64 {
+	var frame = newShadingFrame(ray, hitRecord)
+	var distribution = newGGX(m.Roughness, 0.0)
+	var eta = m.eta(hitRecord)
+
+	var wo = frame.toLocal(ray.Direction.Normalize().Negate())
+	var wi = frame.toLocal(direction.Normalize())
+	if wo.Z <= 0 || wi.Z == 0 {
+		return 0.0
+	}
+
+	if wi.Z > 0 {
+		var wm = wo.Add(wi).Normalize()
+		return FresnelDielectric(wo.Dot(wm), eta) * distribution.ReflectionPDF(wo, wi)
+	}
+
+	var wm, ok = refractionHalfVector(wo, wi, eta)
+	if !ok {
+		return 0.0
+	}
+
+	return (1.0 - FresnelDielectric(wo.Dot(wm), eta)) * distribution.RefractionPDF(wo, wi, eta)
+}
+
+// Get the medium inside of the object, dielectric materials always let light inside.
+func (m *RoughDielectricMaterial) Medium(hitRecord *HitRecord) (Medium, bool) {
+	return Medium{Material: m, RefractiveIndice: m.RefractiveIndice, Priority: m.Priority, Absorption: AbsorptionCoefficient(reflectance(m.AbsorptionColor, hitRecord), m.AbsorptionDistance)}, true
+}
+
+func (m *RoughDielectricMaterial) Emitted(ray vmath.Ray, hitRecord *HitRecord) vmath.Vec3 {
+	return vmath.Vec3{}
+}
+
+func (o *RoughDielectricMaterial) Clone() Material {
+	var m = new(RoughDielectricMaterial)
+	m.RefractiveIndice = o.RefractiveIndice
+	m.Roughness = o.Roughness
+	m.Albedo = o.Albedo
+	m.AbsorptionColor = o.AbsorptionColor
+	m.AbsorptionDistance = o.AbsorptionDistance
+	m.Priority = o.Priority
+	return m
+}
@@ -0,0 +1,69 @@
+package material
+
+import (
+	"gotracer/sampler"
+	"gotracer/texture"
+	"gotracer/vmath"
+)
+
+// Volume material describes a participating medium with constant density (fog, smoke, murky liquids).
+//
+// The surface of an object with a volume material is only the boundary of the medium, rays cross it without changing direction.
+// Inside of the medium the rays are scattered by particles at random distances sampled by the integrator, the material scatters the ray at these points using the phase function.
+type VolumeMaterial struct {
+	// Density of the particles, the probability of a ray hitting a particle per unit of distance (extinction coefficient).
+	Density float64
+
+	// Fraction of the light scattered when a particle is hit, the rest is absorbed.
+	Albedo texture.Texture
+
+	// Distribution of the directions of the scattered light.
+	Phase PhaseFunction
+}
+
+func NewVolumeMaterial(density float64, albedo vmath.Vec3, phase PhaseFunction) *VolumeMaterial {
+	return NewVolumeMaterialTexture(density, texture.NewSolidColor(albedo), phase)
+}
+
+func NewVolumeMaterialTexture(density float64, albedo texture.Texture, phase PhaseFunction) *VolumeMaterial {
+	var m = new(VolumeMaterial)
+	m.Density = density
+	m.Albedo = albedo
+	m.Phase = phase
+	return m
+}
+
+// Scatter the ray at a particle in a direction sampled from the phase function.
+// The attenuation is the albedo since the phase function and the density of the direction cancel out.
+func (m *VolumeMaterial) Scatter(ray vmath.Ray, hitRecord *HitRecord, sampler sampler.Sampler) (attenuation vmath.Vec3, scattered vmath.Ray, ok bool) {
+	var u, v = sampler.Get2D()
+	var direction = m.Phase.Sample(ray.Direction.Normalize(), u, v)
+
+	return SampleTexture(m.Albedo, hitRecord), vmath.NewRay(hitRecord.P, direction), true
+}
+
+// Particles have no surface, the value is the phase function without any cosine.
+func (m *VolumeMaterial) Evaluate(ray vmath.Ray, hitRecord *HitRecord, direction vmath.Vec3) vmath.Vec3 {
+	return SampleTexture(m.Albedo, hitRecord).MulScalar(m.PDF(ray, hitRecord, direction))
+}
+
+func (m *VolumeMaterial) PDF(ray vmath.Ray, hitRecord *HitRecord, direction vmath.Vec3) float64 {
+	return m.Phase.Evaluate(ray.Direction.Normalize(), direction.Normalize())
+}
+
+// Get the medium inside of the object, the boundary does not refract light and does not change the refractive indice.
+func (m *VolumeMaterial) Medium(hitRecord *HitRecord) (Medium, bool) {
+	return Medium{Material: m, RefractiveIndice: hitRecord.OutsideIOR, Density: m.Density, IndexMatched: true}, true
+}
+
+func (m *VolumeMaterial) Emitted(ray vmath.Ray, hitRecord *HitRecord) vmath.Vec3 {
+	return vmath.Vec3{}
+}
+
+func (o *VolumeMaterial) Clone() Material {
+	var m = new(VolumeMaterial)
+	m.Density = o.Density
+	m.Albedo = o.Albedo
+	m.Phase = o.Phase
+	return m
+}
@@ -0,0 +1,155 @@
+package objfile
+
+import (
+	"errors"
+	"gotracer/geometry"
+	"gotracer/material"
+	"gotracer/texture"
+	"gotracer/vmath"
+	"math"
+)
+
+// Create a indexed mesh of the model with the materials of the MTL files.
+// Faces without material use the default material, if nil a lambert material with the MTL default color is used.
+func (model *Model) Mesh(defaultMaterial material.Material) (*geometry.Mesh, error) {
+	if defaultMaterial == nil {
+		defaultMaterial = material.NewLambertMaterial(vmath.NewVec3(0.8, 0.8, 0.8))
+	}
+
+	// The default material is the first, the MTL materials are added when used for the first time
+	var materials = []material.Material{defaultMaterial}
+	var materialIndex = map[string]int32{"": 0}
+	var textures = map[string]texture.Texture{}
+
+	var count = len(model.Faces)
+	if count == 0 {
+		return nil, errors.New("model has no faces")
+	}
+
+	var positionIndices = make([]int32, 0, count*3)
+	var normalIndices = make([]int32, 0, count*3)
+	var texCoordIndices = make([]int32, 0, count*3)
+	var faceMaterials = make([]int32, 0, count)
+
+	for i := 0; i < count; i++ {
+		var face = model.Faces[i]
+
+		var index, ok = materialIndex[face.Material]
+		if !ok {
+			var m, err = model.Materials[face.Material].Material(textures)
+			if err != nil {
+				return nil, err
+			}
+
+			index = int32(len(materials))
+			materials = append(materials, m)
+			materialIndex[face.Material] = index
+		}
+
+		faceMaterials = append(faceMaterials, index)
+
+		for j := 0; j < 3; j++ {
+			positionIndices = append(positionIndices, int32(face.Vertices[j].Position))
+			normalIndices = append(normalIndices, int32(face.Vertices[j].Normal))
+			texCoordIndices = append(texCoordIndices, int32(face.Vertices[j].TexCoord))
+		}
+	}
+
+	var mesh = geometry.NewMesh(values(model.Positions, false), positionIndices, materials, faceMaterials)
+	mesh.DefaultMaterial = materialIndex[""]
+
+	if len(model.Normals) > 0 {
+		mesh.Normals = values(model.Normals, true)
+		mesh.NormalIndices = normalIndices
+	}
+
+	if len(model.TexCoords) > 0 {
+		mesh.TexCoords = values(model.TexCoords, false)
+		mesh.TexCoordIndices = texCoordIndices
+	}
+
+	return mesh, nil
+}
+
+// Copy a list of vectors into a new array, optionally normalizing them.
+func values(list []vmath.Vec3, normalize bool) []vmath.Vec3 {
+	var array = make([]vmath.Vec3, len(list))
+	for i := 0; i < len(list); i++ {
+		if normalize {
+			array[i] = list[i].Normalize()
+		} else {
+			array[i] = list[i]
+		}
+	}
+	return array
+}
+
+// Create a renderer material that approximates the MTL material.
+//
+// Emissive materials (Ke) are converted into light materials and materials with parameters of the PBR extension (Pr, Pm, Ps, Pc, Pcr) into principled materials.
+// Other transparent materials (d < 1) are converted into dielectrics, materials with a specular color (Ks) brighter than the diffuse color into metals and all others into lambert materials.
+// Textures are loaded once and stored in the textures map indexed by path.
+func (m *MTLMaterial) Material(textures map[string]texture.Texture) (material.Material, error) {
+	if m.Emission.MaxComponent() > 0.0 {
+		return material.NewLightMaterial(m.Emission), nil
+	}
+
+	var refractiveIndex = m.RefractiveIndex
+	if refractiveIndex <= 1.0 {
+		refractiveIndex = 1.5
+	}
+
+	if m.PBR {
+		var diffuse, err = m.diffuseTexture(textures)
+		if err != nil {
+			return nil, err
+		}
+
+		var p = material.NewPrincipledMaterialTexture(diffuse)
+		p.Roughness = texture.NewSolidValue(m.Roughness)
+		p.Metallic = texture.NewSolidValue(m.Metallic)
+		p.Sheen = texture.NewSolidValue(m.Sheen)
+		p.Clearcoat = texture.NewSolidValue(m.Clearcoat)
+		p.ClearcoatGloss = texture.NewSolidValue(1.0 - m.ClearcoatRoughness)
+		p.Transmission = texture.NewSolidValue(1.0 - m.Dissolve)
+		p.IOR = texture.NewSolidValue(refractiveIndex)
+		return p, nil
+	}
+
+	if m.Dissolve < 1.0 {
+		return material.NewDieletricMaterial(refractiveIndex, m.Transmission), nil
+	}
+
+	if m.Specular.MaxComponent() > m.Diffuse.MaxComponent() {
+		// Phong exponent converted into roughness
+		var fuzz = math.Min(math.Sqrt(2.0/(m.Shininess+2.0)), 1.0)
+		return material.NewMetalMaterial(m.Specular, fuzz), nil
+	}
+
+	var diffuse, err = m.diffuseTexture(textures)
+	if err != nil {
+		return nil, err
+	}
+
+	return material.NewLambertMaterialTexture(diffuse), nil
+}
+
+// Get the diffuse texture (map_Kd) or a solid texture with the diffuse color if there is no texture.
+// Textures are loaded once and stored in the textures map indexed by path.
+func (m *MTLMaterial) diffuseTexture(textures map[string]texture.Texture) (texture.Texture, error) {
+	if m.DiffuseMap == "" {
+		return texture.NewSolidColor(m.Diffuse), nil
+	}
+
+	var t, ok = textures[m.DiffuseMap]
+	if !ok {
+		var image, err = texture.LoadImageTexture(m.DiffuseMap)
+		if err != nil {
+			return nil, err
+		}
+		t = image
+		textures[m.DiffuseMap] = t
+	}
+
+	return t, nil
+}
@@ -0,0 +1,19 @@
+package objfile
+
+import "fmt"
+
+// Error found while reading a OBJ or MTL file, indicates the file and the line where the problem was found.
+type Error struct {
+	// Name of the file.
+	File string
+
+	// Line in the file (starting at 1).
+	Line int
+
+	// Description of the problem.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+}
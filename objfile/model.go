@@ -0,0 +1,45 @@
+package objfile
+
+import (
+	"gotracer/vmath"
+)
+
+// Model loaded from a OBJ file, polygons are triangulated while the file is read.
+type Model struct {
+	// Vertex attributes in the order they were declared in the file.
+	Positions []vmath.Vec3
+	Normals   []vmath.Vec3
+	TexCoords []vmath.Vec3
+
+	// Triangles of the model.
+	Faces []*Face
+
+	// Materials declared in the MTL files referenced by the model, indexed by name.
+	Materials map[string]*MTLMaterial
+
+	// Problems found while reading the file that did not prevent the model from loading (e.g. undefined materials).
+	Warnings []error
+}
+
+// Triangle of the model, stores the index of the attributes of each vertex.
+type Face struct {
+	Vertices [3]Vertex
+
+	// Name of the material of the face, empty if no material was used.
+	Material string
+}
+
+// Vertex of a face, contains the index of each attribute in the model arrays.
+// Attributes that are not present in the file have the index -1.
+type Vertex struct {
+	Position int
+	TexCoord int
+	Normal   int
+}
+
+// Create a empty model.
+func NewModel() *Model {
+	var m = new(Model)
+	m.Materials = map[string]*MTLMaterial{}
+	return m
+}
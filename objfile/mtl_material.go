@@ -0,0 +1,57 @@
+package objfile
+
+import (
+	"gotracer/vmath"
+)
+
+// Material declared in a MTL file.
+type MTLMaterial struct {
+	// Name of the material.
+	Name string
+
+	// Diffuse (Kd), specular (Ks), emissive (Ke) and transmission filter (Tf) colors.
+	Diffuse      vmath.Vec3
+	Specular     vmath.Vec3
+	Emission     vmath.Vec3
+	Transmission vmath.Vec3
+
+	// Specular exponent (Ns).
+	Shininess float64
+
+	// Index of refraction (Ni).
+	RefractiveIndex float64
+
+	// Opacity of the material (d), transparent materials have values smaller than one.
+	Dissolve float64
+
+	// Illumination model (illum).
+	Illumination int
+
+	// Physically based parameters of the PBR extension, roughness (Pr), metallic (Pm), sheen (Ps), clearcoat thickness (Pc) and clearcoat roughness (Pcr).
+	Roughness          float64
+	Metallic           float64
+	Sheen              float64
+	Clearcoat          float64
+	ClearcoatRoughness float64
+
+	// Indicates if any of the parameters of the PBR extension was declared.
+	PBR bool
+
+	// Path of the diffuse texture (map_Kd), relative paths are resolved from the directory of the MTL file.
+	DiffuseMap string
+}
+
+// Create a material with the default values defined by the MTL format.
+func NewMTLMaterial(name string) *MTLMaterial {
+	var m = new(MTLMaterial)
+	m.Name = name
+	m.Diffuse = vmath.NewVec3(0.8, 0.8, 0.8)
+	m.Specular = vmath.NewVec3(0.0, 0.0, 0.0)
+	m.Emission = vmath.NewVec3(0.0, 0.0, 0.0)
+	m.Transmission = vmath.NewVec3(1.0, 1.0, 1.0)
+	m.Shininess = 0.0
+	m.RefractiveIndex = 1.0
+	m.Dissolve = 1.0
+	m.Illumination = 2
+	return m
+}
@@ -0,0 +1,205 @@
+package objfile
+
+import (
+	"bufio"
+	"gotracer/vmath"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Load the materials of a MTL file.
+func LoadMTL(fname string) (map[string]*MTLMaterial, error) {
+	var file, err = os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var materials = map[string]*MTLMaterial{}
+	return materials, ReadMTL(file, fname, filepath.Dir(fname), materials)
+}
+
+// Read the materials of a MTL file into the materials map, the name is used to report errors.
+// Texture paths are resolved from the directory provided.
+func ReadMTL(reader io.Reader, name string, dir string, materials map[string]*MTLMaterial) error {
+	var p = &parser{file: name, dir: dir}
+	var current *MTLMaterial
+
+	var scanner = bufio.NewScanner(reader)
+	scanner.Buffer(nil, 1024*1024)
+
+	for scanner.Scan() {
+		p.line++
+
+		var text = scanner.Text()
+		var fields = strings.Fields(text)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if fields[0] == "newmtl" {
+			if len(fields) < 2 {
+				return p.errorf("newmtl without material name")
+			}
+			current = NewMTLMaterial(strings.Join(fields[1:], " "))
+			materials[current.Name] = current
+			continue
+		}
+
+		if current == nil {
+			return p.errorf("%s found before newmtl", fields[0])
+		}
+
+		var err error
+
+		switch fields[0] {
+		case "Kd":
+			current.Diffuse, err = p.color(fields[1:])
+		case "Ks":
+			current.Specular, err = p.color(fields[1:])
+		case "Ke":
+			current.Emission, err = p.color(fields[1:])
+		case "Tf":
+			current.Transmission, err = p.color(fields[1:])
+		case "Ns":
+			current.Shininess, err = p.scalar(fields[1:])
+		case "Ni":
+			current.RefractiveIndex, err = p.scalar(fields[1:])
+		case "d":
+			current.Dissolve, err = p.scalar(fields[1:])
+		case "Tr":
+			var tr float64
+			tr, err = p.scalar(fields[1:])
+			current.Dissolve = 1.0 - tr
+		case "illum":
+			var illum float64
+			illum, err = p.scalar(fields[1:])
+			current.Illumination = int(illum)
+		case "Pr":
+			current.Roughness, err = p.scalar(fields[1:])
+			current.PBR = true
+		case "Pm":
+			current.Metallic, err = p.scalar(fields[1:])
+			current.PBR = true
+		case "Ps":
+			current.Sheen, err = p.scalar(fields[1:])
+			current.PBR = true
+		case "Pc":
+			current.Clearcoat, err = p.scalar(fields[1:])
+			current.PBR = true
+		case "Pcr":
+			current.ClearcoatRoughness, err = p.scalar(fields[1:])
+			current.PBR = true
+		case "map_Kd":
+			var fname string
+			fname, err = p.textureFile(text, fields)
+			if err == nil {
+				if !filepath.IsAbs(fname) {
+					fname = filepath.Join(p.dir, fname)
+				}
+				current.DiffuseMap = fname
+			}
+		default:
+			// Ambient color and the other texture maps are not used
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return p.errorf("%s", err.Error())
+	}
+
+	return nil
+}
+
+// Parse a color, a single value is used for all components.
+func (p *parser) color(fields []string) (vmath.Vec3, error) {
+	if len(fields) == 1 {
+		var v, err = p.number(fields[0])
+		if err != nil {
+			return vmath.Vec3{}, err
+		}
+		return vmath.NewVec3(v, v, v), nil
+	}
+
+	if len(fields) > 0 && (fields[0] == "spectral" || fields[0] == "xyz") {
+		return vmath.Vec3{}, p.errorf("%s colors are not supported", fields[0])
+	}
+
+	return p.vector(fields, 3, 0.0)
+}
+
+// Parse a single number value.
+func (p *parser) scalar(fields []string) (float64, error) {
+	if len(fields) != 1 {
+		return 0.0, p.errorf("expected one value")
+	}
+	return p.number(fields[0])
+}
+
+// Number of values of the texture map options, the offset (-o), scale (-s) and turbulence (-t) options take one to three values.
+var textureOptions = map[string]int{
+	"-blendu":  1,
+	"-blendv":  1,
+	"-bm":      1,
+	"-boost":   1,
+	"-cc":      1,
+	"-clamp":   1,
+	"-imfchan": 1,
+	"-mm":      2,
+	"-texres":  1,
+	"-type":    1,
+	"-o":       3,
+	"-s":       3,
+	"-t":       3,
+}
+
+// Get the file name of a texture map statement.
+// The options before the file name are skipped (they are not used), the name is the rest of the line so it can contain spaces.
+func (p *parser) textureFile(text string, fields []string) (string, error) {
+	var start = 1
+
+	for start < len(fields) && strings.HasPrefix(fields[start], "-") {
+		var option = fields[start]
+		var count, ok = textureOptions[option]
+		if !ok {
+			return "", p.errorf("unknown texture option %q", option)
+		}
+
+		start++
+
+		// The vector options only require the first value
+		var required = count
+		if option == "-o" || option == "-s" || option == "-t" {
+			required = 1
+		}
+
+		for i := 0; i < count && start < len(fields); i++ {
+			// The optional values end at the first field that is not a number
+			if i >= required {
+				if _, err := strconv.ParseFloat(fields[start], 64); err != nil {
+					break
+				}
+			}
+			start++
+		}
+	}
+
+	if start >= len(fields) {
+		return "", p.errorf("%s without file name", fields[0])
+	}
+
+	// Remove the statement and the options from the line, keeping the spaces of the name
+	var rest = strings.TrimSpace(text)
+	for i := 0; i < start; i++ {
+		rest = strings.TrimLeft(rest[len(fields[i]):], " \t")
+	}
+
+	return strings.TrimSpace(rest), nil
+}
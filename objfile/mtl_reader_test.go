@@ -0,0 +1,111 @@
+package objfile
+
+import (
+	"errors"
+	"gotracer/vmath"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Read the materials of a MTL file from a string, textures are resolved from the directory "textures".
+func readMTLString(t *testing.T, data string) map[string]*MTLMaterial {
+	t.Helper()
+
+	var materials = map[string]*MTLMaterial{}
+	var err = ReadMTL(strings.NewReader(data), "test.mtl", "textures", materials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return materials
+}
+
+func TestReadMTL(t *testing.T) {
+	var materials = readMTLString(t, `# Materials exported for the tests
+newmtl glass
+Kd 0.1 0.2 0.3
+Ks 0.5
+Ns 250
+Ni 1.45
+d 0.25
+
+newmtl lamp
+Ke 4 3 2
+map_Kd lamp.png
+`)
+
+	if len(materials) != 2 {
+		t.Fatalf("read %d materials, expected 2", len(materials))
+	}
+
+	var glass = materials["glass"]
+	if glass.Diffuse != vmath.NewVec3(0.1, 0.2, 0.3) || glass.Specular != vmath.NewVec3(0.5, 0.5, 0.5) {
+		t.Errorf("glass has diffuse %s and specular %s", glass.Diffuse.ToString(), glass.Specular.ToString())
+	}
+	if glass.Shininess != 250 || glass.RefractiveIndex != 1.45 || glass.Dissolve != 0.25 {
+		t.Errorf("glass has shininess %f, refractive index %f and dissolve %f", glass.Shininess, glass.RefractiveIndex, glass.Dissolve)
+	}
+	if glass.DiffuseMap != "" || glass.Emission != (vmath.Vec3{}) {
+		t.Errorf("glass has a diffuse map %q or emission %s", glass.DiffuseMap, glass.Emission.ToString())
+	}
+
+	var lamp = materials["lamp"]
+	if lamp.Emission != vmath.NewVec3(4, 3, 2) {
+		t.Errorf("lamp has emission %s", lamp.Emission.ToString())
+	}
+	if lamp.DiffuseMap != filepath.Join("textures", "lamp.png") {
+		t.Errorf("lamp has diffuse map %q", lamp.DiffuseMap)
+	}
+	if lamp.Diffuse != vmath.NewVec3(0.8, 0.8, 0.8) || lamp.Dissolve != 1.0 {
+		t.Errorf("lamp does not use the default values")
+	}
+}
+
+func TestReadMTLTextureOptions(t *testing.T) {
+	var tests = map[string]string{
+		"map_Kd wood.png":                                 "wood.png",
+		"map_Kd -s 1 1 1 wood.png":                        "wood.png",
+		"map_Kd -o 0.5 wood.png":                          "wood.png",
+		"map_Kd -blendu off -blendv off -bm 0.5 wood.png": "wood.png",
+		"map_Kd -mm 0 1 -clamp on -s 2 2 old wood.png":    "old wood.png",
+		"map_Kd  my   textures/wood 2.png ":               "my   textures/wood 2.png",
+	}
+
+	for line, expected := range tests {
+		t.Run(line, func(t *testing.T) {
+			var materials = readMTLString(t, "newmtl wood\n"+line+"\n")
+			if m := materials["wood"].DiffuseMap; m != filepath.Join("textures", expected) {
+				t.Errorf("diffuse map is %q, expected %q", m, filepath.Join("textures", expected))
+			}
+		})
+	}
+}
+
+func TestReadMTLErrors(t *testing.T) {
+	var tests = []struct {
+		name    string
+		data    string
+		line    int
+		message string
+	}{
+		{"before newmtl", "Kd 1 1 1\n", 1, "Kd found before newmtl"},
+		{"color size", "newmtl a\nKd 1 1\n", 2, "expected at least 3 values"},
+		{"scalar", "newmtl a\nNs\n", 2, "expected one value"},
+		{"texture without file", "newmtl a\nmap_Kd -s 1 1 1\n", 2, "map_Kd without file name"},
+		{"texture option", "newmtl a\nmap_Kd -unknown 1 wood.png\n", 2, `unknown texture option "-unknown"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var err = ReadMTL(strings.NewReader(test.data), "test.mtl", "", map[string]*MTLMaterial{})
+
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("expected a *Error, got %v", err)
+			}
+			if e.Line != test.line || !strings.Contains(e.Message, test.message) {
+				t.Errorf("got %q, expected line %d: %s", e.Error(), test.line, test.message)
+			}
+		})
+	}
+}
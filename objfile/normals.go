@@ -0,0 +1,79 @@
+package objfile
+
+import (
+	"gotracer/vmath"
+	"math"
+)
+
+// Crease angle in degrees used when smooth normals are generated for models without normals.
+const DefaultCreaseAngle = 60.0
+
+// Generate smooth vertex normals for the faces of the model that have no normals.
+//
+// The normal of each vertex is the area weighted average of the normals of the faces that share its position.
+// Faces whose normal differs from the normal of the face being shaded by more than the crease angle (in degrees) are ignored, keeping hard edges sharp.
+func (model *Model) GenerateNormals(creaseAngle float64) {
+	var cosCrease = math.Cos(creaseAngle * math.Pi / 180.0)
+
+	// Normal of each face scaled by its area, and the faces that use each position
+	var faceNormals = make([]vmath.Vec3, len(model.Faces))
+	var unitNormals = make([]vmath.Vec3, len(model.Faces))
+	var adjacency = make(map[int][]int)
+
+	for i := 0; i < len(model.Faces); i++ {
+		var face = model.Faces[i]
+		var a = model.Positions[face.Vertices[0].Position]
+		var b = model.Positions[face.Vertices[1].Position]
+		var c = model.Positions[face.Vertices[2].Position]
+
+		faceNormals[i] = b.Sub(a).Cross(c.Sub(a))
+		unitNormals[i] = faceNormals[i].Normalize()
+
+		for j := 0; j < 3; j++ {
+			var position = face.Vertices[j].Position
+			adjacency[position] = append(adjacency[position], i)
+		}
+	}
+
+	// Normals created for each position, vertices with the same smoothed normal share it
+	var created = make(map[int]map[vmath.Vec3]int)
+
+	for i := 0; i < len(model.Faces); i++ {
+		var face = model.Faces[i]
+		if face.Vertices[0].Normal >= 0 && face.Vertices[1].Normal >= 0 && face.Vertices[2].Normal >= 0 {
+			continue
+		}
+
+		for j := 0; j < 3; j++ {
+			var position = face.Vertices[j].Position
+			var normal = vmath.Vec3{}
+
+			var neighbors = adjacency[position]
+			for k := 0; k < len(neighbors); k++ {
+				var n = neighbors[k]
+				if n == i || unitNormals[i].Dot(unitNormals[n]) >= cosCrease {
+					normal = normal.Add(faceNormals[n])
+				}
+			}
+
+			if normal.SquaredLength() == 0.0 {
+				normal = unitNormals[i]
+			} else {
+				normal = normal.Normalize()
+			}
+
+			if created[position] == nil {
+				created[position] = make(map[vmath.Vec3]int)
+			}
+
+			var index, ok = created[position][normal]
+			if !ok {
+				index = len(model.Normals)
+				model.Normals = append(model.Normals, normal)
+				created[position][normal] = index
+			}
+
+			face.Vertices[j].Normal = index
+		}
+	}
+}
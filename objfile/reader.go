@@ -0,0 +1,230 @@
+package objfile
+
+import (
+	"bufio"
+	"fmt"
+	"gotracer/vmath"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Parser keeps the state of the file being read, used to report the location of errors.
+type parser struct {
+	// Name of the file being read.
+	file string
+
+	// Directory used to resolve relative paths of the files referenced.
+	dir string
+
+	// Current line.
+	line int
+}
+
+// Load a OBJ file and the MTL files referenced by it.
+func Load(fname string) (*Model, error) {
+	var file, err = os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Read(file, fname, filepath.Dir(fname))
+}
+
+// Read a OBJ file from a reader, the name is used to report errors.
+// MTL files referenced by the model are loaded from the directory provided.
+func Read(reader io.Reader, name string, dir string) (*Model, error) {
+	var p = &parser{file: name, dir: dir}
+	var model = NewModel()
+	var current = ""
+
+	var scanner = bufio.NewScanner(reader)
+	scanner.Buffer(nil, 1024*1024)
+
+	for scanner.Scan() {
+		p.line++
+
+		var fields = strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		var err error
+
+		switch fields[0] {
+		case "v":
+			var v vmath.Vec3
+			v, err = p.vector(fields[1:], 3, 0.0)
+			model.Positions = append(model.Positions, v)
+		case "vn":
+			var v vmath.Vec3
+			v, err = p.vector(fields[1:], 3, 0.0)
+			model.Normals = append(model.Normals, v)
+		case "vt":
+			var v vmath.Vec3
+			v, err = p.vector(fields[1:], 1, 0.0)
+			model.TexCoords = append(model.TexCoords, v)
+		case "f":
+			err = p.face(model, fields[1:], current)
+		case "usemtl":
+			if len(fields) < 2 {
+				err = p.errorf("usemtl without material name")
+			} else {
+				current = strings.Join(fields[1:], " ")
+
+				// Exported files often name materials that are not in any library, their faces use the default material
+				if _, ok := model.Materials[current]; !ok {
+					model.Warnings = append(model.Warnings, p.errorf("undefined material %q, the default material is used", current))
+					current = ""
+				}
+			}
+		case "mtllib":
+			if len(fields) < 2 {
+				err = p.errorf("mtllib without file name")
+			}
+			for i := 1; i < len(fields) && err == nil; i++ {
+				err = p.library(model, fields[i])
+			}
+		default:
+			// Groups, objects, smoothing groups, lines, points and free-form geometry are not used
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, p.errorf("%s", err.Error())
+	}
+
+	return model, nil
+}
+
+// Read the vertices of a face and add its triangles to the model.
+func (p *parser) face(model *Model, fields []string, material string) error {
+	if len(fields) < 3 {
+		return p.errorf("face with less than three vertices")
+	}
+
+	var vertices = make([]Vertex, len(fields))
+
+	for i := 0; i < len(fields); i++ {
+		var parts = strings.Split(fields[i], "/")
+		if len(parts) > 3 || parts[0] == "" {
+			return p.errorf("invalid face vertex %q", fields[i])
+		}
+
+		var err error
+		vertices[i].Position, err = p.index(parts[0], len(model.Positions), "position")
+		if err != nil {
+			return err
+		}
+
+		vertices[i].TexCoord = -1
+		if len(parts) > 1 && parts[1] != "" {
+			vertices[i].TexCoord, err = p.index(parts[1], len(model.TexCoords), "texture coordinate")
+			if err != nil {
+				return err
+			}
+		}
+
+		vertices[i].Normal = -1
+		if len(parts) > 2 && parts[2] != "" {
+			vertices[i].Normal, err = p.index(parts[2], len(model.Normals), "normal")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var points = make([]vmath.Vec3, len(vertices))
+	for i := 0; i < len(vertices); i++ {
+		points[i] = model.Positions[vertices[i].Position]
+	}
+
+	var triangles = Triangulate(points)
+	for i := 0; i < len(triangles); i++ {
+		var face = new(Face)
+		face.Material = material
+		for j := 0; j < 3; j++ {
+			face.Vertices[j] = vertices[triangles[i][j]]
+		}
+		model.Faces = append(model.Faces, face)
+	}
+
+	return nil
+}
+
+// Parse a attribute index, negative indices are relative to the end of the list.
+// Returns the zero based index.
+func (p *parser) index(value string, count int, name string) (int, error) {
+	var index, err = strconv.Atoi(value)
+	if err != nil {
+		return 0, p.errorf("invalid %s index %q", name, value)
+	}
+
+	if index < 0 {
+		index = count + index
+	} else {
+		index--
+	}
+
+	if index < 0 || index >= count {
+		return 0, p.errorf("%s index %s out of range", name, value)
+	}
+
+	return index, nil
+}
+
+// Parse a vector from a list of values, at least the minimum number of values is required.
+// Components that are not present use the default value.
+func (p *parser) vector(fields []string, minimum int, value float64) (vmath.Vec3, error) {
+	if len(fields) < minimum {
+		return vmath.Vec3{}, p.errorf("expected at least %d values", minimum)
+	}
+
+	var values = []float64{value, value, value}
+	for i := 0; i < len(fields) && i < 3; i++ {
+		var err error
+		values[i], err = p.number(fields[i])
+		if err != nil {
+			return vmath.Vec3{}, err
+		}
+	}
+
+	return vmath.NewVec3(values[0], values[1], values[2]), nil
+}
+
+// Parse a number value.
+func (p *parser) number(value string) (float64, error) {
+	var v, err = strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0.0, p.errorf("invalid number %q", value)
+	}
+	return v, nil
+}
+
+// Load a MTL library file, errors are reported with the location of the mtllib statement if the file cannot be opened.
+func (p *parser) library(model *Model, name string) error {
+	var fname = name
+	if !filepath.IsAbs(fname) {
+		fname = filepath.Join(p.dir, fname)
+	}
+
+	var file, err = os.Open(fname)
+	if err != nil {
+		return p.errorf("%s", err.Error())
+	}
+	defer file.Close()
+
+	return ReadMTL(file, fname, filepath.Dir(fname), model.Materials)
+}
+
+// Create a error for the current line.
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &Error{File: p.file, Line: p.line, Message: fmt.Sprintf(format, args...)}
+}
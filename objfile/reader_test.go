@@ -0,0 +1,159 @@
+package objfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Read a OBJ file from a string, MTL files are loaded from the directory provided.
+func readString(t *testing.T, data string, dir string) *Model {
+	t.Helper()
+
+	var model, err = Read(strings.NewReader(data), "test.obj", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return model
+}
+
+// Square with positions, texture coordinates and a normal.
+const testSquare = `v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+`
+
+func TestReadFaceFormats(t *testing.T) {
+	var tests = []struct {
+		name     string
+		face     string
+		texCoord []int
+		normal   []int
+	}{
+		{"position", "f 1 2 3", []int{-1, -1, -1}, []int{-1, -1, -1}},
+		{"position and texture coordinate", "f 1/1 2/2 3/3", []int{0, 1, 2}, []int{-1, -1, -1}},
+		{"position and normal", "f 1//1 2//1 3//1", []int{-1, -1, -1}, []int{0, 0, 0}},
+		{"all attributes", "f 1/1/1 2/2/1 3/3/1", []int{0, 1, 2}, []int{0, 0, 0}},
+		{"relative indices", "f -4/-4/-1 -3/-3/-1 -2/-2/-1", []int{0, 1, 2}, []int{0, 0, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var model = readString(t, testSquare+test.face+"\n", "")
+			if len(model.Faces) != 1 {
+				t.Fatalf("read %d faces, expected 1", len(model.Faces))
+			}
+
+			var vertices = model.Faces[0].Vertices
+			for i := 0; i < 3; i++ {
+				if vertices[i].Position != i || vertices[i].TexCoord != test.texCoord[i] || vertices[i].Normal != test.normal[i] {
+					t.Errorf("vertex %d is %+v, expected position %d, texture coordinate %d and normal %d", i, vertices[i], i, test.texCoord[i], test.normal[i])
+				}
+			}
+		})
+	}
+}
+
+// Relative indices refer to the attributes declared before the face, not to the end of the file.
+func TestReadRelativeIndices(t *testing.T) {
+	var model = readString(t, "v 0 0 0\nv 1 0 0\nv 0 1 0\nf -3 -2 -1\nv 0 0 1\nf -4 -3 -1\n", "")
+
+	if len(model.Faces) != 2 {
+		t.Fatalf("read %d faces, expected 2", len(model.Faces))
+	}
+	if p := model.Faces[0].Vertices[2].Position; p != 2 {
+		t.Errorf("last vertex of the first face has position %d, expected 2", p)
+	}
+	if p := model.Faces[1].Vertices[2].Position; p != 3 {
+		t.Errorf("last vertex of the second face has position %d, expected 3", p)
+	}
+}
+
+// Polygons with more than three vertices are split into triangles that keep the attributes of their vertices.
+func TestReadPolygon(t *testing.T) {
+	var model = readString(t, testSquare+"v 0.5 1.5 0\nf 1/1 2/2 3/3 5/4 4/4\n", "")
+
+	if len(model.Faces) != 3 {
+		t.Fatalf("pentagon split into %d triangles, expected 3", len(model.Faces))
+	}
+
+	for _, face := range model.Faces {
+		for _, v := range face.Vertices {
+			var expected = v.Position
+			if v.Position == 4 {
+				expected = 3
+			}
+			if v.TexCoord != expected {
+				t.Errorf("vertex with position %d has texture coordinate %d, expected %d", v.Position, v.TexCoord, expected)
+			}
+		}
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	var tests = []struct {
+		name    string
+		data    string
+		line    int
+		message string
+	}{
+		{"index out of range", testSquare + "f 1 2 5\n", 10, "position index 5 out of range"},
+		{"relative index out of range", testSquare + "f -5 1 2\n", 10, "position index -5 out of range"},
+		{"zero index", testSquare + "f 0 1 2\n", 10, "position index 0 out of range"},
+		{"normal index", testSquare + "f 1//2 2//2 3//2\n", 10, "normal index 2 out of range"},
+		{"invalid vertex", testSquare + "f 1/1/1/1 2 3\n", 10, "invalid face vertex"},
+		{"two vertices", testSquare + "f 1 2\n", 10, "face with less than three vertices"},
+		{"invalid number", "v 0 a 0\n", 1, `invalid number "a"`},
+		{"missing library", "mtllib missing.mtl\n", 1, "missing.mtl"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var _, err = Read(strings.NewReader(test.data), "test.obj", t.TempDir())
+
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("expected a *Error, got %v", err)
+			}
+			if e.File != "test.obj" || e.Line != test.line || !strings.Contains(e.Message, test.message) {
+				t.Errorf("got %q, expected line %d: %s", e.Error(), test.line, test.message)
+			}
+		})
+	}
+}
+
+// Faces of materials that are not defined by any library use the default material and a warning is returned.
+func TestReadUndefinedMaterial(t *testing.T) {
+	var dir = t.TempDir()
+	var err = os.WriteFile(filepath.Join(dir, "test.mtl"), []byte("newmtl red\nKd 1 0 0\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var model = readString(t, "mtllib test.mtl\n"+testSquare+"usemtl red\nf 1 2 3\nusemtl missing\nf 1 3 4\n", dir)
+
+	if len(model.Faces) != 2 || model.Faces[0].Material != "red" || model.Faces[1].Material != "" {
+		t.Fatalf("faces use the materials %q and %q, expected \"red\" and the default", model.Faces[0].Material, model.Faces[1].Material)
+	}
+
+	var e *Error
+	if len(model.Warnings) != 1 || !errors.As(model.Warnings[0], &e) || e.Line != 13 || !strings.Contains(e.Message, `undefined material "missing"`) {
+		t.Errorf("warnings are %v, expected the undefined material in line 13", model.Warnings)
+	}
+
+	var mesh, meshErr = model.Mesh(nil)
+	if meshErr != nil {
+		t.Fatal(meshErr)
+	}
+	if len(mesh.Materials) != 2 || mesh.FaceMaterials[0] != 1 || mesh.FaceMaterials[1] != mesh.DefaultMaterial {
+		t.Errorf("mesh has %d materials, face materials %v and default material %d, expected 2, [1 0] and 0", len(mesh.Materials), mesh.FaceMaterials, mesh.DefaultMaterial)
+	}
+}
@@ -0,0 +1,114 @@
+package objfile
+
+import (
+	"gotracer/vmath"
+	"math"
+)
+
+// Split a polygon into triangles, returns the indices of the points of each triangle.
+//
+// Polygons are triangulated by ear clipping in the plane of the polygon so concave polygons are also supported.
+// If the polygon is degenerated or self intersecting it is split into a fan of triangles.
+func Triangulate(points []vmath.Vec3) [][3]int {
+	if len(points) == 3 {
+		return [][3]int{{0, 1, 2}}
+	}
+
+	// Newell normal of the polygon
+	var normal = vmath.Vec3{}
+	for i := 0; i < len(points); i++ {
+		var a = points[i]
+		var b = points[(i+1)%len(points)]
+		normal.X += (a.Y - b.Y) * (a.Z + b.Z)
+		normal.Y += (a.Z - b.Z) * (a.X + b.X)
+		normal.Z += (a.X - b.X) * (a.Y + b.Y)
+	}
+
+	if normal.SquaredLength() == 0.0 {
+		return fan(len(points))
+	}
+
+	// Project the points into the plane by dropping the axis where the normal is larger
+	var x, y = 0, 1
+	var ax, ay, az = math.Abs(normal.X), math.Abs(normal.Y), math.Abs(normal.Z)
+	if ax >= ay && ax >= az {
+		x, y = 1, 2
+		if normal.X < 0 {
+			x, y = y, x
+		}
+	} else if ay >= az {
+		x, y = 2, 0
+		if normal.Y < 0 {
+			x, y = y, x
+		}
+	} else if normal.Z < 0 {
+		x, y = y, x
+	}
+
+	var projected = make([][2]float64, len(points))
+	for i := 0; i < len(points); i++ {
+		projected[i] = [2]float64{points[i].Axis(x), points[i].Axis(y)}
+	}
+
+	// The projected polygon is counter clockwise, ears are convex vertices without other vertices inside
+	var remaining = make([]int, len(points))
+	for i := 0; i < len(points); i++ {
+		remaining[i] = i
+	}
+
+	var triangles [][3]int
+
+	for len(remaining) > 3 {
+		var found = false
+
+		for i := 0; i < len(remaining); i++ {
+			var a = remaining[(i+len(remaining)-1)%len(remaining)]
+			var b = remaining[i]
+			var c = remaining[(i+1)%len(remaining)]
+
+			if cross2(projected[a], projected[b], projected[c]) <= 0.0 {
+				continue
+			}
+
+			var ear = true
+			for j := 0; j < len(remaining) && ear; j++ {
+				var p = remaining[j]
+				if p != a && p != b && p != c && insideTriangle(projected[p], projected[a], projected[b], projected[c]) {
+					ear = false
+				}
+			}
+
+			if ear {
+				triangles = append(triangles, [3]int{a, b, c})
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fan(len(points))
+		}
+	}
+
+	return append(triangles, [3]int{remaining[0], remaining[1], remaining[2]})
+}
+
+// Split a polygon into a fan of triangles around the first vertex.
+func fan(count int) [][3]int {
+	var triangles = make([][3]int, 0, count-2)
+	for i := 1; i+1 < count; i++ {
+		triangles = append(triangles, [3]int{0, i, i + 1})
+	}
+	return triangles
+}
+
+// Z component of the cross product between the edges ab and bc.
+func cross2(a [2]float64, b [2]float64, c [2]float64) float64 {
+	return (b[0]-a[0])*(c[1]-b[1]) - (b[1]-a[1])*(c[0]-b[0])
+}
+
+// Check if the point is inside or on the edges of the counter clockwise triangle abc.
+func insideTriangle(p [2]float64, a [2]float64, b [2]float64, c [2]float64) bool {
+	return cross2(a, b, p) >= 0.0 && cross2(b, c, p) >= 0.0 && cross2(c, a, p) >= 0.0
+}
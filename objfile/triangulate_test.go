@@ -0,0 +1,69 @@
+package objfile
+
+import (
+	"gotracer/vmath"
+	"math"
+	"testing"
+)
+
+// Sum of the areas of the triangles of a polygon.
+func triangulatedArea(points []vmath.Vec3, triangles [][3]int) float64 {
+	var area = 0.0
+	for _, t := range triangles {
+		area += points[t[1]].Sub(points[t[0]]).Cross(points[t[2]].Sub(points[t[0]])).Length() / 2.0
+	}
+	return area
+}
+
+func TestTriangulate(t *testing.T) {
+	var tests = []struct {
+		name   string
+		points []vmath.Vec3
+		area   float64
+	}{
+		{"triangle", []vmath.Vec3{vmath.NewVec3(0, 0, 0), vmath.NewVec3(1, 0, 0), vmath.NewVec3(0, 1, 0)}, 0.5},
+		{"square", []vmath.Vec3{vmath.NewVec3(0, 0, 0), vmath.NewVec3(1, 0, 0), vmath.NewVec3(1, 1, 0), vmath.NewVec3(0, 1, 0)}, 1.0},
+		{"clockwise square in the YZ plane", []vmath.Vec3{vmath.NewVec3(0, 0, 0), vmath.NewVec3(0, 1, 0), vmath.NewVec3(0, 1, 1), vmath.NewVec3(0, 0, 1)}, 1.0},
+		{"concave", []vmath.Vec3{vmath.NewVec3(0, 0, 0), vmath.NewVec3(2, 0, 0), vmath.NewVec3(2, 2, 0), vmath.NewVec3(1, 0.5, 0), vmath.NewVec3(0, 2, 0)}, 2.5},
+	}
+
+	// Regular hexagon with unit radius
+	var hexagon []vmath.Vec3
+	for i := 0; i < 6; i++ {
+		var angle = float64(i) * math.Pi / 3.0
+		hexagon = append(hexagon, vmath.NewVec3(math.Cos(angle), 0, math.Sin(angle)))
+	}
+	tests = append(tests, struct {
+		name   string
+		points []vmath.Vec3
+		area   float64
+	}{"hexagon", hexagon, 3.0 * math.Sqrt(3.0) / 2.0})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var triangles = Triangulate(test.points)
+			if len(triangles) != len(test.points)-2 {
+				t.Fatalf("split into %d triangles, expected %d", len(triangles), len(test.points)-2)
+			}
+			if area := triangulatedArea(test.points, triangles); math.Abs(area-test.area) > 1e-9 {
+				t.Errorf("triangles cover a area of %f, expected %f", area, test.area)
+			}
+		})
+	}
+}
+
+// Degenerated polygons are split into a fan of triangles around the first vertex.
+func TestTriangulateFan(t *testing.T) {
+	var points = []vmath.Vec3{vmath.NewVec3(0, 0, 0), vmath.NewVec3(1, 0, 0), vmath.NewVec3(2, 0, 0), vmath.NewVec3(3, 0, 0), vmath.NewVec3(4, 0, 0)}
+	var expected = [][3]int{{0, 1, 2}, {0, 2, 3}, {0, 3, 4}}
+
+	var triangles = Triangulate(points)
+	if len(triangles) != len(expected) {
+		t.Fatalf("split into %d triangles, expected %d", len(triangles), len(expected))
+	}
+	for i := range expected {
+		if triangles[i] != expected[i] {
+			t.Errorf("triangle %d is %v, expected %v", i, triangles[i], expected[i])
+		}
+	}
+}
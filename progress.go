@@ -0,0 +1,104 @@
+package main
+
+import (
+	"gotracer/framebuffer"
+	"sync"
+	"time"
+)
+
+// Progress of a render, reported each time a tile or a pass is finished.
+type Progress struct {
+	// Tiles finished and total number of tiles of the render, counting the tiles of every pass.
+	Tiles      int
+	TotalTiles int
+
+	// Passes finished and total number of passes of the render, each pass adds samples to every pixel.
+	Passes      int
+	TotalPasses int
+
+	// Samples per pixel accumulated in the buffer, includes the samples added before the render started.
+	Samples int
+
+	// Time since the render started and estimated time to finish it.
+	Elapsed   time.Duration
+	Remaining time.Duration
+}
+
+// Fraction of the render finished, in the range [0, 1].
+func (p Progress) Fraction() float64 {
+	if p.TotalTiles == 0 {
+		return 1.0
+	}
+	return float64(p.Tiles) / float64(p.TotalTiles)
+}
+
+// Callback that receives the progress of a render.
+// Calls are not concurrent, but they are made from the render threads and should return quickly.
+type ProgressFunc func(progress Progress)
+
+// Progress tracker counts the tiles finished by the render threads and reports the progress to the callback.
+type ProgressTracker struct {
+	mutex    sync.Mutex
+	callback ProgressFunc
+	start    time.Time
+	progress Progress
+
+	// Samples added to each pixel by a pass.
+	samplesPerPass int
+}
+
+// Create a new progress tracker for a render with a number of passes of tiles, the callback can be nil.
+func NewProgressTracker(callback ProgressFunc, tiles int, passes int, settings *RenderSettings) *ProgressTracker {
+	var t = new(ProgressTracker)
+	t.callback = callback
+	t.start = time.Now()
+	t.progress.TotalTiles = tiles * passes
+	t.progress.TotalPasses = passes
+
+	t.samplesPerPass = 1
+	if settings.Antialiasing {
+		t.samplesPerPass = settings.AntialiasingSamples
+	}
+
+	return t
+}
+
+// Count a tile as finished and report the progress.
+// Safe to call from multiple goroutines.
+func (t *ProgressTracker) TileDone(buffer *framebuffer.Framebuffer) {
+	if t.callback == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.progress.Tiles++
+	t.update(buffer)
+	t.callback(t.progress)
+}
+
+// Count a pass as finished and report the progress, called after all the tiles of the pass are finished.
+func (t *ProgressTracker) PassDone(buffer *framebuffer.Framebuffer) {
+	if t.callback == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.progress.Passes++
+	t.update(buffer)
+	t.callback(t.progress)
+}
+
+// Update the samples and the time estimates, the remaining time assumes all tiles take the same time on average.
+func (t *ProgressTracker) update(buffer *framebuffer.Framebuffer) {
+	t.progress.Samples = buffer.Passes * t.samplesPerPass
+	t.progress.Elapsed = time.Since(t.start)
+
+	if t.progress.Tiles > 0 {
+		var perTile = t.progress.Elapsed / time.Duration(t.progress.Tiles)
+		t.progress.Remaining = perTile * time.Duration(t.progress.TotalTiles-t.progress.Tiles)
+	}
+}
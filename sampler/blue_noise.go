@@ -0,0 +1,180 @@
+package sampler
+
+import (
+	"math"
+	"sync"
+)
+
+// Size in pixels of the blue noise texture, the texture is tiled over the image.
+const BlueNoiseSize = 64
+
+// Blue noise texture, generated when first used.
+var blueNoiseTexture []float64
+var blueNoiseOnce sync.Once
+
+// BlueNoiseSampler uses the same Owen scrambled Sobol sequence in every pixel, shifted by the values of a blue noise texture.
+//
+// Neighbor pixels get very different offsets, the error is distributed as blue noise (high frequency only) instead of white noise,
+// which is perceived as less noisy and is easier to remove by filters. Each dimension uses a different toroidal shift of the texture.
+type BlueNoiseSampler struct {
+	Seed int64
+
+	x         int
+	y         int
+	index     int
+	dimension int
+}
+
+// Create a new blue noise sampler.
+func NewBlueNoiseSampler(seed int64) *BlueNoiseSampler {
+	var s = new(BlueNoiseSampler)
+	s.Seed = seed
+	blueNoiseOnce.Do(generateBlueNoise)
+	return s
+}
+
+func (s *BlueNoiseSampler) StartPixelSample(x int, y int, index int) {
+	s.x = x
+	s.y = y
+	s.index = index
+	s.dimension = 0
+}
+
+func (s *BlueNoiseSampler) Get1D() float64 {
+	var seed = Hash(uint64(s.Seed), uint64(s.dimension))
+	s.dimension++
+
+	var x, _ = scrambledSobol(uint32(s.index), seed)
+	return rotate(unit(x), s.noise(seed))
+}
+
+func (s *BlueNoiseSampler) Get2D() (float64, float64) {
+	var seed = Hash(uint64(s.Seed), uint64(s.dimension))
+	s.dimension += 2
+
+	var x, y = scrambledSobol(uint32(s.index), seed)
+	return rotate(unit(x), s.noise(seed)), rotate(unit(y), s.noise(Hash(seed)))
+}
+
+func (s *BlueNoiseSampler) Clone() Sampler {
+	return NewBlueNoiseSampler(s.Seed)
+}
+
+// Value of the blue noise texture in the pixel, the texture is shifted by the seed.
+func (s *BlueNoiseSampler) noise(seed uint64) float64 {
+	var x = (s.x + int(seed%BlueNoiseSize)) % BlueNoiseSize
+	var y = (s.y + int((seed>>16)%BlueNoiseSize)) % BlueNoiseSize
+	return blueNoiseTexture[y*BlueNoiseSize+x]
+}
+
+// Generate the blue noise texture with the void and cluster method of Ulichney.
+//
+// Each pixel of the texture gets a rank, pixels with close ranks are far apart from each other.
+// The texture values are the ranks normalized to the range [0, 1).
+func generateBlueNoise() {
+	const size = BlueNoiseSize
+	const count = size * size
+	const sigma = 1.5
+
+	// Gaussian energy of a point at each offset, with wrap around
+	var kernel = make([]float64, count)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			var dx = float64(min(x, size-x))
+			var dy = float64(min(y, size-y))
+			kernel[y*size+x] = math.Exp(-(dx*dx + dy*dy) / (2.0 * sigma * sigma))
+		}
+	}
+
+	var pattern = make([]bool, count)
+	var energy = make([]float64, count)
+
+	var set = func(p int, value bool) {
+		pattern[p] = value
+
+		var sign = 1.0
+		if !value {
+			sign = -1.0
+		}
+
+		var px, py = p % size, p / size
+		for y := 0; y < size; y++ {
+			var row = ((y - py + size) % size) * size
+			for x := 0; x < size; x++ {
+				energy[y*size+x] += sign * kernel[row+(x-px+size)%size]
+			}
+		}
+	}
+
+	// Point with the highest energy, the center of the tightest cluster
+	var cluster = func() int {
+		var best = -1
+		for i := 0; i < count; i++ {
+			if pattern[i] && (best < 0 || energy[i] > energy[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+
+	// Empty pixel with the lowest energy, the center of the largest void
+	var void = func() int {
+		var best = -1
+		for i := 0; i < count; i++ {
+			if !pattern[i] && (best < 0 || energy[i] < energy[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+
+	// Initial random pattern with a tenth of the pixels set
+	var random = NewRandom(0x5eed)
+	var initial = count / 10
+	for n := 0; n < initial; {
+		var p = random.Intn(count)
+		if !pattern[p] {
+			set(p, true)
+			n++
+		}
+	}
+
+	// Move points from the tightest clusters to the largest voids until the pattern is evenly distributed
+	for i := 0; i < count; i++ {
+		var c = cluster()
+		set(c, false)
+
+		var v = void()
+		set(v, true)
+
+		if v == c {
+			break
+		}
+	}
+
+	var prototype = append([]bool(nil), pattern...)
+	var prototypeEnergy = append([]float64(nil), energy...)
+	var ranks = make([]int, count)
+
+	// Rank the points of the initial pattern removing the tightest clusters first
+	for rank := initial - 1; rank >= 0; rank-- {
+		var c = cluster()
+		set(c, false)
+		ranks[c] = rank
+	}
+
+	// Rank the remaining pixels filling the largest voids first
+	copy(pattern, prototype)
+	copy(energy, prototypeEnergy)
+
+	for rank := initial; rank < count; rank++ {
+		var v = void()
+		set(v, true)
+		ranks[v] = rank
+	}
+
+	blueNoiseTexture = make([]float64, count)
+	for i := 0; i < count; i++ {
+		blueNoiseTexture[i] = (float64(ranks[i]) + 0.5) / count
+	}
+}
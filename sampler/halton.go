@@ -0,0 +1,152 @@
+package sampler
+
+// Number of dimensions generated by the Halton sequence.
+// The sequences of large prime bases are correlated with each other, the dimensions after these use random values.
+const HaltonDimensions = 64
+
+// Prime bases of each dimension of the Halton sequence.
+var haltonPrimes = primes(HaltonDimensions)
+
+// HaltonSampler uses the Halton low discrepancy sequence, each dimension is the radical inverse of the sample index in a different prime base.
+//
+// The digits of each dimension are scrambled by random permutations, this avoids the clustering of the first samples of the large bases.
+// All pixels use the same sequence, each pixel and dimension is shifted by a random offset (Cranley-Patterson rotation) to decorrelate them.
+type HaltonSampler struct {
+	Seed int64
+
+	pixel       uint64
+	index       int
+	dimension   int
+	random      *Random
+	permutation []haltonPermutation
+}
+
+// Random permutations of the digits of a dimension of the Halton sequence.
+type haltonPermutation struct {
+	base uint64
+
+	// Permutation of the digit values for each digit position.
+	digits [][]uint16
+
+	// Value of the scrambled digits from each position to the last one when all of them are zero.
+	tail []float64
+}
+
+// Create a new Halton sampler.
+func NewHaltonSampler(seed int64) *HaltonSampler {
+	var s = new(HaltonSampler)
+	s.Seed = seed
+	s.random = NewRandom(uint64(seed))
+	s.permutation = haltonPermutations(seed)
+	return s
+}
+
+func (s *HaltonSampler) StartPixelSample(x int, y int, index int) {
+	s.pixel = Hash(uint64(s.Seed), uint64(x), uint64(y))
+	s.index = index
+	s.dimension = 0
+	s.random.Seed(Hash(s.pixel, uint64(index)), 1)
+}
+
+func (s *HaltonSampler) Get1D() float64 {
+	var d = s.dimension
+	s.dimension++
+
+	if d >= HaltonDimensions {
+		return s.random.Float64()
+	}
+
+	var offset = float64(Hash(s.pixel, uint64(d))>>11) * 0x1p-53
+	return rotate(s.permutation[d].radicalInverse(uint64(s.index)), offset)
+}
+
+func (s *HaltonSampler) Get2D() (float64, float64) {
+	return s.Get1D(), s.Get1D()
+}
+
+// Clone the sampler, the permutations are shared since they are not modified.
+func (s *HaltonSampler) Clone() Sampler {
+	var c = new(HaltonSampler)
+	c.Seed = s.Seed
+	c.random = NewRandom(uint64(s.Seed))
+	c.permutation = s.permutation
+	return c
+}
+
+// Mirror the digits of a value written in the base around the decimal point, each digit is replaced by its permutation.
+func (p *haltonPermutation) radicalInverse(i uint64) float64 {
+	var inverse = 1.0 / float64(p.base)
+	var reversed uint64 = 0
+	var scale = 1.0
+	var k = 0
+
+	for ; i > 0 && k < len(p.digits); k++ {
+		var next = i / p.base
+		reversed = reversed*p.base + uint64(p.digits[k][i-next*p.base])
+		scale *= inverse
+		i = next
+	}
+
+	return min(float64(reversed)*scale+p.tail[k], oneMinusEpsilon)
+}
+
+// Create the random digit permutations of each dimension, for the digits that fit in a 32 bit value.
+func haltonPermutations(seed int64) []haltonPermutation {
+	var list = make([]haltonPermutation, HaltonDimensions)
+	var random = NewRandom(uint64(seed))
+
+	for d := 0; d < HaltonDimensions; d++ {
+		var p = &list[d]
+		p.base = haltonPrimes[d]
+
+		for v := p.base; v <= 1<<32; v *= p.base {
+			var digits = make([]uint16, p.base)
+			for i := 0; i < len(digits); i++ {
+				digits[i] = uint16(i)
+			}
+
+			// Fisher-Yates shuffle
+			for i := len(digits) - 1; i > 0; i-- {
+				var j = random.Intn(i + 1)
+				digits[i], digits[j] = digits[j], digits[i]
+			}
+
+			p.digits = append(p.digits, digits)
+		}
+
+		p.tail = make([]float64, len(p.digits)+1)
+
+		var scale = 1.0
+		for k := 0; k < len(p.digits); k++ {
+			scale /= float64(p.base)
+		}
+
+		for k := len(p.digits) - 1; k >= 0; k-- {
+			p.tail[k] = p.tail[k+1] + float64(p.digits[k][0])*scale
+			scale *= float64(p.base)
+		}
+	}
+
+	return list
+}
+
+// Get the first n prime numbers.
+func primes(n int) []uint64 {
+	var list = make([]uint64, 0, n)
+
+	for v := uint64(2); len(list) < n; v++ {
+		var prime = true
+		for i := 0; i < len(list) && list[i]*list[i] <= v; i++ {
+			if v%list[i] == 0 {
+				prime = false
+				break
+			}
+		}
+
+		if prime {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
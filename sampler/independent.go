@@ -0,0 +1,34 @@
+package sampler
+
+// IndependentSampler generates uniform random values for every dimension.
+//
+// The values are not correlated between samples, it converges slower than the other samplers but it has no structured artifacts.
+type IndependentSampler struct {
+	Seed int64
+
+	random *Random
+}
+
+// Create a new independent sampler.
+func NewIndependentSampler(seed int64) *IndependentSampler {
+	var s = new(IndependentSampler)
+	s.Seed = seed
+	s.random = NewRandom(uint64(seed))
+	return s
+}
+
+func (s *IndependentSampler) StartPixelSample(x int, y int, index int) {
+	s.random.SeedPixel(s.Seed, x, y, index)
+}
+
+func (s *IndependentSampler) Get1D() float64 {
+	return s.random.Float64()
+}
+
+func (s *IndependentSampler) Get2D() (float64, float64) {
+	return s.random.Float64(), s.random.Float64()
+}
+
+func (s *IndependentSampler) Clone() Sampler {
+	return NewIndependentSampler(s.Seed)
+}
@@ -0,0 +1,79 @@
+package sampler
+
+import "math/bits"
+
+// Multiplier of the PCG linear congruential step.
+const pcgMultiplier = 6364136223846793005
+
+// Random is a small and fast pseudo random number generator (PCG32).
+//
+// Each thread should use its own generator, unlike the global math/rand functions it is not protected by a mutex.
+// The generator is seeded from the pixel and sample being rendered so the result does not depend on the order of the work.
+type Random struct {
+	state     uint64
+	increment uint64
+}
+
+// Create a new random generator from a seed.
+func NewRandom(seed uint64) *Random {
+	var r = new(Random)
+	r.Seed(seed, 0)
+	return r
+}
+
+// Restart the generator with a seed, different streams produce different sequences for the same seed.
+func (r *Random) Seed(seed uint64, stream uint64) {
+	r.state = 0
+	r.increment = stream<<1 | 1
+	r.Uint32()
+	r.state += seed
+	r.Uint32()
+}
+
+// Restart the generator for a sample of a pixel.
+// The seed of the render is combined with the pixel coordinates and the index of the sample, each sample gets a different sequence.
+func (r *Random) SeedPixel(seed int64, x int, y int, sample int) {
+	r.Seed(Hash(uint64(seed), uint64(x), uint64(y), uint64(sample)), 0)
+}
+
+// Generate a uniformly distributed 32 bit value.
+func (r *Random) Uint32() uint32 {
+	var old = r.state
+	r.state = old*pcgMultiplier + r.increment
+
+	var xorshifted = uint32(((old >> 18) ^ old) >> 27)
+	var rotation = int(old >> 59)
+	return bits.RotateLeft32(xorshifted, -rotation)
+}
+
+// Generate a uniformly distributed 64 bit value.
+func (r *Random) Uint64() uint64 {
+	return uint64(r.Uint32())<<32 | uint64(r.Uint32())
+}
+
+// Generate a uniformly distributed value in the range [0, 1).
+func (r *Random) Float64() float64 {
+	// 53 random bits, the precision of the mantissa
+	return float64(r.Uint64()>>11) * 0x1p-53
+}
+
+// Generate a uniformly distributed integer in the range [0, n).
+func (r *Random) Intn(n int) int {
+	return int((uint64(r.Uint32()) * uint64(n)) >> 32)
+}
+
+// Hash a list of values into a well distributed 64 bit value.
+// Uses the SplitMix64 finalizer to mix the bits of each value.
+func Hash(values ...uint64) uint64 {
+	var h uint64 = 0x9e3779b97f4a7c15
+
+	for i := 0; i < len(values); i++ {
+		h ^= values[i]
+		h += 0x9e3779b97f4a7c15
+		h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+		h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+		h ^= h >> 31
+	}
+
+	return h
+}
@@ -0,0 +1,62 @@
+package sampler
+
+import "fmt"
+
+// Sampler generates the values used to take the samples of a pixel.
+//
+// Each sample of a pixel is a point in a space with many dimensions, the values are consumed in a fixed order:
+// the pixel position, the lens position and then the light and material samples of each bounce.
+// Every call to Get1D or Get2D uses a new dimension, the dimensions of a sample are decorrelated from each other.
+//
+// Samplers keep the state of the current sample and must not be shared between threads, use Clone to get a sampler for each thread.
+type Sampler interface {
+	// Start a new sample of a pixel, the index is the number of samples already taken for the pixel.
+	// The same pixel and index always produce the same values.
+	StartPixelSample(x int, y int, index int)
+
+	// Get the value of the next dimension of the sample, in the range [0, 1).
+	Get1D() float64
+
+	// Get the values of the next two dimensions of the sample, in the range [0, 1).
+	Get2D() (float64, float64)
+
+	// Clone the sampler, the copy has the same configuration and its own state.
+	Clone() Sampler
+}
+
+// Names of the available samplers.
+var Names = []string{"independent", "stratified", "halton", "sobol", "blue-noise"}
+
+// Create a sampler from its name.
+// The samples per pixel are the number of samples expected for each pixel, used by the stratified sampler to divide the sample space.
+// The seed changes the values generated, the same seed always produces the same values.
+func New(name string, samplesPerPixel int, seed int64) (Sampler, error) {
+	switch name {
+	case "independent", "random":
+		return NewIndependentSampler(seed), nil
+	case "stratified":
+		return NewStratifiedSampler(samplesPerPixel, seed), nil
+	case "halton":
+		return NewHaltonSampler(seed), nil
+	case "sobol", "":
+		return NewSobolSampler(seed), nil
+	case "blue-noise", "bluenoise":
+		return NewBlueNoiseSampler(seed), nil
+	}
+
+	return nil, fmt.Errorf("sampler: unknown sampler %q", name)
+}
+
+// Convert a 32 bit value into a value in the range [0, 1).
+func unit(v uint32) float64 {
+	return float64(v) * 0x1p-32
+}
+
+// Add a offset to a value in the range [0, 1) wrapping around, known as a Cranley-Patterson rotation.
+func rotate(v float64, offset float64) float64 {
+	v += offset
+	if v >= 1.0 {
+		v -= 1.0
+	}
+	return v
+}
@@ -0,0 +1,116 @@
+package sampler
+
+import (
+	"math"
+	"testing"
+)
+
+// Number of samples per pixel and dimensions of each sample used by the tests.
+const testSamples, testDimensions = 64, 24
+
+// Get the values of the first dimensions of a sample of a pixel.
+func sampleValues(s Sampler, x int, y int, index int) []float64 {
+	var values = make([]float64, 0, testDimensions)
+
+	s.StartPixelSample(x, y, index)
+	for len(values) < testDimensions {
+		if len(values)%3 == 0 {
+			var u, v = s.Get2D()
+			values = append(values, u, v)
+		} else {
+			values = append(values, s.Get1D())
+		}
+	}
+
+	return values
+}
+
+func newTestSampler(t *testing.T, name string, seed int64) Sampler {
+	t.Helper()
+
+	var s, err = New(name, testSamples, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSamplerRange(t *testing.T) {
+	for _, name := range Names {
+		t.Run(name, func(t *testing.T) {
+			var s = newTestSampler(t, name, 7)
+
+			for p := 0; p < 16; p++ {
+				for i := 0; i < testSamples; i++ {
+					for d, value := range sampleValues(s, p*37, p*11, i) {
+						if value < 0.0 || value >= 1.0 || math.IsNaN(value) {
+							t.Fatalf("pixel %d sample %d dimension %d: value %f out of the range [0, 1)", p, i, d, value)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSamplerDeterminism(t *testing.T) {
+	for _, name := range Names {
+		t.Run(name, func(t *testing.T) {
+			var s = newTestSampler(t, name, 7)
+			var expected = sampleValues(s, 5, 9, 3)
+
+			// Other samples taken before do not change the values
+			sampleValues(s, 6, 9, 0)
+			var clone = s.Clone()
+			sampleValues(clone, 100, 2, 12)
+
+			var tests = map[string]Sampler{
+				"same sampler": s,
+				"clone":        clone,
+				"new sampler":  newTestSampler(t, name, 7),
+			}
+
+			for test, sampler := range tests {
+				var values = sampleValues(sampler, 5, 9, 3)
+				for d := range values {
+					if values[d] != expected[d] {
+						t.Fatalf("%s: dimension %d is %f, expected %f", test, d, values[d], expected[d])
+					}
+				}
+			}
+
+			var other = sampleValues(newTestSampler(t, name, 8), 5, 9, 3)
+			var same = 0
+			for d := range other {
+				if other[d] == expected[d] {
+					same++
+				}
+			}
+			if same == len(other) {
+				t.Errorf("a different seed produced the same values")
+			}
+		})
+	}
+}
+
+// The values of each dimension over the samples of a pixel are uniformly distributed.
+func TestSamplerUniform(t *testing.T) {
+	for _, name := range Names {
+		t.Run(name, func(t *testing.T) {
+			var s = newTestSampler(t, name, 7)
+			var sums = make([]float64, testDimensions)
+
+			for i := 0; i < testSamples; i++ {
+				for d, value := range sampleValues(s, 3, 4, i) {
+					sums[d] += value
+				}
+			}
+
+			for d := range sums {
+				if mean := sums[d] / testSamples; math.Abs(mean-0.5) > 0.12 {
+					t.Errorf("dimension %d has mean %f", d, mean)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package sampler
+
+import "math/bits"
+
+// Direction numbers of the first two dimensions of the Sobol sequence, one for each bit of the index.
+var sobolDirections = sobolMatrices()
+
+// Values of the first two Sobol dimensions for each byte of the index.
+// The sequence is linear (xor) in the bits of the index, a point is the xor of the values of its four bytes.
+var sobolTables = sobolByteTables()
+
+// SobolSampler uses the first two dimensions of the Sobol sequence with Owen scrambling.
+//
+// Each dimension pair uses a different shuffle of the sample index and a different scramble of the values,
+// as proposed by Burley in "Practical Hash-based Owen Scrambling", so any number of dimensions can be generated without correlation.
+// The pixels are scrambled independently, the error is white noise with low variance.
+type SobolSampler struct {
+	Seed int64
+
+	pixel     uint64
+	index     int
+	dimension int
+}
+
+// Create a new Sobol sampler.
+func NewSobolSampler(seed int64) *SobolSampler {
+	var s = new(SobolSampler)
+	s.Seed = seed
+	return s
+}
+
+func (s *SobolSampler) StartPixelSample(x int, y int, index int) {
+	s.pixel = Hash(uint64(s.Seed), uint64(x), uint64(y))
+	s.index = index
+	s.dimension = 0
+}
+
+func (s *SobolSampler) Get1D() float64 {
+	var seed = Hash(s.pixel, uint64(s.dimension))
+	s.dimension++
+
+	var x, _ = scrambledSobol(uint32(s.index), seed)
+	return unit(x)
+}
+
+func (s *SobolSampler) Get2D() (float64, float64) {
+	var seed = Hash(s.pixel, uint64(s.dimension))
+	s.dimension += 2
+
+	var x, y = scrambledSobol(uint32(s.index), seed)
+	return unit(x), unit(y)
+}
+
+func (s *SobolSampler) Clone() Sampler {
+	return NewSobolSampler(s.Seed)
+}
+
+// Get a point of the first two Sobol dimensions, the index is shuffled and the values are Owen scrambled using the seed.
+func scrambledSobol(index uint32, seed uint64) (uint32, uint32) {
+	var shuffled = nestedUniformScramble(index, uint32(seed))
+
+	var x = nestedUniformScramble(sobol(shuffled, 0), uint32(seed>>32))
+	var y = nestedUniformScramble(sobol(shuffled, 1), uint32(Hash(seed)))
+	return x, y
+}
+
+// Get a dimension of a point of the Sobol sequence as a 32 bit fixed point value.
+func sobol(index uint32, dimension int) uint32 {
+	var t = &sobolTables[dimension]
+	return t[0][uint8(index)] ^ t[1][uint8(index>>8)] ^ t[2][uint8(index>>16)] ^ t[3][uint8(index>>24)]
+}
+
+// Calculate the direction numbers of the first two Sobol dimensions.
+// The first dimension is the van der Corput sequence, the second uses the primitive polynomial x + 1.
+func sobolMatrices() [2][32]uint32 {
+	var m [2][32]uint32
+
+	for i := 0; i < 32; i++ {
+		m[0][i] = 1 << (31 - i)
+	}
+
+	m[1][0] = 1 << 31
+	for i := 1; i < 32; i++ {
+		m[1][i] = m[1][i-1] ^ (m[1][i-1] >> 1)
+	}
+
+	return m
+}
+
+// Calculate the tables of the Sobol values of each byte of the index.
+func sobolByteTables() [2][4][256]uint32 {
+	var t [2][4][256]uint32
+
+	for d := 0; d < 2; d++ {
+		for b := 0; b < 4; b++ {
+			for v := 0; v < 256; v++ {
+				for bit := 0; bit < 8; bit++ {
+					if v&(1<<bit) != 0 {
+						t[d][b][v] ^= sobolDirections[d][b*8+bit]
+					}
+				}
+			}
+		}
+	}
+
+	return t
+}
+
+// Owen scramble a 32 bit fixed point value, each bit is flipped based on a hash of the bits above it.
+func nestedUniformScramble(x uint32, seed uint32) uint32 {
+	x = bits.Reverse32(x)
+	x = laineKarrasPermutation(x, seed)
+	return bits.Reverse32(x)
+}
+
+// Hash where each bit only depends on the bits below it, with the improved constants of Burley.
+func laineKarrasPermutation(x uint32, seed uint32) uint32 {
+	x ^= x * 0x3d20adea
+	x += seed
+	x *= (seed >> 16) | 1
+	x ^= x * 0x05526c56
+	x ^= x * 0x53a22864
+	return x
+}
@@ -0,0 +1,127 @@
+package sampler
+
+import "math"
+
+// StratifiedSampler divides each dimension in strata and places one sample in each stratum.
+//
+// Uses the correlated multi-jittered sampling of Kensler, the 2D samples are stratified in a grid and in each axis.
+// The strata are visited in a different random order for each pixel and dimension, so the dimensions are not correlated.
+// After all the samples per pixel were taken the sampler starts a new round with a different order.
+type StratifiedSampler struct {
+	// Number of strata in each dimension, the expected number of samples per pixel.
+	SamplesPerPixel int
+
+	Seed int64
+
+	pixel     uint64
+	index     int
+	dimension int
+}
+
+// Create a new stratified sampler.
+func NewStratifiedSampler(samplesPerPixel int, seed int64) *StratifiedSampler {
+	var s = new(StratifiedSampler)
+	s.SamplesPerPixel = max(samplesPerPixel, 1)
+	s.Seed = seed
+	return s
+}
+
+func (s *StratifiedSampler) StartPixelSample(x int, y int, index int) {
+	s.pixel = Hash(uint64(s.Seed), uint64(x), uint64(y))
+	s.index = index
+	s.dimension = 0
+}
+
+// Get the index of the sample in the current round and the pattern seed of the next dimension.
+func (s *StratifiedSampler) next() (uint32, uint32, uint32) {
+	var n = uint32(s.SamplesPerPixel)
+	var round = s.index / s.SamplesPerPixel
+	var pattern = uint32(Hash(s.pixel, uint64(s.dimension), uint64(round)))
+	s.dimension++
+	return uint32(s.index) % n, n, pattern
+}
+
+func (s *StratifiedSampler) Get1D() float64 {
+	var i, n, p = s.next()
+	var stratum = permute(i, n, p*0x68bc21eb)
+	var jitter = randomFloat(i, p*0x967a889b)
+	return (float64(stratum) + jitter) / float64(n)
+}
+
+func (s *StratifiedSampler) Get2D() (float64, float64) {
+	var i, n, p = s.next()
+
+	// Grid of m x k cells with at least n cells
+	var m = uint32(math.Sqrt(float64(n)))
+	var k = (n + m - 1) / m
+
+	i = permute(i, n, p*0x51633e2d)
+	var sx = permute(i%m, m, p*0x68bc21eb)
+	var sy = permute(i/m, k, p*0x02e5be93)
+	var jx = randomFloat(i, p*0x967a889b)
+	var jy = randomFloat(i, p*0x368cc8b7)
+
+	var x = (float64(sx) + (float64(sy)+jx)/float64(k)) / float64(m)
+	var y = (float64(i) + jy) / float64(n)
+	return min(x, oneMinusEpsilon), min(y, oneMinusEpsilon)
+}
+
+func (s *StratifiedSampler) Clone() Sampler {
+	return NewStratifiedSampler(s.SamplesPerPixel, s.Seed)
+}
+
+// Largest float64 value smaller than 1.
+const oneMinusEpsilon = 0x1.fffffffffffffp-1
+
+// Random permutation of the values in the range [0, l), returns the position of i in the permutation selected by the seed p.
+// Hash based permutation proposed by Kensler in "Correlated Multi-Jittered Sampling".
+func permute(i uint32, l uint32, p uint32) uint32 {
+	var w = l - 1
+	w |= w >> 1
+	w |= w >> 2
+	w |= w >> 4
+	w |= w >> 8
+	w |= w >> 16
+
+	for {
+		i ^= p
+		i *= 0xe170893d
+		i ^= p >> 16
+		i ^= (i & w) >> 4
+		i ^= p >> 8
+		i *= 0x0929eb3f
+		i ^= p >> 23
+		i ^= (i & w) >> 1
+		i *= 1 | p>>27
+		i *= 0x6935fa69
+		i ^= (i & w) >> 11
+		i *= 0x74dcb303
+		i ^= (i & w) >> 2
+		i *= 0x9e501cc3
+		i ^= (i & w) >> 2
+		i *= 0xc860a3df
+		i &= w
+		i ^= i >> 5
+
+		if i < l {
+			break
+		}
+	}
+
+	return (i + p) % l
+}
+
+// Hash a value and a seed into a value in the range [0, 1).
+func randomFloat(i uint32, p uint32) float64 {
+	i ^= p
+	i ^= i >> 17
+	i ^= i >> 10
+	i *= 0xb36534e5
+	i ^= i >> 12
+	i ^= i >> 21
+	i *= 0x93fc4795
+	i ^= 0xdf6e307f
+	i ^= i >> 17
+	i *= 1 | p>>18
+	return unit(i)
+}
@@ -0,0 +1,386 @@
+package scenefile
+
+import (
+	"fmt"
+	"gotracer/camera"
+	"gotracer/geometry"
+	"gotracer/light"
+	"gotracer/material"
+	"gotracer/objfile"
+	"gotracer/spectrum"
+	"gotracer/texture"
+	"gotracer/vmath"
+	"log"
+	"path/filepath"
+
+	"github.com/gopxl/pixel/v2"
+)
+
+// Wrap modes of image textures by name, the empty name is the default mode.
+var wrapModes = map[string]texture.WrapMode{
+	"":       texture.WrapRepeat,
+	"repeat": texture.WrapRepeat,
+	"clamp":  texture.WrapClamp,
+	"mirror": texture.WrapMirror,
+}
+
+// Cull modes of triangles and meshes by name, the empty name is the default mode.
+var cullModes = map[string]geometry.CullMode{
+	"":      geometry.CullBack,
+	"back":  geometry.CullBack,
+	"front": geometry.CullFront,
+	"none":  geometry.CullNone,
+}
+
+// Load a scene file and build the scene and camera described in it.
+// The bounds of the output image are used to calculate the aspect ratio of the camera.
+func Load(fname string, bounds pixel.Rect) (*geometry.Scene, *camera.CameraDefocus, error) {
+	var description, err = ReadFile(fname)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", fname, err)
+	}
+
+	return Build(description, bounds, filepath.Dir(fname))
+}
+
+// Build the scene and camera from a scene description.
+// Relative mesh and image file paths are resolved from the directory provided.
+// If the description has no camera the default camera for the bounds is used.
+func Build(description *Description, bounds pixel.Rect, dir string) (*geometry.Scene, *camera.CameraDefocus, error) {
+	var textures = map[string]texture.Texture{}
+
+	for name, t := range description.Textures {
+		var tex, err = buildTexture(t, dir)
+		if err != nil {
+			return nil, nil, &Error{Line: t.line, Field: "textures." + name + ".file", Message: err.Error()}
+		}
+		textures[name] = tex
+	}
+
+	var materials = map[string]material.Material{}
+
+	for name, m := range description.Materials {
+		for field, t := range m.textureFields() {
+			if _, ok := textures[t]; t != "" && !ok {
+				return nil, nil, &Error{Line: m.line, Field: "materials." + name + "." + field, Message: fmt.Sprintf("undefined texture %q", t)}
+			}
+		}
+
+		var albedo, color texture.Texture
+		if m.AlbedoTexture != "" {
+			albedo = textures[m.AlbedoTexture]
+		} else if m.Albedo != nil {
+			albedo = texture.NewSolidColor(vector(m.Albedo))
+		}
+		if m.ColorTexture != "" {
+			color = textures[m.ColorTexture]
+		} else if m.Color != nil {
+			color = texture.NewSolidColor(vector(m.Color))
+		}
+
+		materials[name] = buildMaterial(m, albedo, color, textures)
+	}
+
+	var scene = geometry.NewScene()
+
+	// Meshes loaded with the same options are shared by the instances that use them
+	var meshes = map[meshKey]*geometry.Mesh{}
+
+	for i := 0; i < len(description.Objects); i++ {
+		var object = description.Objects[i]
+		// Meshes without material use the materials of the MTL files
+		var m, ok = materials[object.Material]
+		if !ok && (object.Type != "mesh" || object.Material != "") {
+			return nil, nil, &Error{Line: object.line, Field: fmt.Sprintf("objects[%d].material", i), Message: fmt.Sprintf("undefined material %q", object.Material)}
+		}
+
+		var hitable geometry.Hitable
+
+		switch object.Type {
+		case "sphere":
+			hitable = geometry.NewSphere(object.Radius, vector(object.Center), m)
+		case "box":
+			hitable = geometry.NewBox(vector(object.Min), vector(object.Max), m)
+		case "triangle":
+			var triangle *geometry.Triangle
+			if object.Normals != nil {
+				triangle = geometry.NewSmoothTriangle(vector(object.A), vector(object.B), vector(object.C), vector(object.Normals[0]), vector(object.Normals[1]), vector(object.Normals[2]), m)
+			} else {
+				triangle = geometry.NewTriangle(vector(object.A), vector(object.B), vector(object.C), m)
+			}
+			if object.UVs != nil {
+				triangle.TexCoordA = vmath.NewVec3(object.UVs[0][0], object.UVs[0][1], 0.0)
+				triangle.TexCoordB = vmath.NewVec3(object.UVs[1][0], object.UVs[1][1], 0.0)
+				triangle.TexCoordC = vmath.NewVec3(object.UVs[2][0], object.UVs[2][1], 0.0)
+				triangle.Textured = true
+			}
+			triangle.Cull = cullModes[object.Cull]
+			hitable = triangle
+		case "mesh":
+			var fname = object.File
+			if !filepath.IsAbs(fname) {
+				fname = filepath.Join(dir, fname)
+			}
+
+			var key = meshKey{file: fname, material: object.Material, creaseAngle: object.CreaseAngle, cull: cullModes[object.Cull]}
+			var mesh, ok = meshes[key]
+			if !ok {
+				var err error
+				mesh, err = loadMesh(fname, m, object.CreaseAngle, key.cull)
+				if err != nil {
+					return nil, nil, &Error{Line: object.line, Field: fmt.Sprintf("objects[%d].file", i), Message: err.Error()}
+				}
+				meshes[key] = mesh
+			}
+			hitable = mesh
+		default:
+			return nil, nil, &Error{Line: object.line, Field: fmt.Sprintf("objects[%d].type", i), Message: fmt.Sprintf("unknown object type %q", object.Type)}
+		}
+
+		if object.Transform != nil {
+			var instance, err = geometry.NewInstance(hitable, object.Transform.matrix())
+			if err != nil {
+				return nil, nil, &Error{Line: object.line, Field: fmt.Sprintf("objects[%d].transform", i), Message: "transform is not invertible"}
+			}
+			hitable = instance
+		}
+
+		// Objects with a volume material are the boundary of a volume
+		if volume, ok := m.(*material.VolumeMaterial); ok {
+			hitable = geometry.NewConstantMediumMaterial(hitable, volume)
+		}
+
+		scene.Add(hitable)
+	}
+
+	if description.Atmosphere != "" {
+		var volume, ok = materials[description.Atmosphere].(*material.VolumeMaterial)
+		if !ok {
+			return nil, nil, &Error{Line: description.atmosphereLine, Field: "atmosphere", Message: fmt.Sprintf("material %q is not a volume material", description.Atmosphere)}
+		}
+		scene.Atmosphere = volume
+	}
+	if description.AtmosphereRadius > 0 {
+		scene.AtmosphereRadius = description.AtmosphereRadius
+	}
+
+	for i := 0; i < len(description.Lights); i++ {
+		scene.AddLight(buildLight(description.Lights[i]))
+	}
+
+	var cam *camera.CameraDefocus
+
+	if description.Camera != nil {
+		var c = description.Camera
+		var up = vmath.NewVec3(0.0, 1.0, 0.0)
+		if c.Up != nil {
+			up = vector(c.Up)
+		}
+
+		var position = vector(c.Position)
+		var lookAt = vector(c.LookAt)
+
+		var focusDistance = c.FocusDistance
+		if focusDistance == 0 {
+			focusDistance = position.Sub(lookAt).Length()
+		}
+
+		cam = camera.NewCameraDefocus(bounds, position, lookAt, up, c.Fov, c.Aperture, focusDistance)
+	} else {
+		cam = camera.NewCameraDefocusBounds(bounds)
+	}
+
+	return scene, cam, nil
+}
+
+// Create a material from its description, the albedo and color textures are resolved by the caller.
+// The parameter textures of principled materials are resolved from the textures map.
+func buildMaterial(m *MaterialDescription, albedo texture.Texture, color texture.Texture, textures map[string]texture.Texture) material.Material {
+	switch m.Type {
+	case "lambert":
+		return material.NewLambertMaterialTexture(albedo)
+	case "metal":
+		return material.NewMetalMaterialTexture(albedo, m.Fuzz)
+	case "dielectric":
+		var absorption = vmath.NewVec3(1.0, 1.0, 1.0)
+		if m.AbsorptionColor != nil {
+			absorption = vector(m.AbsorptionColor)
+		}
+
+		if m.Roughness > 0 {
+			var rough = material.NewRoughDielectricMaterialTexture(m.RefractiveIndice, m.Roughness, albedo)
+			rough.AbsorptionColor = absorption
+			rough.AbsorptionDistance = m.AbsorptionDistance
+			rough.Priority = m.Priority
+			return rough
+		}
+
+		var dispersion = buildDispersion(m)
+		var ior = m.RefractiveIndice
+		if ior == 0 && dispersion != nil {
+			ior = dispersion.RefractiveIndice(spectrum.SodiumDLine)
+		}
+
+		var dielectric = material.NewDieletricMaterialTexture(ior, albedo)
+		dielectric.Dispersion = dispersion
+		dielectric.AbsorptionColor = absorption
+		dielectric.AbsorptionDistance = m.AbsorptionDistance
+		dielectric.Priority = m.Priority
+		return dielectric
+	case "conductor":
+		if m.Preset != "" {
+			var preset = material.ConductorPresets[m.Preset]
+			return material.NewConductorMaterial(preset.Eta, preset.K, m.Roughness, m.Anisotropy)
+		}
+		return material.NewConductorMaterial(vector(m.Eta), vector(m.K), m.Roughness, m.Anisotropy)
+	case "principled":
+		var p = material.NewPrincipledMaterialTexture(albedo)
+		p.Metallic = parameterTexture(m.Metallic, m.MetallicTexture, textures)
+		p.Roughness = parameterTexture(m.Roughness, m.RoughnessTexture, textures)
+		p.Specular = parameterTexture(defaultValue(m.Specular, 0.5), m.SpecularTexture, textures)
+		p.SpecularTint = parameterTexture(m.SpecularTint, m.SpecularTintTexture, textures)
+		p.Sheen = parameterTexture(m.Sheen, m.SheenTexture, textures)
+		p.SheenTint = parameterTexture(defaultValue(m.SheenTint, 0.5), m.SheenTintTexture, textures)
+		p.Clearcoat = parameterTexture(m.Clearcoat, m.ClearcoatTexture, textures)
+		p.ClearcoatGloss = parameterTexture(defaultValue(m.ClearcoatGloss, 1.0), m.ClearcoatGlossTexture, textures)
+		p.Transmission = parameterTexture(m.Transmission, m.TransmissionTexture, textures)
+
+		var ior = m.RefractiveIndice
+		if ior == 0 {
+			ior = 1.5
+		}
+		p.IOR = parameterTexture(ior, m.RefractiveIndiceTexture, textures)
+		p.Priority = m.Priority
+		return p
+	case "volume":
+		var phase material.PhaseFunction = material.NewIsotropicPhaseFunction()
+		if m.Anisotropy != 0 {
+			phase = material.NewHenyeyGreensteinPhaseFunction(m.Anisotropy)
+		}
+		return material.NewVolumeMaterialTexture(m.Density, albedo, phase)
+	case "light":
+		var light = material.NewLightMaterialTexture(color)
+		if m.Intensity != 0 {
+			light.Intensity = m.Intensity
+		}
+		light.OneSided = m.OneSided
+		return light
+	}
+
+	return material.NewNormalMaterial()
+}
+
+// Create the dispersion of a dielectric material from its description, returns nil if the material has no dispersion.
+func buildDispersion(m *MaterialDescription) spectrum.Dispersion {
+	if m.Dispersion != "" {
+		return spectrum.DispersionPresets[m.Dispersion]
+	}
+	if m.Cauchy != nil {
+		return spectrum.NewCauchyDispersion(m.Cauchy[0], m.Cauchy[1])
+	}
+	if m.Sellmeier != nil {
+		var s = m.Sellmeier
+		return spectrum.NewSellmeierDispersion([3]float64{s[0], s[1], s[2]}, [3]float64{s[3], s[4], s[5]})
+	}
+	return nil
+}
+
+// Get the texture of a scalar material parameter, the named texture if there is one or a solid texture with the value.
+func parameterTexture(value float64, name string, textures map[string]texture.Texture) texture.Texture {
+	if name != "" {
+		return textures[name]
+	}
+	return texture.NewSolidValue(value)
+}
+
+// Get the value of a optional parameter or its default value if it was omitted.
+func defaultValue(value *float64, defaultValue float64) float64 {
+	if value == nil {
+		return defaultValue
+	}
+	return *value
+}
+
+// Create a texture from its description, image files are loaded from the directory provided.
+func buildTexture(t *TextureDescription, dir string) (texture.Texture, error) {
+	switch t.Type {
+	case "checker":
+		return texture.NewChecker(texture.NewSolidColor(vector(t.Even)), texture.NewSolidColor(vector(t.Odd)), t.Size), nil
+	case "image":
+		var fname = t.File
+		if !filepath.IsAbs(fname) {
+			fname = filepath.Join(dir, fname)
+		}
+
+		var image, err = texture.LoadImageTexture(fname)
+		if err != nil {
+			return nil, err
+		}
+
+		image.WrapU = wrapModes[t.WrapU]
+		image.WrapV = wrapModes[t.WrapV]
+		return image, nil
+	case "noise":
+		return texture.NewNoise(vector(t.Color), t.Scale, t.Octaves, t.Seed), nil
+	}
+
+	return texture.NewSolidColor(vector(t.Color)), nil
+}
+
+// Create a light from its description.
+func buildLight(l *LightDescription) light.Light {
+	switch l.Type {
+	case "point":
+		return light.NewPointLight(vector(l.Position), vector(l.Color), l.Intensity)
+	case "spot":
+		return light.NewSpotLight(vector(l.Position), vector(l.Direction), vector(l.Color), l.Intensity, l.Angle, l.FalloffAngle)
+	case "directional":
+		return light.NewDirectionalLight(vector(l.Direction), vector(l.Color), l.Intensity)
+	case "sphere":
+		return light.NewSphereLight(vector(l.Center), l.Radius, vector(l.Color), l.Intensity)
+	}
+
+	return light.NewRectangleLight(vector(l.Corner), vector(l.U), vector(l.V), vector(l.Color), l.Intensity)
+}
+
+// Create a vector from a array of values.
+func vector(v []float64) vmath.Vec3 {
+	return vmath.NewVec3(v[0], v[1], v[2])
+}
+
+// Options used to load a mesh, meshes with the same options are loaded only once.
+type meshKey struct {
+	file        string
+	material    string
+	creaseAngle float64
+	cull        geometry.CullMode
+}
+
+// Load a OBJ file as a single mesh.
+// Faces with a MTL material use it, the other faces use the material provided (nil for the default MTL material).
+// If the crease angle is not zero smooth normals are generated for the faces without normals.
+func loadMesh(fname string, m material.Material, creaseAngle float64, cull geometry.CullMode) (*geometry.Mesh, error) {
+	var model, err = objfile.Load(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(model.Warnings); i++ {
+		log.Printf("Warning: %s", model.Warnings[i])
+	}
+
+	if creaseAngle > 0 {
+		model.GenerateNormals(creaseAngle)
+	}
+
+	var mesh *geometry.Mesh
+	mesh, err = model.Mesh(m)
+	if err != nil {
+		return nil, err
+	}
+
+	mesh.Cull = cull
+	mesh.File = fname
+	mesh.CreaseAngle = creaseAngle
+	return mesh, nil
+}
@@ -0,0 +1,291 @@
+package scenefile
+
+// Version of the scene file format written by the exporter.
+// Files with a different version are rejected by the loader.
+const Version = 1
+
+// Description of a scene file.
+//
+// Materials are declared once with a unique name and referenced by name from the objects, allowing them to be shared.
+// Vectors are represented as arrays with three values [x, y, z].
+type Description struct {
+	// Version of the file format.
+	Version int `json:"version"`
+
+	// Camera used to render the scene.
+	Camera *CameraDescription `json:"camera,omitempty"`
+
+	// Named textures that can be used by the materials.
+	Textures map[string]*TextureDescription `json:"textures,omitempty"`
+
+	// Named materials that can be used by the objects.
+	Materials map[string]*MaterialDescription `json:"materials"`
+
+	// Objects in the scene.
+	Objects []*ObjectDescription `json:"objects"`
+
+	// Explicit light sources in the scene.
+	Lights []*LightDescription `json:"lights,omitempty"`
+
+	// Name of the volume material that fills the space outside of the objects (fog, haze), the scene is in vacuum if empty.
+	Atmosphere string `json:"atmosphere,omitempty"`
+
+	// Radius of the sphere centered at the origin filled by the atmosphere, defaults to 50.
+	AtmosphereRadius float64 `json:"atmosphereRadius,omitempty"`
+
+	// Line where the atmosphere was declared, used to report errors.
+	atmosphereLine int
+}
+
+// Description of a camera, maps to the fields of the camera.CameraDefocus object.
+type CameraDescription struct {
+	// World position of the camera.
+	Position []float64 `json:"position"`
+
+	// Point where the camera is looking at.
+	LookAt []float64 `json:"lookAt"`
+
+	// Up direction of the camera, defaults to [0, 1, 0].
+	Up []float64 `json:"up,omitempty"`
+
+	// Field of view of the camera in degrees.
+	Fov float64 `json:"fov"`
+
+	// Lens aperture.
+	Aperture float64 `json:"aperture,omitempty"`
+
+	// Distance to be in perfect focus, defaults to the distance between the position and the look at point.
+	FocusDistance float64 `json:"focusDistance,omitempty"`
+}
+
+// Description of a material, the type indicates which of the other fields are used.
+//
+// Supported types are "lambert" (albedo), "metal" (albedo, fuzz), "dielectric" (albedo, refractiveIndice, dispersion, cauchy or sellmeier, roughness, absorptionColor, absorptionDistance, priority), "conductor" (preset or eta and k, roughness, anisotropy),
+// "principled" (albedo, metallic, roughness, specular, specularTint, sheen, sheenTint, clearcoat, clearcoatGloss, transmission, refractiveIndice, priority),
+// "volume" (albedo, density, anisotropy), "light" (color, intensity, oneSided) and "normal".
+// Objects with a volume material are the boundary of a constant density volume (fog, smoke).
+// Colors can be replaced by a texture using the albedoTexture and colorTexture fields, the parameters of principled materials using the fields with the Texture suffix.
+type MaterialDescription struct {
+	// Type of the material.
+	Type string `json:"type"`
+
+	// Base color of lambert, metal, dielectric and principled materials.
+	Albedo []float64 `json:"albedo,omitempty"`
+
+	// Name of the texture used for the base color instead of the albedo.
+	AlbedoTexture string `json:"albedoTexture,omitempty"`
+
+	// Roughness of metal materials.
+	Fuzz float64 `json:"fuzz,omitempty"`
+
+	// Refractive indice of dielectric and principled materials, defaults to 1.5 for principled materials if omitted.
+	RefractiveIndice        float64 `json:"refractiveIndice,omitempty"`
+	RefractiveIndiceTexture string  `json:"refractiveIndiceTexture,omitempty"`
+
+	// Name of the measured dispersion of smooth dielectric materials (bk7, dense-flint, diamond, fused-silica, sapphire or water), only visible in spectral mode.
+	// The refractive indice defaults to the one of the dispersion at the sodium D line if omitted.
+	Dispersion string `json:"dispersion,omitempty"`
+
+	// Custom dispersion of smooth dielectric materials with the wavelength in micrometers.
+	// The coefficients [A, B] of the Cauchy equation or [B1, B2, B3, C1, C2, C3] of the Sellmeier equation.
+	Cauchy    []float64 `json:"cauchy,omitempty"`
+	Sellmeier []float64 `json:"sellmeier,omitempty"`
+
+	// Name of the measured metal used by conductor materials (gold, copper, aluminium or silver), replaces the eta and k fields.
+	Preset string `json:"preset,omitempty"`
+
+	// Real and imaginary parts of the refractive indice of conductor materials for each color channel.
+	Eta []float64 `json:"eta,omitempty"`
+	K   []float64 `json:"k,omitempty"`
+
+	// Microfacet roughness of dielectric, conductor and principled materials in the range [0, 1], dielectrics are perfectly smooth if zero.
+	Roughness        float64 `json:"roughness,omitempty"`
+	RoughnessTexture string  `json:"roughnessTexture,omitempty"`
+
+	// Anisotropy of the roughness of conductor materials in the range [0, 1].
+	// For volume materials the anisotropy of the Henyey-Greenstein phase function in the range (-1, 1), positive values scatter forward and zero is isotropic.
+	Anisotropy float64 `json:"anisotropy,omitempty"`
+
+	// Density of the particles of volume materials (probability of scattering or absorbing a ray per unit of distance).
+	Density float64 `json:"density,omitempty"`
+
+	// Color of the light after traveling the absorption distance inside of dielectric materials, no light is absorbed if the distance is zero.
+	AbsorptionColor    []float64 `json:"absorptionColor,omitempty"`
+	AbsorptionDistance float64   `json:"absorptionDistance,omitempty"`
+
+	// Priority of dielectric and principled materials where objects overlap, the material with the highest priority fills the overlapping space.
+	Priority int `json:"priority,omitempty"`
+
+	// Parameters of principled materials in the range [0, 1], the specular defaults to 0.5, the sheen tint to 0.5 and the clearcoat gloss to 1 if omitted.
+	Metallic              float64  `json:"metallic,omitempty"`
+	MetallicTexture       string   `json:"metallicTexture,omitempty"`
+	Specular              *float64 `json:"specular,omitempty"`
+	SpecularTexture       string   `json:"specularTexture,omitempty"`
+	SpecularTint          float64  `json:"specularTint,omitempty"`
+	SpecularTintTexture   string   `json:"specularTintTexture,omitempty"`
+	Sheen                 float64  `json:"sheen,omitempty"`
+	SheenTexture          string   `json:"sheenTexture,omitempty"`
+	SheenTint             *float64 `json:"sheenTint,omitempty"`
+	SheenTintTexture      string   `json:"sheenTintTexture,omitempty"`
+	Clearcoat             float64  `json:"clearcoat,omitempty"`
+	ClearcoatTexture      string   `json:"clearcoatTexture,omitempty"`
+	ClearcoatGloss        *float64 `json:"clearcoatGloss,omitempty"`
+	ClearcoatGlossTexture string   `json:"clearcoatGlossTexture,omitempty"`
+	Transmission          float64  `json:"transmission,omitempty"`
+	TransmissionTexture   string   `json:"transmissionTexture,omitempty"`
+
+	// Color of light materials.
+	Color []float64 `json:"color,omitempty"`
+
+	// Name of the texture used for the color of light materials instead of the color.
+	ColorTexture string `json:"colorTexture,omitempty"`
+
+	// Radiance emitted by light materials, defaults to 1 if omitted.
+	Intensity float64 `json:"intensity,omitempty"`
+
+	// If true light materials only emit light from the front face.
+	OneSided bool `json:"oneSided,omitempty"`
+
+	// Line where the material was declared, used to report errors.
+	line int
+}
+
+// Get the texture fields of the material indexed by their name in the file, empty values are not used.
+func (m *MaterialDescription) textureFields() map[string]string {
+	return map[string]string{
+		"albedoTexture":           m.AlbedoTexture,
+		"colorTexture":            m.ColorTexture,
+		"metallicTexture":         m.MetallicTexture,
+		"roughnessTexture":        m.RoughnessTexture,
+		"specularTexture":         m.SpecularTexture,
+		"specularTintTexture":     m.SpecularTintTexture,
+		"sheenTexture":            m.SheenTexture,
+		"sheenTintTexture":        m.SheenTintTexture,
+		"clearcoatTexture":        m.ClearcoatTexture,
+		"clearcoatGlossTexture":   m.ClearcoatGlossTexture,
+		"transmissionTexture":     m.TransmissionTexture,
+		"refractiveIndiceTexture": m.RefractiveIndiceTexture,
+	}
+}
+
+// Description of a object, the type indicates which of the other fields are used.
+//
+// Supported types are "sphere" (center, radius), "box" (min, max), "triangle" (a, b, c, normals, uvs) and "mesh" (file).
+type ObjectDescription struct {
+	// Type of the object.
+	Type string `json:"type"`
+
+	// Name of the material used by the object.
+	// Optional for meshes, faces with a MTL material use it and the material is used for the other faces.
+	Material string `json:"material,omitempty"`
+
+	// Center and radius of spheres.
+	Center []float64 `json:"center,omitempty"`
+	Radius float64   `json:"radius,omitempty"`
+
+	// Corners of boxes.
+	Min []float64 `json:"min,omitempty"`
+	Max []float64 `json:"max,omitempty"`
+
+	// Vertices of triangles.
+	A []float64 `json:"a,omitempty"`
+	B []float64 `json:"b,omitempty"`
+	C []float64 `json:"c,omitempty"`
+
+	// Optional normals of the vertices of triangles (a, b and c), interpolated across the triangle for smooth shading.
+	Normals [][]float64 `json:"normals,omitempty"`
+
+	// Optional texture coordinates (u and v) of the vertices of triangles, the barycentric coordinates are used if not present.
+	UVs [][]float64 `json:"uvs,omitempty"`
+
+	// Path of the OBJ file of a mesh, relative paths are resolved from the directory of the scene file.
+	File string `json:"file,omitempty"`
+
+	// Faces of triangles and meshes ignored by the ray intersection ("back", "front" or "none"), defaults to back.
+	Cull string `json:"cull,omitempty"`
+
+	// Crease angle in degrees used to generate smooth normals for meshes without normals, zero keeps the faces flat.
+	CreaseAngle float64 `json:"creaseAngle,omitempty"`
+
+	// Optional transform that places the object in the scene.
+	Transform *TransformDescription `json:"transform,omitempty"`
+
+	// Line where the object was declared, used to report errors.
+	line int
+}
+
+// Description of a explicit light source, the type indicates which of the other fields are used.
+//
+// Supported types are "point" (position), "spot" (position, direction, angle, falloffAngle), "directional" (direction),
+// "sphere" (center, radius) and "rectangle" (corner, u, v). All lights have a color and intensity.
+type LightDescription struct {
+	// Type of the light.
+	Type string `json:"type"`
+
+	// Color and intensity of the light.
+	Color     []float64 `json:"color"`
+	Intensity float64   `json:"intensity"`
+
+	// Position of point and spot lights.
+	Position []float64 `json:"position,omitempty"`
+
+	// Direction of spot and directional lights.
+	Direction []float64 `json:"direction,omitempty"`
+
+	// Cone angles of spot lights in degrees.
+	Angle        float64 `json:"angle,omitempty"`
+	FalloffAngle float64 `json:"falloffAngle,omitempty"`
+
+	// Center and radius of sphere lights.
+	Center []float64 `json:"center,omitempty"`
+	Radius float64   `json:"radius,omitempty"`
+
+	// Corner and edges of rectangle lights.
+	Corner []float64 `json:"corner,omitempty"`
+	U      []float64 `json:"u,omitempty"`
+	V      []float64 `json:"v,omitempty"`
+}
+
+// Description of a texture, the type indicates which of the other fields are used.
+//
+// Supported types are "solid" (color), "checker" (even, odd, size), "image" (file, wrapU, wrapV) and "noise" (color, scale, octaves, seed).
+type TextureDescription struct {
+	// Type of the texture.
+	Type string `json:"type"`
+
+	// Color of solid and noise textures.
+	Color []float64 `json:"color,omitempty"`
+
+	// Colors and cell size of checker textures.
+	Even []float64 `json:"even,omitempty"`
+	Odd  []float64 `json:"odd,omitempty"`
+	Size float64   `json:"size,omitempty"`
+
+	// Path of the image file (PNG or JPEG), relative paths are resolved from the directory of the scene file.
+	File string `json:"file,omitempty"`
+
+	// Wrap mode of image textures ("repeat", "clamp" or "mirror"), defaults to repeat.
+	WrapU string `json:"wrapU,omitempty"`
+	WrapV string `json:"wrapV,omitempty"`
+
+	// Frequency, number of octaves and random seed of noise textures.
+	Scale   float64 `json:"scale,omitempty"`
+	Octaves int     `json:"octaves,omitempty"`
+	Seed    int64   `json:"seed,omitempty"`
+
+	// Line where the texture was declared, used to report errors.
+	line int
+}
+
+// Description of a object-to-world transform.
+//
+// The transform is either a 4x4 matrix in row-major order or a combination of scale, rotation and translation applied in this order.
+// Rotations are euler angles in degrees applied around the X, Y and Z axes in this order.
+type TransformDescription struct {
+	Translate []float64 `json:"translate,omitempty"`
+	Rotate    []float64 `json:"rotate,omitempty"`
+	Scale     []float64 `json:"scale,omitempty"`
+
+	// Transform matrix, cannot be used together with the other fields.
+	Matrix []float64 `json:"matrix,omitempty"`
+}
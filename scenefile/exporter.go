@@ -0,0 +1,445 @@
+package scenefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"gotracer/camera"
+	"gotracer/geometry"
+	"gotracer/light"
+	"gotracer/material"
+	"gotracer/spectrum"
+	"gotracer/texture"
+	"gotracer/vmath"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Create a scene description from a scene and camera.
+// Materials and textures shared by multiple objects are exported once and referenced by name, solid colors are exported inline.
+// Mesh and image files are referenced by their absolute path, WriteFile makes them relative to the directory of the file written.
+// The camera is optional, if nil the description is exported without camera.
+func Export(scene *geometry.Scene, cam *camera.CameraDefocus) (*Description, error) {
+	var description = new(Description)
+	description.Version = Version
+	description.Materials = map[string]*MaterialDescription{}
+
+	if cam != nil {
+		description.Camera = &CameraDescription{
+			Position:      array(cam.Position),
+			LookAt:        array(cam.LookAt),
+			Up:            array(cam.Up),
+			Fov:           cam.Fov,
+			Aperture:      cam.Aperture,
+			FocusDistance: cam.FocusDistance,
+		}
+	}
+
+	var names = map[material.Material]string{}
+	var textures = map[texture.Texture]string{}
+
+	for i := 0; i < len(scene.List); i++ {
+		var objects, err = exportObject(description, names, textures, scene.List[i], nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("scenefile: object %d %w", i, err)
+		}
+
+		description.Objects = append(description.Objects, objects...)
+	}
+
+	if scene.Atmosphere != nil {
+		description.Atmosphere = exportMaterial(description, names, textures, scene.Atmosphere)
+		if description.Atmosphere == "" {
+			return nil, fmt.Errorf("scenefile: atmosphere cannot be exported")
+		}
+		description.AtmosphereRadius = scene.AtmosphereRadius
+	}
+
+	for i := 0; i < len(scene.Lights); i++ {
+		var ld *LightDescription
+
+		switch l := scene.Lights[i].(type) {
+		case *light.PointLight:
+			ld = &LightDescription{Type: "point", Position: array(l.Position), Color: array(l.Color), Intensity: l.Intensity}
+		case *light.SpotLight:
+			ld = &LightDescription{Type: "spot", Position: array(l.Position), Direction: array(l.Direction), Color: array(l.Color), Intensity: l.Intensity, Angle: l.Angle, FalloffAngle: l.FalloffAngle}
+		case *light.DirectionalLight:
+			ld = &LightDescription{Type: "directional", Direction: array(l.Direction), Color: array(l.Color), Intensity: l.Intensity}
+		case *light.SphereLight:
+			ld = &LightDescription{Type: "sphere", Center: array(l.Center), Radius: l.Radius, Color: array(l.Color), Intensity: l.Intensity}
+		case *light.RectangleLight:
+			ld = &LightDescription{Type: "rectangle", Corner: array(l.Corner), U: array(l.U), V: array(l.V), Color: array(l.Color), Intensity: l.Intensity}
+		default:
+			return nil, fmt.Errorf("scenefile: light %d of type %T cannot be exported", i, l)
+		}
+
+		description.Lights = append(description.Lights, ld)
+	}
+
+	return description, nil
+}
+
+// Write the scene description as indented JSON.
+func Write(writer io.Writer, description *Description) error {
+	var data, err = json.MarshalIndent(description, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(append(data, '\n'))
+	return err
+}
+
+// Write the scene description into a file.
+// Absolute mesh and image file paths are written relative to the directory of the file, the description is not modified.
+func WriteFile(fname string, description *Description) error {
+	var file, err = os.Create(fname)
+	if err != nil {
+		return err
+	}
+
+	err = Write(file, relativePaths(description, filepath.Dir(fname)))
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Create the descriptions of a object, meshes are exported as a reference to the OBJ file they were loaded from.
+// Instances are exported as the descriptions of their object with the transform, combined with the transform of the parent instances.
+// Constant media are exported as their boundary with the volume material, the volume replaces the materials of the boundary if not nil.
+func exportObject(description *Description, names map[material.Material]string, textures map[texture.Texture]string, hitable geometry.Hitable, transform *vmath.Matrix4, volume *material.VolumeMaterial) ([]*ObjectDescription, error) {
+	var objects []*ObjectDescription
+	var materials []material.Material
+
+	switch o := hitable.(type) {
+	case *geometry.Sphere:
+		objects = append(objects, &ObjectDescription{Type: "sphere", Center: array(o.Center), Radius: o.Radius})
+		materials = append(materials, o.Material)
+	case *geometry.Box:
+		objects = append(objects, &ObjectDescription{Type: "box", Min: array(o.Min), Max: array(o.Max)})
+		materials = append(materials, o.Material)
+	case *geometry.Triangle:
+		var object = &ObjectDescription{Type: "triangle", A: array(o.A), B: array(o.B), C: array(o.C), Cull: cullModeName(o.Cull)}
+		if o.Smooth {
+			object.Normals = [][]float64{array(o.NormalA), array(o.NormalB), array(o.NormalC)}
+		}
+		if o.Textured {
+			object.UVs = [][]float64{{o.TexCoordA.X, o.TexCoordA.Y}, {o.TexCoordB.X, o.TexCoordB.Y}, {o.TexCoordC.X, o.TexCoordC.Y}}
+		}
+		objects = append(objects, object)
+		materials = append(materials, o.Material)
+	case *geometry.Mesh:
+		if o.File == "" {
+			return nil, fmt.Errorf("mesh was not loaded from a file and cannot be exported")
+		}
+		objects = append(objects, &ObjectDescription{Type: "mesh", File: absolutePath(o.File), CreaseAngle: o.CreaseAngle, Cull: cullModeName(o.Cull)})
+		materials = append(materials, meshMaterial(o))
+	case *geometry.Instance:
+		var combined = o.Transform.Clone()
+		if transform != nil {
+			combined.Premultiply(transform)
+		}
+		return exportObject(description, names, textures, o.Object, combined, volume)
+	case *geometry.ConstantMedium:
+		return exportObject(description, names, textures, o.Boundary, transform, o.Material)
+	default:
+		return nil, fmt.Errorf("of type %T cannot be exported", o)
+	}
+
+	for i := 0; i < len(objects); i++ {
+		if volume != nil {
+			materials[i] = volume
+		}
+
+		// Meshes without material only use the materials of their MTL files
+		if materials[i] != nil {
+			objects[i].Material = exportMaterial(description, names, textures, materials[i])
+			if objects[i].Material == "" {
+				return nil, fmt.Errorf("material cannot be exported")
+			}
+		}
+
+		if transform != nil {
+			objects[i].Transform = &TransformDescription{Matrix: transform.Values[:]}
+		}
+	}
+
+	return objects, nil
+}
+
+// Get the material used by the faces of a mesh without MTL material, returns nil if every face has a MTL material.
+// The MTL materials are loaded again from the OBJ file and are not exported.
+func meshMaterial(mesh *geometry.Mesh) material.Material {
+	if mesh.DefaultMaterial < 0 {
+		return nil
+	}
+
+	for i := 0; i < len(mesh.FaceMaterials); i++ {
+		if mesh.FaceMaterials[i] == mesh.DefaultMaterial {
+			return mesh.Materials[mesh.DefaultMaterial]
+		}
+	}
+	return nil
+}
+
+// Add the material to the description if it was not added before, returns the name of the material.
+// Returns a empty name if the material type or its texture are not supported.
+func exportMaterial(description *Description, names map[material.Material]string, textures map[texture.Texture]string, m material.Material) string {
+	if name, ok := names[m]; ok {
+		return name
+	}
+
+	var md *MaterialDescription
+	var ok = true
+
+	switch o := m.(type) {
+	case *material.LambertMaterial:
+		md = &MaterialDescription{Type: "lambert"}
+		md.Albedo, md.AlbedoTexture, ok = exportTexture(description, textures, o.Albedo)
+	case *material.MetalMaterial:
+		md = &MaterialDescription{Type: "metal", Fuzz: o.Fuzz}
+		md.Albedo, md.AlbedoTexture, ok = exportTexture(description, textures, o.Albedo)
+	case *material.DieletricMaterial:
+		md = &MaterialDescription{Type: "dielectric", RefractiveIndice: o.RefractiveIndice, Priority: o.Priority}
+		md.AbsorptionColor, md.AbsorptionDistance = exportAbsorption(o.AbsorptionColor, o.AbsorptionDistance)
+		exportDispersion(md, o.Dispersion)
+		md.Albedo, md.AlbedoTexture, ok = exportTexture(description, textures, o.Albedo)
+	case *material.RoughDielectricMaterial:
+		md = &MaterialDescription{Type: "dielectric", RefractiveIndice: o.RefractiveIndice, Roughness: o.Roughness, Priority: o.Priority}
+		md.AbsorptionColor, md.AbsorptionDistance = exportAbsorption(o.AbsorptionColor, o.AbsorptionDistance)
+		md.Albedo, md.AlbedoTexture, ok = exportTexture(description, textures, o.Albedo)
+	case *material.ConductorMaterial:
+		md = &MaterialDescription{Type: "conductor", Eta: array(o.Eta), K: array(o.K), Roughness: o.Roughness, Anisotropy: o.Anisotropy}
+	case *material.PrincipledMaterial:
+		md, ok = exportPrincipled(description, textures, o)
+	case *material.VolumeMaterial:
+		md = &MaterialDescription{Type: "volume", Density: o.Density}
+		if phase, ok := o.Phase.(*material.HenyeyGreensteinPhaseFunction); ok {
+			md.Anisotropy = phase.G
+		}
+		md.Albedo, md.AlbedoTexture, ok = exportTexture(description, textures, o.Albedo)
+	case *material.LightMaterial:
+		md = &MaterialDescription{Type: "light", Intensity: o.Intensity, OneSided: o.OneSided}
+		md.Color, md.ColorTexture, ok = exportTexture(description, textures, o.Color)
+	case *material.NormalMaterial:
+		md = &MaterialDescription{Type: "normal"}
+	default:
+		return ""
+	}
+
+	if !ok {
+		return ""
+	}
+
+	var name = fmt.Sprintf("%s%d", md.Type, len(description.Materials))
+	description.Materials[name] = md
+	names[m] = name
+
+	return name
+}
+
+// Export the dispersion of a dielectric material, presets are exported by name.
+// Custom dispersion models are not supported by the scene format and are not exported.
+func exportDispersion(md *MaterialDescription, dispersion spectrum.Dispersion) {
+	if dispersion == nil {
+		return
+	}
+
+	for name, preset := range spectrum.DispersionPresets {
+		if preset == dispersion {
+			md.Dispersion = name
+			return
+		}
+	}
+
+	switch d := dispersion.(type) {
+	case *spectrum.CauchyDispersion:
+		md.Cauchy = []float64{d.A, d.B}
+	case *spectrum.SellmeierDispersion:
+		md.Sellmeier = []float64{d.B[0], d.B[1], d.B[2], d.C[0], d.C[1], d.C[2]}
+	}
+}
+
+// Export the absorption of a dielectric material, the color is omitted if there is no absorption.
+func exportAbsorption(color vmath.Vec3, distance float64) ([]float64, float64) {
+	if distance <= 0 {
+		return nil, 0.0
+	}
+	return array(color), distance
+}
+
+// Export the base color and the parameters of a principled material.
+// Returns false if any of the textures is not supported.
+func exportPrincipled(description *Description, textures map[texture.Texture]string, m *material.PrincipledMaterial) (*MaterialDescription, bool) {
+	var md = &MaterialDescription{Type: "principled", Priority: m.Priority}
+	var specular, sheenTint, clearcoatGloss float64
+
+	var parameters = []struct {
+		value   *float64
+		name    *string
+		texture texture.Texture
+	}{
+		{&md.Metallic, &md.MetallicTexture, m.Metallic},
+		{&md.Roughness, &md.RoughnessTexture, m.Roughness},
+		{&specular, &md.SpecularTexture, m.Specular},
+		{&md.SpecularTint, &md.SpecularTintTexture, m.SpecularTint},
+		{&md.Sheen, &md.SheenTexture, m.Sheen},
+		{&sheenTint, &md.SheenTintTexture, m.SheenTint},
+		{&md.Clearcoat, &md.ClearcoatTexture, m.Clearcoat},
+		{&clearcoatGloss, &md.ClearcoatGlossTexture, m.ClearcoatGloss},
+		{&md.Transmission, &md.TransmissionTexture, m.Transmission},
+		{&md.RefractiveIndice, &md.RefractiveIndiceTexture, m.IOR},
+	}
+
+	var ok bool
+	md.Albedo, md.AlbedoTexture, ok = exportTexture(description, textures, m.BaseColor)
+	if !ok {
+		return nil, false
+	}
+
+	for _, p := range parameters {
+		var value []float64
+		value, *p.name, ok = exportTexture(description, textures, p.texture)
+		if !ok {
+			return nil, false
+		}
+		if value != nil {
+			*p.value = value[0]
+		}
+	}
+
+	// Parameters with default values different from zero are omitted when they are textures
+	if md.SpecularTexture == "" {
+		md.Specular = &specular
+	}
+	if md.SheenTintTexture == "" {
+		md.SheenTint = &sheenTint
+	}
+	if md.ClearcoatGlossTexture == "" {
+		md.ClearcoatGloss = &clearcoatGloss
+	}
+
+	return md, true
+}
+
+// Export a texture used by a material, solid colors are returned as a color value and other textures are added to the description by name.
+// Returns false if the texture type is not supported.
+func exportTexture(description *Description, names map[texture.Texture]string, t texture.Texture) ([]float64, string, bool) {
+	if solid, ok := t.(*texture.SolidColor); ok {
+		return array(solid.Color), "", true
+	}
+
+	if name, ok := names[t]; ok {
+		return nil, name, true
+	}
+
+	var td *TextureDescription
+
+	switch o := t.(type) {
+	case *texture.Checker:
+		var even, evenOk = o.Even.(*texture.SolidColor)
+		var odd, oddOk = o.Odd.(*texture.SolidColor)
+		if !evenOk || !oddOk {
+			return nil, "", false
+		}
+		td = &TextureDescription{Type: "checker", Even: array(even.Color), Odd: array(odd.Color), Size: o.Size}
+	case *texture.ImageTexture:
+		if o.Path == "" {
+			return nil, "", false
+		}
+		td = &TextureDescription{Type: "image", File: absolutePath(o.Path), WrapU: wrapModeName(o.WrapU), WrapV: wrapModeName(o.WrapV)}
+	case *texture.Noise:
+		td = &TextureDescription{Type: "noise", Color: array(o.Color), Scale: o.Scale, Octaves: o.Octaves, Seed: o.Seed}
+	default:
+		return nil, "", false
+	}
+
+	if description.Textures == nil {
+		description.Textures = map[string]*TextureDescription{}
+	}
+
+	var name = fmt.Sprintf("%s%d", td.Type, len(description.Textures))
+	description.Textures[name] = td
+	names[t] = name
+
+	return nil, name, true
+}
+
+// Create a copy of the description with the absolute mesh and image file paths relative to the directory.
+func relativePaths(description *Description, dir string) *Description {
+	var result = *description
+
+	result.Objects = make([]*ObjectDescription, len(description.Objects))
+	for i, o := range description.Objects {
+		var od = *o
+		od.File = relativePath(o.File, dir)
+		result.Objects[i] = &od
+	}
+
+	if description.Textures != nil {
+		result.Textures = make(map[string]*TextureDescription, len(description.Textures))
+		for name, t := range description.Textures {
+			var td = *t
+			td.File = relativePath(t.File, dir)
+			result.Textures[name] = &td
+		}
+	}
+
+	return &result
+}
+
+// Get the absolute path of a file, the path is kept if it cannot be resolved.
+func absolutePath(fname string) string {
+	var path, err = filepath.Abs(fname)
+	if err != nil {
+		return fname
+	}
+	return path
+}
+
+// Get the path of a file relative to a directory, relative paths and paths that cannot be made relative are kept.
+func relativePath(fname string, dir string) string {
+	if !filepath.IsAbs(fname) {
+		return fname
+	}
+
+	var base, err = filepath.Abs(dir)
+	if err != nil {
+		return fname
+	}
+
+	var path string
+	path, err = filepath.Rel(base, fname)
+	if err != nil {
+		return fname
+	}
+	return filepath.ToSlash(path)
+}
+
+// Get the name of a cull mode as used in the scene file, the default mode has a empty name.
+func cullModeName(mode geometry.CullMode) string {
+	for name, m := range cullModes {
+		if m == mode && name != "back" {
+			return name
+		}
+	}
+	return ""
+}
+
+// Get the name of a wrap mode as used in the scene file.
+func wrapModeName(mode texture.WrapMode) string {
+	for name, m := range wrapModes {
+		if m == mode && name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// Create a array of values from a vector.
+func array(v vmath.Vec3) []float64 {
+	return []float64{v.X, v.Y, v.Z}
+}
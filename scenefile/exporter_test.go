@@ -0,0 +1,220 @@
+package scenefile
+
+import (
+	"bytes"
+	"gotracer/camera"
+	"gotracer/geometry"
+	"gotracer/material"
+	"gotracer/vmath"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gopxl/pixel/v2"
+)
+
+var testBounds = pixel.R(0, 0, 64, 48)
+
+// Load a scene file, export it into a temporary directory and load the exported file again.
+func roundTrip(t *testing.T, fname string) (*Description, *geometry.Scene, *camera.CameraDefocus) {
+	t.Helper()
+
+	var scene, cam, err = Load(fname, testBounds)
+	if err != nil {
+		t.Fatalf("load %s: %v", fname, err)
+	}
+
+	var description *Description
+	description, err = Export(scene, cam)
+	if err != nil {
+		t.Fatalf("export %s: %v", fname, err)
+	}
+
+	var output = filepath.Join(t.TempDir(), "scene.json")
+	err = WriteFile(output, description)
+	if err != nil {
+		t.Fatalf("write %s: %v", output, err)
+	}
+
+	var reloaded *geometry.Scene
+	reloaded, cam, err = Load(output, testBounds)
+	if err != nil {
+		t.Fatalf("reload %s: %v", fname, err)
+	}
+
+	return description, reloaded, cam
+}
+
+// Get the JSON of a description.
+func marshal(t *testing.T, description *Description) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	var err = Write(&buffer, description)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+// Exporting a scene loaded from a exported file produces the same description.
+func TestExportRoundTrip(t *testing.T) {
+	var files, err = filepath.Glob("../scenes/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no scene files found: %v", err)
+	}
+
+	for _, fname := range files {
+		t.Run(filepath.Base(fname), func(t *testing.T) {
+			var description, scene, cam = roundTrip(t, fname)
+
+			var exported, err = Export(scene, cam)
+			if err != nil {
+				t.Fatalf("export the reloaded scene: %v", err)
+			}
+
+			var expected, result = marshal(t, description), marshal(t, exported)
+			if !bytes.Equal(expected, result) {
+				t.Errorf("reloaded scene exported a different description\n%s\nexpected\n%s", result, expected)
+			}
+		})
+	}
+}
+
+// Meshes are exported as a reference to their file, the normals and texture coordinates are not lost.
+func TestExportMeshAttributes(t *testing.T) {
+	var original, _, err = Load("../scenes/mesh.json", testBounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var description, scene, _ = roundTrip(t, "../scenes/mesh.json")
+	if len(description.Objects) != 1 || description.Objects[0].Type != "mesh" {
+		t.Fatalf("mesh exported as %d objects, expected a single mesh", len(description.Objects))
+	}
+
+	var a = original.List[0].(*geometry.Mesh)
+	var b = scene.List[0].(*geometry.Mesh)
+
+	if a.FaceCount() != b.FaceCount() || len(a.Normals) != len(b.Normals) || len(a.TexCoords) != len(b.TexCoords) || len(a.Materials) != len(b.Materials) {
+		t.Errorf("reloaded mesh has %d faces, %d normals, %d texture coordinates and %d materials, expected %d, %d, %d and %d",
+			b.FaceCount(), len(b.Normals), len(b.TexCoords), len(b.Materials), a.FaceCount(), len(a.Normals), len(a.TexCoords), len(a.Materials))
+	}
+	if len(b.TexCoords) == 0 {
+		t.Errorf("reloaded mesh has no texture coordinates")
+	}
+}
+
+// Meshes that were not loaded from a file cannot be referenced by the scene file.
+func TestExportMeshWithoutFile(t *testing.T) {
+	var scene, _, err = Load("../scenes/mesh.json", testBounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scene.List[0].(*geometry.Mesh).File = ""
+	if _, err = Export(scene, nil); err == nil {
+		t.Errorf("mesh without file was exported")
+	}
+}
+
+// The material of the faces without MTL material is found by its index, it does not have to be the first material of the mesh.
+func TestExportMeshDefaultMaterial(t *testing.T) {
+	var positions = []vmath.Vec3{vmath.NewVec3(0, 0, 0), vmath.NewVec3(1, 0, 0), vmath.NewVec3(0, 1, 0)}
+	var mtl = material.NewMetalMaterial(vmath.NewVec3(0.9, 0.9, 0.9), 0.1)
+	var lambert = material.NewLambertMaterial(vmath.NewVec3(0.2, 0.4, 0.8))
+
+	var mesh = geometry.NewMesh(positions, []int32{0, 1, 2, 0, 2, 1}, []material.Material{mtl, lambert}, []int32{0, 1})
+	mesh.DefaultMaterial = 1
+	mesh.File = "mesh.obj"
+
+	var scene = geometry.NewScene()
+	scene.Add(mesh)
+
+	var description, err = Export(scene, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m = description.Materials[description.Objects[0].Material]
+	if m == nil || m.Type != "lambert" {
+		t.Errorf("mesh exported with the material %q, expected the lambert material", description.Objects[0].Material)
+	}
+
+	// Meshes where every face has a MTL material are exported without material
+	mesh.DefaultMaterial = -1
+	description, err = Export(scene, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if description.Objects[0].Material != "" {
+		t.Errorf("mesh without default material exported with the material %q", description.Objects[0].Material)
+	}
+}
+
+// Vertex normals and texture coordinates of triangles are kept by the export.
+func TestExportTriangleAttributes(t *testing.T) {
+	var fname = filepath.Join(t.TempDir(), "triangle.json")
+	var data = testScene(testMaterials, []string{
+		`{"type": "triangle", "a": [0, 0, 0], "b": [1, 0, 0], "c": [0, 1, 0], "normals": [[0, 0, 1], [0.6, 0, 0.8], [0, 0.6, 0.8]], "uvs": [[0, 0], [2, 0], [0, 2]], "material": "red"}`,
+		`{"type": "triangle", "a": [0, 0, 1], "b": [1, 0, 1], "c": [0, 1, 1], "material": "red", "cull": "none"}`,
+	})
+
+	var err = os.WriteFile(fname, []byte(data), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var _, scene, _ = roundTrip(t, fname)
+
+	var smooth = scene.List[0].(*geometry.Triangle)
+	if !smooth.Smooth || smooth.NormalB != vmath.NewVec3(0.6, 0, 0.8) {
+		t.Errorf("smooth triangle reloaded with smooth %t and normal %s", smooth.Smooth, smooth.NormalB.ToString())
+	}
+	if !smooth.Textured || smooth.TexCoordC != vmath.NewVec3(0, 2, 0) {
+		t.Errorf("textured triangle reloaded with textured %t and texture coordinate %s", smooth.Textured, smooth.TexCoordC.ToString())
+	}
+
+	var flat = scene.List[1].(*geometry.Triangle)
+	if flat.Smooth || flat.Textured || flat.Cull != geometry.CullNone {
+		t.Errorf("flat triangle reloaded with smooth %t, textured %t and cull mode %d", flat.Smooth, flat.Textured, flat.Cull)
+	}
+}
+
+func TestExportInstances(t *testing.T) {
+	var description, scene, _ = roundTrip(t, "../scenes/instances.json")
+
+	if len(description.Objects) != 5 {
+		t.Fatalf("exported %d objects, expected 5", len(description.Objects))
+	}
+
+	var meshes []*geometry.Mesh
+	for i, o := range description.Objects[1:4] {
+		if o.Type != "mesh" || o.Transform == nil {
+			t.Fatalf("object %d exported as %q, expected a mesh with a transform", i+1, o.Type)
+		}
+
+		var instance, ok = scene.List[i+1].(*geometry.Instance)
+		if !ok {
+			t.Fatalf("object %d reloaded as %T, expected a instance", i+1, scene.List[i+1])
+		}
+		meshes = append(meshes, instance.Object.(*geometry.Mesh))
+	}
+
+	// The first two instances use the same mesh and material
+	if meshes[0] != meshes[1] {
+		t.Errorf("instances of the same mesh were not shared after reloading")
+	}
+}
+
+// The atmosphere is exported with the radius of its sphere.
+func TestExportAtmosphere(t *testing.T) {
+	var description, scene, _ = roundTrip(t, "../scenes/fog.json")
+
+	if description.Atmosphere == "" || scene.Atmosphere == nil {
+		t.Fatalf("atmosphere was not exported")
+	}
+	if scene.AtmosphereRadius != 20.0 {
+		t.Errorf("atmosphere radius is %f, expected 20", scene.AtmosphereRadius)
+	}
+}
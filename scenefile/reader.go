@@ -0,0 +1,467 @@
+package scenefile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Error found while reading a scene file, indicates the line and the field where the problem was found.
+type Error struct {
+	// Line in the file (starting at 1).
+	Line int
+
+	// Path of the field with the problem (e.g. "objects[2].radius").
+	Field string
+
+	// Description of the problem.
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Field, e.Message)
+}
+
+// Parser keeps the state required to report the line of the values being read.
+type parser struct {
+	data    []byte
+	decoder *json.Decoder
+}
+
+// Read a scene description from a file.
+func ReadFile(fname string) (*Description, error) {
+	var data, err = os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Read a scene description from a reader.
+func Read(reader io.Reader) (*Description, error) {
+	var data, err = io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Parse and validate a scene description.
+// Errors returned are of the type *Error and indicate the line and field of the problem.
+func Parse(data []byte) (*Description, error) {
+	var p = &parser{data: data, decoder: json.NewDecoder(bytes.NewReader(data))}
+	var description = new(Description)
+
+	var err = p.expectDelim('{', "")
+	if err != nil {
+		return nil, err
+	}
+
+	var versionLine = 0
+
+	// Line of each field found, used to report duplicated fields
+	var keyLines = map[string]int{}
+
+	for p.decoder.More() {
+		var keyLine = p.line(p.decoder.InputOffset())
+
+		var key string
+		key, err = p.key("")
+		if err != nil {
+			return nil, err
+		}
+
+		if first, ok := keyLines[key]; ok {
+			return nil, &Error{Line: keyLine, Field: key, Message: fmt.Sprintf("duplicated field, already defined in line %d", first)}
+		}
+		keyLines[key] = keyLine
+
+		var line = p.line(p.decoder.InputOffset())
+
+		switch key {
+		case "version":
+			versionLine = line
+			err = p.value(&description.Version, "version")
+		case "camera":
+			description.Camera = new(CameraDescription)
+			err = p.value(description.Camera, "camera")
+			if err == nil {
+				err = description.Camera.validate(line, "camera")
+			}
+		case "textures":
+			description.Textures, err = p.textures()
+		case "materials":
+			description.Materials, err = p.materials()
+		case "objects":
+			description.Objects, err = p.objects()
+		case "lights":
+			description.Lights, err = p.lights()
+		case "atmosphere":
+			description.atmosphereLine = line
+			err = p.value(&description.Atmosphere, "atmosphere")
+		case "atmosphereRadius":
+			err = p.value(&description.AtmosphereRadius, "atmosphereRadius")
+			if err == nil && description.AtmosphereRadius <= 0 {
+				err = &Error{Line: line, Field: "atmosphereRadius", Message: "must be greater than zero"}
+			}
+		default:
+			err = &Error{Line: keyLine, Field: key, Message: "unknown field"}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = p.expectDelim('}', "")
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.end()
+	if err != nil {
+		return nil, err
+	}
+
+	if versionLine == 0 {
+		return nil, &Error{Line: 1, Field: "version", Message: "missing version"}
+	}
+
+	if description.Version != Version {
+		return nil, &Error{Line: versionLine, Field: "version", Message: fmt.Sprintf("unsupported version %d, expected %d", description.Version, Version)}
+	}
+
+	// Material textures are validated after all textures are known
+	for name, material := range description.Materials {
+		for field, texture := range material.textureFields() {
+			if _, ok := description.Textures[texture]; texture != "" && !ok {
+				return nil, &Error{Line: material.line, Field: "materials." + name + "." + field, Message: fmt.Sprintf("undefined texture %q", texture)}
+			}
+		}
+	}
+
+	// Objects are validated after all materials are known, materials can be declared after the objects
+	for i := 0; i < len(description.Objects); i++ {
+		var object = description.Objects[i]
+		if _, ok := description.Materials[object.Material]; !ok && object.Material != "" {
+			return nil, &Error{Line: object.line, Field: fmt.Sprintf("objects[%d].material", i), Message: fmt.Sprintf("undefined material %q", object.Material)}
+		}
+	}
+
+	if description.Atmosphere != "" {
+		var m, ok = description.Materials[description.Atmosphere]
+		if !ok {
+			return nil, &Error{Line: description.atmosphereLine, Field: "atmosphere", Message: fmt.Sprintf("undefined material %q", description.Atmosphere)}
+		}
+		if m.Type != "volume" {
+			return nil, &Error{Line: description.atmosphereLine, Field: "atmosphere", Message: fmt.Sprintf("material %q is not a volume material", description.Atmosphere)}
+		}
+	}
+
+	return description, nil
+}
+
+// Read the named materials object.
+func (p *parser) materials() (map[string]*MaterialDescription, error) {
+	var materials = map[string]*MaterialDescription{}
+
+	var err = p.expectDelim('{', "materials")
+	if err != nil {
+		return nil, err
+	}
+
+	for p.decoder.More() {
+		var name string
+		name, err = p.key("materials")
+		if err != nil {
+			return nil, err
+		}
+
+		var field = "materials." + name
+		var line = p.line(p.decoder.InputOffset())
+
+		if _, ok := materials[name]; ok {
+			return nil, &Error{Line: line, Field: field, Message: "duplicated material name"}
+		}
+
+		var material = new(MaterialDescription)
+		err = p.value(material, field)
+		if err != nil {
+			return nil, err
+		}
+
+		material.line = line
+		err = material.validate(line, field)
+		if err != nil {
+			return nil, err
+		}
+
+		materials[name] = material
+	}
+
+	return materials, p.expectDelim('}', "materials")
+}
+
+// Read the named textures object.
+func (p *parser) textures() (map[string]*TextureDescription, error) {
+	var textures = map[string]*TextureDescription{}
+
+	var err = p.expectDelim('{', "textures")
+	if err != nil {
+		return nil, err
+	}
+
+	for p.decoder.More() {
+		var name string
+		name, err = p.key("textures")
+		if err != nil {
+			return nil, err
+		}
+
+		var field = "textures." + name
+		var line = p.line(p.decoder.InputOffset())
+
+		if _, ok := textures[name]; ok {
+			return nil, &Error{Line: line, Field: field, Message: "duplicated texture name"}
+		}
+
+		var texture = new(TextureDescription)
+		err = p.value(texture, field)
+		if err != nil {
+			return nil, err
+		}
+
+		texture.line = line
+		err = texture.validate(line, field)
+		if err != nil {
+			return nil, err
+		}
+
+		textures[name] = texture
+	}
+
+	return textures, p.expectDelim('}', "textures")
+}
+
+// Read the list of objects.
+func (p *parser) objects() ([]*ObjectDescription, error) {
+	var objects []*ObjectDescription
+
+	var err = p.expectDelim('[', "objects")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; p.decoder.More(); i++ {
+		var field = fmt.Sprintf("objects[%d]", i)
+		var line = p.line(p.decoder.InputOffset())
+
+		var object = new(ObjectDescription)
+		err = p.value(object, field)
+		if err != nil {
+			return nil, err
+		}
+
+		object.line = line
+		err = object.validate(line, field)
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, object)
+	}
+
+	return objects, p.expectDelim(']', "objects")
+}
+
+// Read the list of lights.
+func (p *parser) lights() ([]*LightDescription, error) {
+	var lights []*LightDescription
+
+	var err = p.expectDelim('[', "lights")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; p.decoder.More(); i++ {
+		var field = fmt.Sprintf("lights[%d]", i)
+		var line = p.line(p.decoder.InputOffset())
+
+		var light = new(LightDescription)
+		err = p.value(light, field)
+		if err != nil {
+			return nil, err
+		}
+
+		err = light.validate(line, field)
+		if err != nil {
+			return nil, err
+		}
+
+		lights = append(lights, light)
+	}
+
+	return lights, p.expectDelim(']', "lights")
+}
+
+// Read the next token and check if it is the expected delimiter.
+func (p *parser) expectDelim(delim json.Delim, field string) error {
+	var offset = p.decoder.InputOffset()
+	var token, err = p.decoder.Token()
+	if err != nil {
+		return p.wrap(err, 0, field)
+	}
+
+	if d, ok := token.(json.Delim); !ok || d != delim {
+		return &Error{Line: p.line(offset), Field: field, Message: fmt.Sprintf("expected %q", string(delim))}
+	}
+
+	return nil
+}
+
+// Check that only whitespace is left after the end of the top level object.
+func (p *parser) end() error {
+	for offset := p.decoder.InputOffset(); offset < int64(len(p.data)); offset++ {
+		var c = p.data[offset]
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			return &Error{Line: p.line(offset), Message: "unexpected data after the end of the scene"}
+		}
+	}
+
+	return nil
+}
+
+// Read the next object key.
+func (p *parser) key(field string) (string, error) {
+	var offset = p.decoder.InputOffset()
+	var token, err = p.decoder.Token()
+	if err != nil {
+		return "", p.wrap(err, 0, field)
+	}
+
+	var key, ok = token.(string)
+	if !ok {
+		return "", &Error{Line: p.line(offset), Field: field, Message: "expected a object key"}
+	}
+
+	return key, nil
+}
+
+// Decode the next value into the target, unknown fields are reported as errors.
+func (p *parser) value(target interface{}, field string) error {
+	var offset = p.skip(p.decoder.InputOffset())
+
+	// Errors from the file decoder have offsets relative to the start of the file
+	var raw json.RawMessage
+	var err = p.decoder.Decode(&raw)
+	if err != nil {
+		return p.wrap(err, 0, field)
+	}
+
+	var decoder = json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(target)
+	if err != nil {
+		return p.wrap(err, offset, field)
+	}
+
+	return nil
+}
+
+// Convert errors from the JSON decoder into errors with line and field information.
+// The base offset is the position in the file where the value being decoded starts.
+func (p *parser) wrap(err error, base int64, field string) error {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+
+	if errors.As(err, &syntaxError) {
+		return &Error{Line: p.line(base + syntaxError.Offset), Field: field, Message: syntaxError.Error()}
+	}
+
+	if errors.As(err, &typeError) {
+		return &Error{Line: p.line(base + typeError.Offset), Field: joinField(field, typeError.Field), Message: "expected " + typeError.Type.String() + " got " + typeError.Value}
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return &Error{Line: p.line(int64(len(p.data))), Field: field, Message: "unexpected end of file"}
+	}
+
+	// Unknown fields are reported by the decoder as a plain error
+	var message = err.Error()
+	if strings.HasPrefix(message, "json: unknown field ") {
+		var name = strings.Trim(strings.TrimPrefix(message, "json: unknown field "), "\"")
+		return &Error{Line: p.line(p.findKey(base, name)), Field: joinField(field, name), Message: "unknown field"}
+	}
+
+	return &Error{Line: p.line(base), Field: field, Message: message}
+}
+
+// Skip whitespace and separators to find the start of the next value.
+func (p *parser) skip(offset int64) int64 {
+	for offset < int64(len(p.data)) {
+		var c = p.data[offset]
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' && c != ':' && c != ',' {
+			break
+		}
+		offset++
+	}
+
+	return offset
+}
+
+// Find the first object key with the name after the offset, used to report the line of a field inside of a value.
+// Returns the offset provided if the key is not found.
+func (p *parser) findKey(offset int64, name string) int64 {
+	var quoted, _ = json.Marshal(name)
+
+	for start := offset; start < int64(len(p.data)); {
+		var index = bytes.Index(p.data[start:], quoted)
+		if index < 0 {
+			break
+		}
+
+		// Strings are only keys if they are followed by a colon
+		var end = start + int64(index+len(quoted))
+		for end < int64(len(p.data)) && (p.data[end] == ' ' || p.data[end] == '\t' || p.data[end] == '\r' || p.data[end] == '\n') {
+			end++
+		}
+		if end < int64(len(p.data)) && p.data[end] == ':' {
+			return start + int64(index)
+		}
+
+		start = end
+	}
+
+	return offset
+}
+
+// Get the line number of a byte offset in the file.
+func (p *parser) line(offset int64) int {
+	offset = p.skip(offset)
+	if offset > int64(len(p.data)) {
+		offset = int64(len(p.data))
+	}
+
+	return bytes.Count(p.data[:offset], []byte{'\n'}) + 1
+}
+
+// Join the path of a parent field with a child field.
+func joinField(parent string, child string) string {
+	if child == "" {
+		return parent
+	}
+	if parent == "" {
+		return child
+	}
+	return parent + "." + child
+}
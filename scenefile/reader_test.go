@@ -0,0 +1,180 @@
+package scenefile
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Build a scene file with the materials and objects provided, one per line.
+// The version is in line 2, the materials start in line 4 and the objects start after them.
+func testScene(materials []string, objects []string) string {
+	return "{\n" +
+		"\"version\": 1,\n" +
+		"\"materials\": {\n" + strings.Join(materials, ",\n") + "\n},\n" +
+		"\"objects\": [\n" + strings.Join(objects, ",\n") + "\n]\n" +
+		"}\n"
+}
+
+var testMaterials = []string{
+	`"red": {"type": "lambert", "albedo": [0.8, 0.1, 0.1]}`,
+	`"mirror": {"type": "metal", "albedo": [0.9, 0.9, 0.9], "fuzz": 0.1}`,
+}
+
+func TestParse(t *testing.T) {
+	var data = testScene(testMaterials, []string{
+		`{"type": "sphere", "center": [0, 1, 0], "radius": 1, "material": "red"}`,
+		`{"type": "box", "min": [0, 0, 0], "max": [1, 1, 1], "material": "mirror", "transform": {"translate": [1, 0, 0], "rotate": [0, 45, 0]}}`,
+	})
+
+	var description, err = Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(description.Materials) != 2 || len(description.Objects) != 2 {
+		t.Fatalf("parsed %d materials and %d objects, expected 2 and 2", len(description.Materials), len(description.Objects))
+	}
+	if description.Materials["mirror"].Fuzz != 0.1 {
+		t.Errorf("fuzz is %f, expected 0.1", description.Materials["mirror"].Fuzz)
+	}
+	if description.Objects[1].Material != "mirror" {
+		t.Errorf("material of the box is %q, expected \"mirror\"", description.Objects[1].Material)
+	}
+	if description.Objects[1].Transform == nil || description.Objects[1].Transform.Rotate[1] != 45 {
+		t.Errorf("transform of the box was not parsed")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	var tests = []struct {
+		name    string
+		data    string
+		line    int
+		field   string
+		message string
+	}{
+		{
+			"version",
+			strings.Replace(testScene(testMaterials, nil), `"version": 1`, `"version": 2`, 1),
+			2, "version", "unsupported version 2",
+		},
+		{
+			"missing version",
+			strings.Replace(testScene(testMaterials, nil), "\"version\": 1,\n", "\n", 1),
+			1, "version", "missing version",
+		},
+		{
+			"unknown field",
+			strings.Replace(testScene(testMaterials, nil), `"version": 1,`, `"version": 1, "scale": 2,`, 1),
+			2, "scale", "unknown field",
+		},
+		{
+			"unknown field in a value",
+			testScene([]string{"\"red\": {\"type\": \"lambert\",\n\"albedo\": [0.8, 0.1, 0.1],\n\"shininess\": 2}"}, nil),
+			6, "materials.red.shininess", "unknown field",
+		},
+		{
+			"duplicated field",
+			strings.Replace(testScene(testMaterials, nil), "\"version\": 1,\n", "\"version\": 1,\n\"version\": 1,\n", 1),
+			3, "version", "duplicated field, already defined in line 2",
+		},
+		{
+			"trailing data",
+			testScene(testMaterials, nil) + "garbage",
+			11, "", "unexpected data after the end of the scene",
+		},
+		{
+			"material type",
+			testScene([]string{`"red": {"type": "plastic"}`}, nil),
+			4, "materials.red.type", `unknown material type "plastic"`,
+		},
+		{
+			"color size",
+			testScene([]string{`"red": {"type": "lambert", "albedo": [0.8, 0.1]}`}, nil),
+			4, "materials.red.albedo", "expected 3 values got 2",
+		},
+		{
+			"sphere radius",
+			testScene(testMaterials, []string{`{"type": "sphere", "center": [0, 0, 0], "radius": 1, "material": "red"}`, `{"type": "sphere", "center": [0, 0, 0], "radius": -1, "material": "red"}`}),
+			9, "objects[1].radius", "must be greater than zero",
+		},
+		{
+			"mesh file",
+			testScene(testMaterials, []string{`{"type": "mesh", "material": "red"}`}),
+			8, "objects[0].file", "required field is missing",
+		},
+		{
+			"triangle normals",
+			testScene(testMaterials, []string{`{"type": "triangle", "a": [0, 0, 0], "b": [1, 0, 0], "c": [0, 1, 0], "normals": [[0, 0, 1], [0, 0, 1]], "material": "red"}`}),
+			8, "objects[0].normals", "expected 3 normals got 2",
+		},
+		{
+			"triangle texture coordinates",
+			testScene(testMaterials, []string{`{"type": "triangle", "a": [0, 0, 0], "b": [1, 0, 0], "c": [0, 1, 0], "uvs": [[0, 0], [1, 0], [0, 1, 0]], "material": "red"}`}),
+			8, "objects[0].uvs[2]", "expected 2 values got 3",
+		},
+		{
+			"volume density",
+			testScene([]string{testMaterials[0], `"fog": {"type": "volume", "albedo": [1, 1, 1], "density": 0}`}, nil),
+			5, "materials.fog.density", "must be greater than zero",
+		},
+		{
+			"undefined material",
+			testScene(testMaterials, []string{`{"type": "sphere", "center": [0, 0, 0], "radius": 1, "material": "blue"}`}),
+			8, "objects[0].material", `undefined material "blue"`,
+		},
+		{
+			"rectangle light edges",
+			strings.Replace(testScene(testMaterials, nil), `"version": 1,`, `"version": 1, "lights": [{"type": "rectangle", "corner": [0, 2, 0], "u": [1, 0, 0], "v": [-2, 0, 0], "color": [1, 1, 1], "intensity": 1}],`, 1),
+			2, "lights[0].v", "must not be parallel to u",
+		},
+		{
+			"rectangle light without area",
+			strings.Replace(testScene(testMaterials, nil), `"version": 1,`, `"version": 1, "lights": [{"type": "rectangle", "corner": [0, 2, 0], "u": [0, 0, 0], "v": [0, 0, 1], "color": [1, 1, 1], "intensity": 1}],`, 1),
+			2, "lights[0].u", "must not be zero",
+		},
+		{
+			"spot light direction",
+			strings.Replace(testScene(testMaterials, nil), `"version": 1,`, `"version": 1, "lights": [{"type": "spot", "position": [0, 2, 0], "direction": [0, 0, 0], "angle": 30, "color": [1, 1, 1], "intensity": 1}],`, 1),
+			2, "lights[0].direction", "must not be zero",
+		},
+		{
+			"directional light direction",
+			strings.Replace(testScene(testMaterials, nil), `"version": 1,`, `"version": 1, "lights": [{"type": "point", "position": [0, 2, 0], "color": [1, 1, 1], "intensity": 1}, {"type": "directional", "direction": [0, 0, 0], "color": [1, 1, 1], "intensity": 1}],`, 1),
+			2, "lights[1].direction", "must not be zero",
+		},
+		{
+			"atmosphere",
+			strings.Replace(testScene(testMaterials, nil), `"version": 1,`, `"version": 1, "atmosphere": "red",`, 1),
+			2, "atmosphere", `material "red" is not a volume material`,
+		},
+		{
+			"atmosphere radius",
+			strings.Replace(testScene(testMaterials, nil), `"version": 1,`, `"version": 1, "atmosphereRadius": -5,`, 1),
+			2, "atmosphereRadius", "must be greater than zero",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var _, err = Parse([]byte(test.data))
+
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("expected a *Error, got %v", err)
+			}
+			if e.Line != test.line || e.Field != test.field || !strings.Contains(e.Message, test.message) {
+				t.Errorf("got %q, expected line %d: %s: %s", e.Error(), test.line, test.field, test.message)
+			}
+		})
+	}
+}
+
+// Whitespace after the end of the scene is allowed.
+func TestParseTrailingWhitespace(t *testing.T) {
+	var _, err = Parse([]byte(testScene(testMaterials, nil) + "\n\t \r\n"))
+	if err != nil {
+		t.Error(err)
+	}
+}
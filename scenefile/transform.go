@@ -0,0 +1,28 @@
+package scenefile
+
+import (
+	"gotracer/vmath"
+	"math"
+)
+
+// Create the transform matrix of the description.
+func (t *TransformDescription) matrix() *vmath.Matrix4 {
+	var m = vmath.NewIdentityMatrix4()
+
+	if t.Matrix != nil {
+		copy(m.Values[:], t.Matrix)
+	} else {
+		if t.Translate != nil {
+			m.Multiply(vmath.NewTranslationMatrix4(t.Translate[0], t.Translate[1], t.Translate[2]))
+		}
+		if t.Rotate != nil {
+			var rotation = vmath.NewQuaternionFromEuler(t.Rotate[0]*math.Pi/180.0, t.Rotate[1]*math.Pi/180.0, t.Rotate[2]*math.Pi/180.0)
+			m.Multiply(rotation.ToMatrix4())
+		}
+		if t.Scale != nil {
+			m.Multiply(vmath.NewScaleMatrix4(t.Scale[0], t.Scale[1], t.Scale[2]))
+		}
+	}
+
+	return m
+}
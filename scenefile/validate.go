@@ -0,0 +1,449 @@
+package scenefile
+
+import (
+	"fmt"
+	"gotracer/material"
+	"gotracer/spectrum"
+	"strings"
+)
+
+// Check if the vector field has exactly three values.
+func validateVector(v []float64, line int, field string, name string) error {
+	if v == nil {
+		return &Error{Line: line, Field: joinField(field, name), Message: "required field is missing"}
+	}
+	if len(v) != 3 {
+		return &Error{Line: line, Field: joinField(field, name), Message: fmt.Sprintf("expected 3 values got %d", len(v))}
+	}
+	return nil
+}
+
+// Check if a direction vector is present, has three values and is not zero.
+func validateDirection(v []float64, line int, field string, name string) error {
+	var err = validateVector(v, line, field, name)
+	if err != nil {
+		return err
+	}
+	if vector(v).SquaredLength() == 0 {
+		return &Error{Line: line, Field: joinField(field, name), Message: "must not be zero"}
+	}
+	return nil
+}
+
+// Check if a color parameter has either a color or a texture.
+func validateColor(v []float64, texture string, line int, field string, name string) error {
+	if texture != "" {
+		if v != nil {
+			return &Error{Line: line, Field: joinField(field, name), Message: "cannot be used together with " + name + "Texture"}
+		}
+		return nil
+	}
+
+	return validateVector(v, line, field, name)
+}
+
+// Validate the camera description.
+func (c *CameraDescription) validate(line int, field string) error {
+	var err = validateVector(c.Position, line, field, "position")
+	if err != nil {
+		return err
+	}
+
+	err = validateVector(c.LookAt, line, field, "lookAt")
+	if err != nil {
+		return err
+	}
+
+	if c.Up != nil {
+		err = validateVector(c.Up, line, field, "up")
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.Fov <= 0 || c.Fov >= 180 {
+		return &Error{Line: line, Field: joinField(field, "fov"), Message: "must be between 0 and 180 degrees"}
+	}
+	if c.Aperture < 0 {
+		return &Error{Line: line, Field: joinField(field, "aperture"), Message: "must not be negative"}
+	}
+	if c.FocusDistance < 0 {
+		return &Error{Line: line, Field: joinField(field, "focusDistance"), Message: "must not be negative"}
+	}
+
+	return nil
+}
+
+// Validate the material description, checks if the fields required by the material type are present.
+func (m *MaterialDescription) validate(line int, field string) error {
+	switch m.Type {
+	case "lambert", "metal":
+		return validateColor(m.Albedo, m.AlbedoTexture, line, field, "albedo")
+	case "dielectric":
+		return m.validateDielectric(line, field)
+	case "conductor":
+		return m.validateConductor(line, field)
+	case "principled":
+		return m.validatePrincipled(line, field)
+	case "volume":
+		if m.Density <= 0 {
+			return &Error{Line: line, Field: joinField(field, "density"), Message: "must be greater than zero"}
+		}
+		if m.Anisotropy <= -1 || m.Anisotropy >= 1 {
+			return &Error{Line: line, Field: joinField(field, "anisotropy"), Message: "must be between -1 and 1 (exclusive)"}
+		}
+		return validateColor(m.Albedo, m.AlbedoTexture, line, field, "albedo")
+	case "light":
+		if m.Intensity < 0 {
+			return &Error{Line: line, Field: joinField(field, "intensity"), Message: "must not be negative"}
+		}
+		return validateColor(m.Color, m.ColorTexture, line, field, "color")
+	case "normal":
+		return nil
+	case "":
+		return &Error{Line: line, Field: joinField(field, "type"), Message: "required field is missing"}
+	}
+
+	return &Error{Line: line, Field: joinField(field, "type"), Message: fmt.Sprintf("unknown material type %q", m.Type)}
+}
+
+// Validate the dielectric material fields, the refractive indice can be omitted if a dispersion is used.
+func (m *MaterialDescription) validateDielectric(line int, field string) error {
+	var dispersive = m.Dispersion != "" || m.Cauchy != nil || m.Sellmeier != nil
+
+	if m.RefractiveIndice < 0 || (m.RefractiveIndice == 0 && !dispersive) {
+		return &Error{Line: line, Field: joinField(field, "refractiveIndice"), Message: "must be greater than zero"}
+	}
+	if m.Roughness < 0 || m.Roughness > 1 {
+		return &Error{Line: line, Field: joinField(field, "roughness"), Message: "must be between 0 and 1"}
+	}
+
+	var err = m.validateDispersion(line, field)
+	if err != nil {
+		return err
+	}
+
+	if m.AbsorptionColor != nil {
+		err = validateVector(m.AbsorptionColor, line, field, "absorptionColor")
+		if err != nil {
+			return err
+		}
+	}
+	if m.AbsorptionDistance < 0 {
+		return &Error{Line: line, Field: joinField(field, "absorptionDistance"), Message: "must not be negative"}
+	}
+
+	return validateColor(m.Albedo, m.AlbedoTexture, line, field, "albedo")
+}
+
+// Validate the dispersion of a dielectric material, only one of the preset, Cauchy and Sellmeier fields can be used.
+func (m *MaterialDescription) validateDispersion(line int, field string) error {
+	var count = 0
+	var name string
+
+	if m.Dispersion != "" {
+		count++
+		name = "dispersion"
+		if _, ok := spectrum.DispersionPresets[m.Dispersion]; !ok {
+			return &Error{Line: line, Field: joinField(field, "dispersion"), Message: fmt.Sprintf("unknown preset %q, expected one of %s", m.Dispersion, strings.Join(spectrum.DispersionPresetNames(), ", "))}
+		}
+	}
+	if m.Cauchy != nil {
+		count++
+		name = "cauchy"
+		if len(m.Cauchy) != 2 {
+			return &Error{Line: line, Field: joinField(field, "cauchy"), Message: fmt.Sprintf("expected 2 values got %d", len(m.Cauchy))}
+		}
+	}
+	if m.Sellmeier != nil {
+		count++
+		name = "sellmeier"
+		if len(m.Sellmeier) != 6 {
+			return &Error{Line: line, Field: joinField(field, "sellmeier"), Message: fmt.Sprintf("expected 6 values got %d", len(m.Sellmeier))}
+		}
+	}
+
+	if count > 1 {
+		return &Error{Line: line, Field: joinField(field, name), Message: "only one of dispersion, cauchy and sellmeier can be used"}
+	}
+	if count > 0 && m.Roughness > 0 {
+		return &Error{Line: line, Field: joinField(field, name), Message: "is only supported by smooth dielectrics"}
+	}
+
+	return nil
+}
+
+// Validate the conductor material fields, the complex refractive indice comes either from a preset or from the eta and k fields.
+func (m *MaterialDescription) validateConductor(line int, field string) error {
+	if m.Preset != "" {
+		if _, ok := material.ConductorPresets[m.Preset]; !ok {
+			return &Error{Line: line, Field: joinField(field, "preset"), Message: fmt.Sprintf("unknown preset %q, expected one of %s", m.Preset, strings.Join(material.ConductorPresetNames(), ", "))}
+		}
+		if m.Eta != nil || m.K != nil {
+			return &Error{Line: line, Field: joinField(field, "preset"), Message: "cannot be used together with eta and k"}
+		}
+	} else {
+		var err = validateVector(m.Eta, line, field, "eta")
+		if err != nil {
+			return err
+		}
+
+		err = validateVector(m.K, line, field, "k")
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.Roughness < 0 || m.Roughness > 1 {
+		return &Error{Line: line, Field: joinField(field, "roughness"), Message: "must be between 0 and 1"}
+	}
+	if m.Anisotropy < 0 || m.Anisotropy > 1 {
+		return &Error{Line: line, Field: joinField(field, "anisotropy"), Message: "must be between 0 and 1"}
+	}
+
+	return nil
+}
+
+// Validate the principled material fields, the parameters must be in the range [0, 1] unless they are replaced by a texture.
+func (m *MaterialDescription) validatePrincipled(line int, field string) error {
+	var err = validateColor(m.Albedo, m.AlbedoTexture, line, field, "albedo")
+	if err != nil {
+		return err
+	}
+
+	var parameters = []struct {
+		name    string
+		value   float64
+		texture string
+	}{
+		{"metallic", m.Metallic, m.MetallicTexture},
+		{"roughness", m.Roughness, m.RoughnessTexture},
+		{"specular", defaultValue(m.Specular, 0.5), m.SpecularTexture},
+		{"specularTint", m.SpecularTint, m.SpecularTintTexture},
+		{"sheen", m.Sheen, m.SheenTexture},
+		{"sheenTint", defaultValue(m.SheenTint, 0.5), m.SheenTintTexture},
+		{"clearcoat", m.Clearcoat, m.ClearcoatTexture},
+		{"clearcoatGloss", defaultValue(m.ClearcoatGloss, 1.0), m.ClearcoatGlossTexture},
+		{"transmission", m.Transmission, m.TransmissionTexture},
+	}
+
+	for _, p := range parameters {
+		if p.value < 0 || p.value > 1 {
+			return &Error{Line: line, Field: joinField(field, p.name), Message: "must be between 0 and 1"}
+		}
+	}
+
+	if m.RefractiveIndice < 0 {
+		return &Error{Line: line, Field: joinField(field, "refractiveIndice"), Message: "must not be negative"}
+	}
+
+	return nil
+}
+
+// Validate the object description, checks if the fields required by the object type are present.
+func (o *ObjectDescription) validate(line int, field string) error {
+	if o.Material == "" && o.Type != "mesh" {
+		return &Error{Line: line, Field: joinField(field, "material"), Message: "required field is missing"}
+	}
+
+	if _, ok := cullModes[o.Cull]; !ok {
+		return &Error{Line: line, Field: joinField(field, "cull"), Message: fmt.Sprintf("unknown cull mode %q", o.Cull)}
+	}
+
+	if o.Transform != nil {
+		var err = o.Transform.validate(line, joinField(field, "transform"))
+		if err != nil {
+			return err
+		}
+	}
+
+	switch o.Type {
+	case "sphere":
+		if o.Radius <= 0 {
+			return &Error{Line: line, Field: joinField(field, "radius"), Message: "must be greater than zero"}
+		}
+		return validateVector(o.Center, line, field, "center")
+	case "box":
+		var err = validateVector(o.Min, line, field, "min")
+		if err != nil {
+			return err
+		}
+		return validateVector(o.Max, line, field, "max")
+	case "triangle":
+		var err = validateVector(o.A, line, field, "a")
+		if err != nil {
+			return err
+		}
+		err = validateVector(o.B, line, field, "b")
+		if err != nil {
+			return err
+		}
+		err = validateVector(o.C, line, field, "c")
+		if err != nil {
+			return err
+		}
+		return o.validateVertexAttributes(line, field)
+	case "mesh":
+		if o.CreaseAngle < 0 || o.CreaseAngle > 180 {
+			return &Error{Line: line, Field: joinField(field, "creaseAngle"), Message: "must be between 0 and 180"}
+		}
+		if o.File == "" {
+			return &Error{Line: line, Field: joinField(field, "file"), Message: "required field is missing"}
+		}
+		return nil
+	case "":
+		return &Error{Line: line, Field: joinField(field, "type"), Message: "required field is missing"}
+	}
+
+	return &Error{Line: line, Field: joinField(field, "type"), Message: fmt.Sprintf("unknown object type %q", o.Type)}
+}
+
+// Validate the optional normals and texture coordinates of the vertices of a triangle.
+func (o *ObjectDescription) validateVertexAttributes(line int, field string) error {
+	if o.Normals != nil {
+		if len(o.Normals) != 3 {
+			return &Error{Line: line, Field: joinField(field, "normals"), Message: fmt.Sprintf("expected 3 normals got %d", len(o.Normals))}
+		}
+		for i := 0; i < 3; i++ {
+			var err = validateVector(o.Normals[i], line, field, fmt.Sprintf("normals[%d]", i))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if o.UVs != nil {
+		if len(o.UVs) != 3 {
+			return &Error{Line: line, Field: joinField(field, "uvs"), Message: fmt.Sprintf("expected 3 texture coordinates got %d", len(o.UVs))}
+		}
+		for i := 0; i < 3; i++ {
+			if len(o.UVs[i]) != 2 {
+				return &Error{Line: line, Field: joinField(field, fmt.Sprintf("uvs[%d]", i)), Message: fmt.Sprintf("expected 2 values got %d", len(o.UVs[i]))}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Validate the light description, checks if the fields required by the light type are present.
+func (l *LightDescription) validate(line int, field string) error {
+	var err = validateVector(l.Color, line, field, "color")
+	if err != nil {
+		return err
+	}
+
+	if l.Intensity < 0 {
+		return &Error{Line: line, Field: joinField(field, "intensity"), Message: "must not be negative"}
+	}
+
+	switch l.Type {
+	case "point":
+		return validateVector(l.Position, line, field, "position")
+	case "spot":
+		err = validateVector(l.Position, line, field, "position")
+		if err != nil {
+			return err
+		}
+		if l.Angle <= 0 || l.Angle > 180 {
+			return &Error{Line: line, Field: joinField(field, "angle"), Message: "must be between 0 and 180 degrees"}
+		}
+		if l.FalloffAngle < 0 || l.FalloffAngle > l.Angle {
+			return &Error{Line: line, Field: joinField(field, "falloffAngle"), Message: "must be between 0 and the angle of the light"}
+		}
+		return validateDirection(l.Direction, line, field, "direction")
+	case "directional":
+		return validateDirection(l.Direction, line, field, "direction")
+	case "sphere":
+		if l.Radius <= 0 {
+			return &Error{Line: line, Field: joinField(field, "radius"), Message: "must be greater than zero"}
+		}
+		return validateVector(l.Center, line, field, "center")
+	case "rectangle":
+		err = validateVector(l.Corner, line, field, "corner")
+		if err != nil {
+			return err
+		}
+		err = validateDirection(l.U, line, field, "u")
+		if err != nil {
+			return err
+		}
+		err = validateDirection(l.V, line, field, "v")
+		if err != nil {
+			return err
+		}
+
+		// Parallel edges make a rectangle without area
+		if vector(l.U).Cross(vector(l.V)).SquaredLength() == 0 {
+			return &Error{Line: line, Field: joinField(field, "v"), Message: "must not be parallel to u"}
+		}
+		return nil
+	case "":
+		return &Error{Line: line, Field: joinField(field, "type"), Message: "required field is missing"}
+	}
+
+	return &Error{Line: line, Field: joinField(field, "type"), Message: fmt.Sprintf("unknown light type %q", l.Type)}
+}
+
+// Validate the texture description, checks if the fields required by the texture type are present.
+func (t *TextureDescription) validate(line int, field string) error {
+	switch t.Type {
+	case "solid":
+		return validateVector(t.Color, line, field, "color")
+	case "checker":
+		if t.Size <= 0 {
+			return &Error{Line: line, Field: joinField(field, "size"), Message: "must be greater than zero"}
+		}
+		var err = validateVector(t.Even, line, field, "even")
+		if err != nil {
+			return err
+		}
+		return validateVector(t.Odd, line, field, "odd")
+	case "image":
+		if t.File == "" {
+			return &Error{Line: line, Field: joinField(field, "file"), Message: "required field is missing"}
+		}
+		if _, ok := wrapModes[t.WrapU]; !ok {
+			return &Error{Line: line, Field: joinField(field, "wrapU"), Message: fmt.Sprintf("unknown wrap mode %q", t.WrapU)}
+		}
+		if _, ok := wrapModes[t.WrapV]; !ok {
+			return &Error{Line: line, Field: joinField(field, "wrapV"), Message: fmt.Sprintf("unknown wrap mode %q", t.WrapV)}
+		}
+		return nil
+	case "noise":
+		if t.Scale <= 0 {
+			return &Error{Line: line, Field: joinField(field, "scale"), Message: "must be greater than zero"}
+		}
+		if t.Octaves <= 0 {
+			return &Error{Line: line, Field: joinField(field, "octaves"), Message: "must be greater than zero"}
+		}
+		return validateVector(t.Color, line, field, "color")
+	case "":
+		return &Error{Line: line, Field: joinField(field, "type"), Message: "required field is missing"}
+	}
+
+	return &Error{Line: line, Field: joinField(field, "type"), Message: fmt.Sprintf("unknown texture type %q", t.Type)}
+}
+
+// Validate the transform description.
+func (t *TransformDescription) validate(line int, field string) error {
+	if t.Matrix != nil {
+		if t.Translate != nil || t.Rotate != nil || t.Scale != nil {
+			return &Error{Line: line, Field: joinField(field, "matrix"), Message: "cannot be used together with translate, rotate or scale"}
+		}
+		if len(t.Matrix) != 16 {
+			return &Error{Line: line, Field: joinField(field, "matrix"), Message: "expected 16 values"}
+		}
+	}
+
+	for name, v := range map[string][]float64{"translate": t.Translate, "rotate": t.Rotate, "scale": t.Scale} {
+		if v != nil {
+			var err = validateVector(v, line, field, name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
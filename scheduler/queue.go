@@ -0,0 +1,47 @@
+package scheduler
+
+import "sync/atomic"
+
+// Queue of tiles shared by the render workers.
+//
+// Workers pull the next tile when they finish the previous one, so a worker that gets cheap tiles renders more of them
+// and the load is balanced without knowing the cost of each tile in advance. Tiles are handed out in the order of the list.
+type Queue struct {
+	tiles    []Tile
+	next     atomic.Int64
+	finished atomic.Int64
+}
+
+// Create a new queue with a list of tiles.
+func NewQueue(tiles []Tile) *Queue {
+	var q = new(Queue)
+	q.tiles = tiles
+	return q
+}
+
+// Get the next tile to render, returns false when all the tiles were taken.
+// Safe to call from multiple goroutines.
+func (q *Queue) Next() (Tile, bool) {
+	var i = q.next.Add(1) - 1
+	if i >= int64(len(q.tiles)) {
+		return Tile{}, false
+	}
+
+	return q.tiles[i], true
+}
+
+// Mark a tile taken from the queue as finished.
+// Safe to call from multiple goroutines.
+func (q *Queue) Finish() {
+	q.finished.Add(1)
+}
+
+// Number of tiles marked as finished.
+func (q *Queue) Finished() int {
+	return int(q.finished.Load())
+}
+
+// Number of tiles in the queue, including the ones already taken.
+func (q *Queue) Len() int {
+	return len(q.tiles)
+}
@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Tile is a rectangular region of the image rendered as a unit of work.
+// The tile contains the pixels from (X, Y) to (X + Width, Y + Height), not inclusive.
+type Tile struct {
+	X      int
+	Y      int
+	Width  int
+	Height int
+}
+
+// Names of the available tile orderings.
+var OrderNames = []string{"scanline", "spiral", "hilbert"}
+
+// Split the image in tiles of the size in pixels, the tiles in the borders are smaller if the image size is not a multiple of the tile size.
+//
+// The order defines the sequence in which tiles are rendered:
+// scanline goes row by row, spiral starts in the center of the image and hilbert follows a Hilbert curve that keeps consecutive tiles close.
+func Tiles(width int, height int, size int, order string) ([]Tile, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("scheduler: tile size must be greater than zero")
+	}
+
+	var columns = (width + size - 1) / size
+	var rows = (height + size - 1) / size
+
+	var tiles = make([]Tile, 0, columns*rows)
+	var keys = make([]int, 0, columns*rows)
+
+	var key func(column int, row int) int
+
+	switch order {
+	case "scanline", "":
+		key = func(column int, row int) int {
+			return row*columns + column
+		}
+	case "spiral":
+		var ranks = spiral(columns, rows)
+		key = func(column int, row int) int {
+			return ranks[row*columns+column]
+		}
+	case "hilbert":
+		var n = 1
+		for n < columns || n < rows {
+			n *= 2
+		}
+		key = func(column int, row int) int {
+			return hilbert(n, column, row)
+		}
+	default:
+		return nil, fmt.Errorf("scheduler: unknown tile order %q", order)
+	}
+
+	for row := 0; row < rows; row++ {
+		for column := 0; column < columns; column++ {
+			var x = column * size
+			var y = row * size
+			tiles = append(tiles, Tile{X: x, Y: y, Width: min(size, width-x), Height: min(size, height-y)})
+			keys = append(keys, key(column, row))
+		}
+	}
+
+	sort.Sort(&byKey{tiles: tiles, keys: keys})
+
+	return tiles, nil
+}
+
+// Sort the tiles by a key of each tile.
+type byKey struct {
+	tiles []Tile
+	keys  []int
+}
+
+func (s *byKey) Len() int {
+	return len(s.tiles)
+}
+
+func (s *byKey) Less(i int, j int) bool {
+	return s.keys[i] < s.keys[j]
+}
+
+func (s *byKey) Swap(i int, j int) {
+	s.tiles[i], s.tiles[j] = s.tiles[j], s.tiles[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// Position of each tile of a grid in a square spiral that starts in the center of the grid.
+func spiral(columns int, rows int) []int {
+	var ranks = make([]int, columns*rows)
+	var count = 0
+
+	var x = (columns - 1) / 2
+	var y = (rows - 1) / 2
+	var dx, dy = 1, 0
+
+	var visit = func() {
+		if x >= 0 && x < columns && y >= 0 && y < rows {
+			ranks[y*columns+x] = count
+			count++
+		}
+	}
+
+	visit()
+
+	// Walk segments of length 1, 1, 2, 2, 3, 3... turning after each one
+	for length := 1; count < len(ranks); length++ {
+		for turn := 0; turn < 2; turn++ {
+			for i := 0; i < length; i++ {
+				x += dx
+				y += dy
+				visit()
+			}
+			dx, dy = -dy, dx
+		}
+	}
+
+	return ranks
+}
+
+// Distance of a point along the Hilbert curve that fills a square grid of size n (power of two).
+func hilbert(n int, x int, y int) int {
+	var d = 0
+
+	for s := n / 2; s > 0; s /= 2 {
+		var rx, ry = 0, 0
+		if x&s != 0 {
+			rx = 1
+		}
+		if y&s != 0 {
+			ry = 1
+		}
+
+		d += s * s * ((3 * rx) ^ ry)
+
+		// Rotate the quadrant so the curve is continuous
+		if ry == 0 {
+			if rx == 1 {
+				x = s - 1 - x
+				y = s - 1 - y
+			}
+			x, y = y, x
+		}
+	}
+
+	return d
+}
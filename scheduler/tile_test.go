@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Image sizes and tile sizes tested, with square, non power of two and single row or column grids.
+var testGrids = []struct {
+	width  int
+	height int
+	size   int
+}{
+	{64, 64, 16},
+	{100, 70, 16},
+	{33, 33, 32},
+	{16, 160, 16},
+	{200, 10, 32},
+	{5, 300, 8},
+	{1, 1, 32},
+}
+
+// Every order visits each tile of the grid exactly once and the tiles cover every pixel exactly once.
+func TestTilesOrder(t *testing.T) {
+	for _, order := range OrderNames {
+		for _, grid := range testGrids {
+			t.Run(fmt.Sprintf("%s %dx%d tile %d", order, grid.width, grid.height, grid.size), func(t *testing.T) {
+				var tiles, err = Tiles(grid.width, grid.height, grid.size, order)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var columns = (grid.width + grid.size - 1) / grid.size
+				var rows = (grid.height + grid.size - 1) / grid.size
+				if len(tiles) != columns*rows {
+					t.Fatalf("got %d tiles, expected %d", len(tiles), columns*rows)
+				}
+
+				var visited = map[[2]int]bool{}
+				var coverage = make([]int, grid.width*grid.height)
+
+				for _, tile := range tiles {
+					var position = [2]int{tile.X, tile.Y}
+					if visited[position] {
+						t.Fatalf("tile at %d, %d visited more than once", tile.X, tile.Y)
+					}
+					visited[position] = true
+
+					for y := tile.Y; y < tile.Y+tile.Height; y++ {
+						for x := tile.X; x < tile.X+tile.Width; x++ {
+							coverage[y*grid.width+x]++
+						}
+					}
+				}
+
+				for i, count := range coverage {
+					if count != 1 {
+						t.Fatalf("pixel %d, %d covered by %d tiles", i%grid.width, i/grid.width, count)
+					}
+				}
+			})
+		}
+	}
+}
+
+// The spiral starts in the center tile of the grid.
+func TestTilesSpiral(t *testing.T) {
+	var tiles, err = Tiles(70, 50, 10, "spiral")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tiles[0].X != 30 || tiles[0].Y != 20 {
+		t.Errorf("spiral starts at %d, %d, expected the center tile 30, 20", tiles[0].X, tiles[0].Y)
+	}
+}
+
+// In a power of two grid consecutive tiles of the Hilbert curve are neighbors.
+func TestTilesHilbert(t *testing.T) {
+	var tiles, err = Tiles(128, 128, 16, "hilbert")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i < len(tiles); i++ {
+		var dx = tiles[i].X - tiles[i-1].X
+		var dy = tiles[i].Y - tiles[i-1].Y
+		if dx*dx+dy*dy != 16*16 {
+			t.Errorf("tile %d at %d, %d is not next to the previous tile at %d, %d", i, tiles[i].X, tiles[i].Y, tiles[i-1].X, tiles[i-1].Y)
+		}
+	}
+}
+
+func TestTilesErrors(t *testing.T) {
+	if _, err := Tiles(64, 64, 0, "scanline"); err == nil {
+		t.Errorf("tile size zero accepted")
+	}
+	if _, err := Tiles(64, 64, 16, "random"); err == nil {
+		t.Errorf("unknown order accepted")
+	}
+}
+
+// Tiles taken from the queue by multiple goroutines are handed out only once.
+func TestQueue(t *testing.T) {
+	var tiles, err = Tiles(300, 200, 8, "hilbert")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var queue = NewQueue(tiles)
+	var mutex sync.Mutex
+	var taken = map[Tile]int{}
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tile, ok := queue.Next(); ok; tile, ok = queue.Next() {
+				mutex.Lock()
+				taken[tile]++
+				mutex.Unlock()
+				queue.Finish()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(taken) != len(tiles) || queue.Finished() != queue.Len() {
+		t.Errorf("took %d different tiles and finished %d, expected %d", len(taken), queue.Finished(), len(tiles))
+	}
+	for tile, count := range taken {
+		if count != 1 {
+			t.Errorf("tile %+v taken %d times", tile, count)
+		}
+	}
+}
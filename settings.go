@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gotracer/sampler"
+	"gotracer/scheduler"
+	"gotracer/tonemap"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Prefix of the environment variables used to configure the renderer.
+// The variable name is the flag name in upper case with dashes replaced by underscores (e.g. GOTRACER_MAX_DEPTH).
+const SettingsEnvironmentPrefix = "GOTRACER_"
+
+// RenderSettings controls how a frame is rendered.
+//
+// Settings can be loaded from a JSON config file, environment variables and command line flags.
+// When multiple sources are used flags override environment variables that override the config file.
+type RenderSettings struct {
+	// Render size
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	// Scale applied to the rendered image when presented in the window.
+	Upscale float64 `json:"upscale"`
+
+	// Max raytracing recursive depth
+	MaxDepth int64 `json:"maxDepth"`
+
+	// Minimum distance to be considerd for ray collision
+	MinDistance float64 `json:"minDistance"`
+
+	// If true multiple rays are casted and blended for each pixel
+	Antialiasing        bool `json:"antialiasing"`
+	AntialiasingSamples int  `json:"antialiasingSamples"`
+
+	// If true the explicit light sources are sampled directly at each hit (next event estimation)
+	DirectLighting bool `json:"directLighting"`
+
+	// If true the rays are jittered and the frames are accumulated while the camera is not moved
+	TemporalFilter bool `json:"temporalFilter"`
+
+	// If true each path is traced for a few wavelengths sampled from the visible spectrum instead of the RGB channels.
+	// Needed to render the dispersion of dielectric materials, RGB colors are converted into spectra and the result back into RGB.
+	Spectral bool `json:"spectral"`
+
+	// Number of frames accumulated by the render command.
+	Samples int `json:"samples"`
+
+	// Sampler used to generate the sample values (independent, stratified, halton, sobol or blue-noise).
+	Sampler string `json:"sampler"`
+
+	// Seed of the random numbers, the same seed always produces the same image independently of the number of threads.
+	Seed int64 `json:"seed"`
+
+	// Tone mapping operator applied to the output (linear, reinhard, reinhard-extended, aces or hable).
+	ToneMapping string `json:"toneMapping"`
+
+	// Exposure of the output in EV stops.
+	Exposure float64 `json:"exposure"`
+
+	// Smallest value mapped to white by the tone mapping operators that support it.
+	WhitePoint float64 `json:"whitePoint"`
+
+	// If true splits the image generation into threads
+	Multithreaded bool `json:"multithreaded"`
+	Threads       int  `json:"threads"`
+
+	// Size in pixels of the tiles rendered by each thread and the order of the tiles (scanline, spiral or hilbert).
+	TileSize  int    `json:"tileSize"`
+	TileOrder string `json:"tileOrder"`
+
+	// If true each thread uses its own copy of the scene and camera.
+	MultithreadDataCopies bool `json:"multithreadDataCopies"`
+}
+
+// Create render settings with the default values.
+func NewRenderSettings() *RenderSettings {
+	var s = new(RenderSettings)
+	s.Width = 640
+	s.Height = 480
+	s.Upscale = 1.0
+	s.MaxDepth = 50
+	s.MinDistance = 1e-5
+	s.Antialiasing = false
+	s.AntialiasingSamples = 4
+	s.DirectLighting = true
+	s.TemporalFilter = true
+	s.Spectral = false
+	s.Samples = 32
+	s.Sampler = "sobol"
+	s.Seed = 0
+	s.ToneMapping = "linear"
+	s.Exposure = 0.0
+	s.WhitePoint = 4.0
+	s.Multithreaded = true
+	s.Threads = runtime.NumCPU()
+	s.TileSize = 32
+	s.TileOrder = "spiral"
+	s.MultithreadDataCopies = false
+	return s
+}
+
+// Register command line flags that write into the settings fields.
+// The current values of the settings are used as default values for the flags.
+func (s *RenderSettings) RegisterFlags(flags *flag.FlagSet) {
+	flags.IntVar(&s.Width, "width", s.Width, "Width of the rendered image in pixels.")
+	flags.IntVar(&s.Height, "height", s.Height, "Height of the rendered image in pixels.")
+	flags.Float64Var(&s.Upscale, "upscale", s.Upscale, "Scale applied to the image presented in the window.")
+	flags.Int64Var(&s.MaxDepth, "max-depth", s.MaxDepth, "Maximum number of bounces of each ray.")
+	flags.Float64Var(&s.MinDistance, "min-distance", s.MinDistance, "Minimum distance to be considered for ray collision.")
+	flags.BoolVar(&s.Antialiasing, "antialiasing", s.Antialiasing, "Cast multiple jittered rays for each pixel.")
+	flags.IntVar(&s.AntialiasingSamples, "antialiasing-samples", s.AntialiasingSamples, "Number of rays casted for each pixel when antialiasing is used.")
+	flags.BoolVar(&s.DirectLighting, "direct-lighting", s.DirectLighting, "Sample the light sources directly at each hit.")
+	flags.BoolVar(&s.TemporalFilter, "temporal-filter", s.TemporalFilter, "Jitter the rays and accumulate frames while the camera is not moved.")
+	flags.BoolVar(&s.Spectral, "spectral", s.Spectral, "Trace each path for wavelengths sampled from the visible spectrum, needed for dispersion.")
+	flags.IntVar(&s.Samples, "samples", s.Samples, "Number of frames accumulated by the render command.")
+	flags.StringVar(&s.Sampler, "sampler", s.Sampler, "Sampler used to generate the sample values ("+strings.Join(sampler.Names, ", ")+").")
+	flags.Int64Var(&s.Seed, "seed", s.Seed, "Seed of the random numbers used to render and to create the default scene.")
+	flags.StringVar(&s.ToneMapping, "tone-mapping", s.ToneMapping, "Tone mapping operator ("+strings.Join(tonemap.OperatorNames, ", ")+").")
+	flags.Float64Var(&s.Exposure, "exposure", s.Exposure, "Exposure of the output in EV stops.")
+	flags.Float64Var(&s.WhitePoint, "white-point", s.WhitePoint, "Smallest value mapped to white by the extended Reinhard and Hable operators.")
+	flags.BoolVar(&s.Multithreaded, "multithreaded", s.Multithreaded, "Split the image generation into threads.")
+	flags.IntVar(&s.Threads, "threads", s.Threads, "Number of threads used when multithreaded, the number of CPUs by default.")
+	flags.IntVar(&s.TileSize, "tile-size", s.TileSize, "Size in pixels of the tiles rendered by each thread.")
+	flags.StringVar(&s.TileOrder, "tile-order", s.TileOrder, "Order of the tiles ("+strings.Join(scheduler.OrderNames, ", ")+").")
+	flags.BoolVar(&s.MultithreadDataCopies, "data-copies", s.MultithreadDataCopies, "Use a copy of the scene and camera for each thread.")
+}
+
+// Load settings from a JSON config file, only the fields present in the file are changed.
+func (s *RenderSettings) LoadFile(fname string) error {
+	var file, err = os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var decoder = json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(s)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+
+	return nil
+}
+
+// Load settings from environment variables, only the variables that are defined are used.
+func (s *RenderSettings) LoadEnvironment() error {
+	var flags = flag.NewFlagSet("environment", flag.ContinueOnError)
+	s.RegisterFlags(flags)
+
+	var err error
+
+	flags.VisitAll(func(f *flag.Flag) {
+		var name = SettingsEnvironmentPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		var value, ok = os.LookupEnv(name)
+
+		if ok && err == nil {
+			if e := flags.Set(f.Name, value); e != nil {
+				err = fmt.Errorf("%s: %w", name, e)
+			}
+		}
+	})
+
+	return err
+}
+
+// Check if the settings values are valid.
+func (s *RenderSettings) Validate() error {
+	if s.Width <= 0 || s.Height <= 0 {
+		return fmt.Errorf("settings: width and height must be greater than zero")
+	}
+	if s.Upscale <= 0 {
+		return fmt.Errorf("settings: upscale must be greater than zero")
+	}
+	if s.MaxDepth < 0 {
+		return fmt.Errorf("settings: max depth must not be negative")
+	}
+	if s.AntialiasingSamples <= 0 {
+		return fmt.Errorf("settings: antialiasing samples must be greater than zero")
+	}
+	if s.Samples <= 0 {
+		return fmt.Errorf("settings: samples must be greater than zero")
+	}
+	if s.Threads <= 0 {
+		return fmt.Errorf("settings: threads must be greater than zero")
+	}
+	if s.TileSize <= 0 {
+		return fmt.Errorf("settings: tile size must be greater than zero")
+	}
+	if s.WhitePoint <= 0 {
+		return fmt.Errorf("settings: white point must be greater than zero")
+	}
+
+	var _, err = tonemap.NewOperator(s.ToneMapping, s.WhitePoint)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.NewSampler()
+	if err != nil {
+		return err
+	}
+
+	_, err = scheduler.Tiles(s.Width, s.Height, s.TileSize, s.TileOrder)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Create the tone mapper used to present the rendered image.
+func (s *RenderSettings) ToneMapper() (*tonemap.ToneMapper, error) {
+	var operator, err = tonemap.NewOperator(s.ToneMapping, s.WhitePoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return tonemap.NewToneMapper(operator, s.Exposure), nil
+}
+
+// Number of samples expected for each pixel of a render, used to divide the sample space by the stratified sampler.
+func (s *RenderSettings) SamplesPerPixel() int {
+	var samples = 1
+	if s.TemporalFilter {
+		samples = s.Samples
+	}
+	if s.Antialiasing {
+		samples *= s.AntialiasingSamples
+	}
+	return samples
+}
+
+// Create the sampler used to generate the sample values of each pixel.
+func (s *RenderSettings) NewSampler() (sampler.Sampler, error) {
+	return sampler.New(s.Sampler, s.SamplesPerPixel(), s.Seed)
+}
+
+// Parse the command line arguments and load the render settings.
+//
+// The flag set should contain any other flags used by the command, the render settings flags and the "config" flag are added to it.
+// The config file can also be indicated by the GOTRACER_CONFIG environment variable.
+func ParseRenderSettings(flags *flag.FlagSet, args []string) (*RenderSettings, error) {
+	var config = flags.String("config", os.Getenv(SettingsEnvironmentPrefix+"CONFIG"), "JSON file with render settings.")
+	NewRenderSettings().RegisterFlags(flags)
+
+	var err = flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings = NewRenderSettings()
+
+	if *config != "" {
+		err = settings.LoadFile(*config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = settings.LoadEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	// Flags set explicitly override the other sources
+	var overrides = flag.NewFlagSet("overrides", flag.ContinueOnError)
+	settings.RegisterFlags(overrides)
+
+	flags.Visit(func(f *flag.Flag) {
+		if overrides.Lookup(f.Name) != nil && err == nil {
+			err = overrides.Set(f.Name, f.Value.String())
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, settings.Validate()
+}
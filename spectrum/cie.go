@@ -0,0 +1,92 @@
+package spectrum
+
+import (
+	"gotracer/vmath"
+	"math"
+)
+
+// Range of wavelengths (in nanometers) sampled by the spectral mode, covers the visible spectrum.
+const (
+	MinWavelength = 360.0
+	MaxWavelength = 830.0
+)
+
+// Value of a piecewise gaussian with a different width on each side of the center.
+func gaussian(wavelength float64, center float64, left float64, right float64) float64 {
+	var t = (wavelength - center) / right
+	if wavelength < center {
+		t = (wavelength - center) / left
+	}
+	return math.Exp(-0.5 * t * t)
+}
+
+// CIE 1931 standard observer color matching functions for a wavelength in nanometers.
+// Uses the multi-lobe gaussian fit of Wyman et al. (2013), the error is smaller than the variability of the measured data.
+func ColorMatching(wavelength float64) vmath.Vec3 {
+	var x = 1.056*gaussian(wavelength, 599.8, 37.9, 31.0) + 0.362*gaussian(wavelength, 442.0, 16.0, 26.7) - 0.065*gaussian(wavelength, 501.1, 20.4, 26.2)
+	var y = 0.821*gaussian(wavelength, 568.8, 46.9, 40.5) + 0.286*gaussian(wavelength, 530.9, 16.3, 31.1)
+	var z = 1.217*gaussian(wavelength, 437.0, 11.8, 36.0) + 0.681*gaussian(wavelength, 459.0, 26.0, 13.8)
+	return vmath.Vec3{X: x, Y: y, Z: z}
+}
+
+// Convert a CIE XYZ color into linear sRGB (D65 white point).
+func XYZToRGB(xyz vmath.Vec3) vmath.Vec3 {
+	return vmath.Vec3{
+		X: 3.2404542*xyz.X - 1.5371385*xyz.Y - 0.4985314*xyz.Z,
+		Y: -0.9692660*xyz.X + 1.8760108*xyz.Y + 0.0415560*xyz.Z,
+		Z: 0.0556434*xyz.X - 0.2040259*xyz.Y + 1.0572252*xyz.Z,
+	}
+}
+
+// Relative spectral power of the CIE D65 illuminant from 380nm to 780nm in steps of 10nm.
+var d65 = []float64{
+	49.9755, 54.6482, 82.7549, 91.486, 93.4318, 86.6823, 104.865, 117.008, 117.812, 114.861,
+	115.923, 108.811, 109.354, 107.802, 104.79, 107.689, 104.405, 104.046, 100.0, 96.3342,
+	95.788, 88.6856, 90.0062, 89.5991, 87.6987, 83.2886, 83.6992, 80.0268, 80.2146, 82.2778,
+	78.2842, 69.7213, 71.6091, 74.349, 61.604, 69.8856, 75.087, 63.5927, 46.4182, 66.8054,
+	63.3828,
+}
+
+// Value of a table of equally spaced samples at a wavelength, interpolated linearly and clamped outside of the table.
+func interpolateTable(table []float64, first float64, last float64, wavelength float64) float64 {
+	var x = (wavelength - first) / (last - first) * float64(len(table)-1)
+	if x <= 0 {
+		return table[0]
+	}
+	if x >= float64(len(table)-1) {
+		return table[len(table)-1]
+	}
+
+	var i = int(x)
+	var t = x - float64(i)
+	return table[i]*(1.0-t) + table[i+1]*t
+}
+
+// Relative spectral power of the D65 illuminant at a wavelength.
+func D65(wavelength float64) float64 {
+	return interpolateTable(d65, 380.0, 780.0, wavelength)
+}
+
+// Integrate a function over the sampled range of wavelengths with steps of one nanometer.
+func integrate(f func(wavelength float64) vmath.Vec3) vmath.Vec3 {
+	var sum vmath.Vec3
+	for wavelength := MinWavelength; wavelength <= MaxWavelength; wavelength++ {
+		sum = sum.Add(f(wavelength))
+	}
+	return sum
+}
+
+// Integral of the luminance matching function, a constant spectrum of one has luminance one.
+var cieYIntegral = integrate(ColorMatching).Y
+
+// Scale of the D65 illuminant so that its luminance is one.
+var d65Scale = cieYIntegral / integrate(func(wavelength float64) vmath.Vec3 {
+	return ColorMatching(wavelength).MulScalar(D65(wavelength))
+}).Y
+
+// Scale of each channel so that the spectrum of a white light is converted back to white.
+// Corrects the small error of the color matching functions fit and of the RGB upsampling.
+var whiteBalance = vmath.NewVec3(1.0, 1.0, 1.0).Divide(XYZToRGB(integrate(func(wavelength float64) vmath.Vec3 {
+	var white = interpolateTable(smitsWhite, smitsFirst, smitsLast, wavelength)
+	return ColorMatching(wavelength).MulScalar(white * D65(wavelength) * d65Scale / cieYIntegral)
+})))
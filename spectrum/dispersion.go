@@ -0,0 +1,90 @@
+package spectrum
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Wavelength (in nanometers) of the sodium D line, the refractive indice of transparent materials is usually given for it.
+const SodiumDLine = 589.3
+
+// Dispersion describes how the refractive indice of a material changes with the wavelength of the light.
+type Dispersion interface {
+	// Refractive indice for a wavelength in nanometers.
+	RefractiveIndice(wavelength float64) float64
+}
+
+// Cauchy equation of the refractive indice, n = A + B / λ² with the wavelength in micrometers.
+// Simple approximation that fits well most transparent materials in the visible spectrum.
+type CauchyDispersion struct {
+	A float64
+	B float64
+}
+
+func NewCauchyDispersion(a float64, b float64) *CauchyDispersion {
+	var d = new(CauchyDispersion)
+	d.A = a
+	d.B = b
+	return d
+}
+
+func (d *CauchyDispersion) RefractiveIndice(wavelength float64) float64 {
+	var micrometers = wavelength * 1e-3
+	return d.A + d.B/(micrometers*micrometers)
+}
+
+// Sellmeier equation of the refractive indice, n² = 1 + Σ B λ² / (λ² - C) with the wavelength in micrometers.
+// Used by the glass manufacturers to describe their materials, accurate in a wide range of wavelengths.
+type SellmeierDispersion struct {
+	B [3]float64
+	C [3]float64
+}
+
+func NewSellmeierDispersion(b [3]float64, c [3]float64) *SellmeierDispersion {
+	var d = new(SellmeierDispersion)
+	d.B = b
+	d.C = c
+	return d
+}
+
+func (d *SellmeierDispersion) RefractiveIndice(wavelength float64) float64 {
+	var micrometers = wavelength * 1e-3
+	var l2 = micrometers * micrometers
+
+	var n2 = 1.0
+	for i := 0; i < 3; i++ {
+		n2 += d.B[i] * l2 / (l2 - d.C[i])
+	}
+
+	return math.Sqrt(n2)
+}
+
+// Measured dispersion of common transparent materials.
+var DispersionPresets = map[string]Dispersion{
+	"bk7":          NewSellmeierDispersion([3]float64{1.03961212, 0.231792344, 1.01046945}, [3]float64{0.00600069867, 0.0200179144, 103.560653}),
+	"dense-flint":  NewSellmeierDispersion([3]float64{1.73759695, 0.313747346, 1.89878101}, [3]float64{0.013188707, 0.0623068142, 155.23629}),
+	"fused-silica": NewSellmeierDispersion([3]float64{0.6961663, 0.4079426, 0.8974794}, [3]float64{0.00467914826, 0.0135120631, 97.9340025}),
+	"sapphire":     NewSellmeierDispersion([3]float64{1.4313493, 0.65054713, 5.3414021}, [3]float64{0.00527992610, 0.0142382647, 325.017834}),
+	"diamond":      NewSellmeierDispersion([3]float64{0.3306, 4.3356, 0.0}, [3]float64{0.030625, 0.011236, 0.0}),
+	"water":        NewCauchyDispersion(1.3240, 0.00300),
+}
+
+// Get the names of the dispersion presets sorted alphabetically.
+func DispersionPresetNames() []string {
+	var names = make([]string, 0, len(DispersionPresets))
+	for name := range DispersionPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get a dispersion preset from its name.
+func NewDispersionPreset(name string) (Dispersion, error) {
+	var d, ok = DispersionPresets[name]
+	if !ok {
+		return nil, fmt.Errorf("spectrum: unknown dispersion preset %q", name)
+	}
+	return d, nil
+}
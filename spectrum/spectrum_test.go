@@ -0,0 +1,153 @@
+package spectrum
+
+import (
+	"gotracer/vmath"
+	"math"
+	"testing"
+)
+
+// Number of stratified wavelength samples used to average the spectral values.
+const testWavelengthSamples = 4096
+
+// Average the RGB color of a spectrum over stratified samples of the wavelengths.
+func averageRGB(spectrum func(w *Wavelengths) vmath.Vec3) vmath.Vec3 {
+	var sum vmath.Vec3
+	var w = new(Wavelengths)
+
+	for i := 0; i < testWavelengthSamples; i++ {
+		w.Sample((float64(i) + 0.5) / testWavelengthSamples)
+		sum = sum.Add(w.ToRGB(spectrum(w)))
+	}
+
+	return sum.DivideScalar(testWavelengthSamples)
+}
+
+func TestSampleVisible(t *testing.T) {
+	var integral = 0.0
+	var step = 0.01
+	for lambda := float64(MinWavelength); lambda < MaxWavelength; lambda += step {
+		integral += visiblePDF(lambda+step/2.0) * step
+	}
+	if math.Abs(integral-1.0) > 0.01 {
+		t.Errorf("density integrates to %f over the wavelength range", integral)
+	}
+
+	for i := 0; i < 100; i++ {
+		var u = (float64(i) + 0.5) / 100.0
+		var lambda = sampleVisible(u)
+		if lambda < MinWavelength || lambda > MaxWavelength {
+			t.Fatalf("sampled wavelength %f out of the range", lambda)
+		}
+
+		// The density is the derivative of the inverse of the sampling function
+		var du = 1e-6
+		var derivative = du / (sampleVisible(u+du) - sampleVisible(u))
+		if math.Abs(derivative-visiblePDF(lambda))/visiblePDF(lambda) > 1e-3 {
+			t.Errorf("density at %f is %f, the sampling function has %f", lambda, visiblePDF(lambda), derivative)
+		}
+	}
+}
+
+// Lights are converted into spectra and back into the same color.
+func TestIlluminantRoundTrip(t *testing.T) {
+	var colors = []vmath.Vec3{
+		vmath.NewVec3(1.0, 1.0, 1.0),
+		vmath.NewVec3(0.5, 0.5, 0.5),
+		vmath.NewVec3(1.0, 0.0, 0.0),
+		vmath.NewVec3(0.0, 1.0, 0.0),
+		vmath.NewVec3(0.0, 0.0, 1.0),
+		vmath.NewVec3(1.0, 0.95, 0.85),
+		vmath.NewVec3(0.2, 0.3, 0.9),
+	}
+
+	for _, color := range colors {
+		var rgb = averageRGB(func(w *Wavelengths) vmath.Vec3 {
+			return w.Illuminant(color)
+		})
+
+		// Saturated colors have a error of a few percent with the Smits basis
+		var tolerance = 0.12
+		if color.X == color.Y && color.Y == color.Z {
+			// Gray colors use the constant part of the basis and are white balanced
+			tolerance = 0.002
+		}
+
+		if rgb.Sub(color).Length() > tolerance {
+			t.Errorf("color %s converted into %s", color.ToString(), rgb.ToString())
+		}
+	}
+}
+
+// Reflectance spectra of colors in the [0, 1] range stay in the [0, 1] range, so surfaces never reflect more light than they receive.
+// The published tables of the Smits basis exceed one by up to 1.5% (red spectrum).
+func TestReflectanceRange(t *testing.T) {
+	var w = new(Wavelengths)
+
+	for i := 0; i < 512; i++ {
+		w.Sample((float64(i) + 0.5) / 512.0)
+
+		for c := 0; c < 64; c++ {
+			var color = vmath.NewVec3(float64(c&3)/3.0, float64(c>>2&3)/3.0, float64(c>>4&3)/3.0)
+			var r = w.Reflectance(color)
+
+			if r.X < -1e-9 || r.Y < -1e-9 || r.Z < -1e-9 || r.X > 1.015 || r.Y > 1.015 || r.Z > 1.015 {
+				t.Fatalf("reflectance of %s at %v is %s", color.ToString(), w.Lambda, r.ToString())
+			}
+		}
+	}
+}
+
+func TestTerminateSecondary(t *testing.T) {
+	var w = NewWavelengths(0.3)
+
+	if f := w.TerminateSecondary(); f != (vmath.Vec3{X: SampledWavelengths}) {
+		t.Errorf("first termination factor is %s", f.ToString())
+	}
+	if f := w.TerminateSecondary(); f != (vmath.Vec3{X: 1.0}) {
+		t.Errorf("second termination factor is %s", f.ToString())
+	}
+
+	w.Sample(0.3)
+	if f := w.TerminateSecondary(); f != (vmath.Vec3{X: SampledWavelengths}) {
+		t.Errorf("sampling the wavelengths again did not restore the secondary wavelengths")
+	}
+}
+
+func TestDispersionPresets(t *testing.T) {
+	// Refractive indices at the sodium D line
+	var tests = map[string]float64{
+		"bk7":          1.5168,
+		"dense-flint":  1.7847,
+		"fused-silica": 1.4585,
+		"sapphire":     1.7682,
+		"diamond":      2.4175,
+		"water":        1.3327,
+	}
+
+	if len(tests) != len(DispersionPresets) {
+		t.Errorf("%d presets tested, expected %d", len(tests), len(DispersionPresets))
+	}
+
+	for name, expected := range tests {
+		var d, err = NewDispersionPreset(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n := d.RefractiveIndice(SodiumDLine); math.Abs(n-expected) > 1e-3 {
+			t.Errorf("%s: refractive indice %f, expected %f", name, n, expected)
+		}
+
+		// Normal dispersion, blue light is refracted more than red light
+		for lambda := 400.0; lambda < 700.0; lambda += 10.0 {
+			if d.RefractiveIndice(lambda) <= d.RefractiveIndice(lambda+10.0) {
+				t.Errorf("%s: refractive indice does not decrease at %fnm", name, lambda)
+				break
+			}
+		}
+	}
+
+	if _, err := NewDispersionPreset("glass"); err == nil {
+		t.Errorf("unknown preset did not return an error")
+	}
+}
@@ -0,0 +1,85 @@
+package spectrum
+
+import (
+	"gotracer/vmath"
+)
+
+// Range of wavelengths covered by the basis spectra of the RGB upsampling, the spectra are constant outside of it.
+const (
+	smitsFirst = 380.0
+	smitsLast  = 720.0
+)
+
+// Basis spectra used to convert RGB colors into smooth reflectance spectra (Smits 1999).
+// Each table has ten equally spaced samples between 380nm and 720nm.
+var (
+	smitsWhite   = []float64{1.0000, 1.0000, 0.9999, 0.9993, 0.9992, 0.9998, 1.0000, 1.0000, 1.0000, 1.0000}
+	smitsCyan    = []float64{0.9710, 0.9426, 1.0007, 1.0007, 1.0007, 1.0007, 0.1564, 0.0000, 0.0000, 0.0000}
+	smitsMagenta = []float64{1.0000, 1.0000, 0.9685, 0.2229, 0.0000, 0.0458, 0.8369, 1.0000, 1.0000, 0.9959}
+	smitsYellow  = []float64{0.0001, 0.0000, 0.1088, 0.6651, 1.0000, 1.0000, 0.9996, 0.9586, 0.9685, 0.9840}
+	smitsRed     = []float64{0.1012, 0.0515, 0.0000, 0.0000, 0.0000, 0.0000, 0.8325, 1.0149, 1.0149, 1.0149}
+	smitsGreen   = []float64{0.0000, 0.0000, 0.0273, 0.7937, 1.0000, 0.9418, 0.1719, 0.0000, 0.0000, 0.0025}
+	smitsBlue    = []float64{1.0000, 1.0000, 0.8916, 0.3323, 0.0000, 0.0000, 0.0003, 0.0369, 0.0483, 0.0496}
+)
+
+// Values of the basis spectra at the sampled wavelengths, one component for each wavelength.
+type upsamplingBasis struct {
+	white   vmath.Vec3
+	cyan    vmath.Vec3
+	magenta vmath.Vec3
+	yellow  vmath.Vec3
+	red     vmath.Vec3
+	green   vmath.Vec3
+	blue    vmath.Vec3
+}
+
+// Set the value of the basis spectra for one of the sampled wavelengths.
+func (b *upsamplingBasis) set(index int, wavelength float64) {
+	setComponent(&b.white, index, interpolateTable(smitsWhite, smitsFirst, smitsLast, wavelength))
+	setComponent(&b.cyan, index, interpolateTable(smitsCyan, smitsFirst, smitsLast, wavelength))
+	setComponent(&b.magenta, index, interpolateTable(smitsMagenta, smitsFirst, smitsLast, wavelength))
+	setComponent(&b.yellow, index, interpolateTable(smitsYellow, smitsFirst, smitsLast, wavelength))
+	setComponent(&b.red, index, interpolateTable(smitsRed, smitsFirst, smitsLast, wavelength))
+	setComponent(&b.green, index, interpolateTable(smitsGreen, smitsFirst, smitsLast, wavelength))
+	setComponent(&b.blue, index, interpolateTable(smitsBlue, smitsFirst, smitsLast, wavelength))
+}
+
+// Convert a RGB color into the values of its reflectance spectrum at the sampled wavelengths.
+// The spectrum is the white part of the color plus the secondary (cyan, magenta or yellow) and primary colors remaining.
+func (b *upsamplingBasis) spectrum(color vmath.Vec3) vmath.Vec3 {
+	var r, g, bl = color.X, color.Y, color.Z
+
+	if r <= g && r <= bl {
+		var s = b.white.MulScalar(r)
+		if g <= bl {
+			return s.AddScaled(b.cyan, g-r).AddScaled(b.blue, bl-g)
+		}
+		return s.AddScaled(b.cyan, bl-r).AddScaled(b.green, g-bl)
+	}
+
+	if g <= r && g <= bl {
+		var s = b.white.MulScalar(g)
+		if r <= bl {
+			return s.AddScaled(b.magenta, r-g).AddScaled(b.blue, bl-r)
+		}
+		return s.AddScaled(b.magenta, bl-g).AddScaled(b.red, r-bl)
+	}
+
+	var s = b.white.MulScalar(bl)
+	if r <= g {
+		return s.AddScaled(b.yellow, r-bl).AddScaled(b.green, g-r)
+	}
+	return s.AddScaled(b.yellow, g-bl).AddScaled(b.red, r-g)
+}
+
+// Set a component of a vector by index.
+func setComponent(v *vmath.Vec3, index int, value float64) {
+	switch index {
+	case 0:
+		v.X = value
+	case 1:
+		v.Y = value
+	default:
+		v.Z = value
+	}
+}
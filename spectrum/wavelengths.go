@@ -0,0 +1,133 @@
+package spectrum
+
+import (
+	"gotracer/vmath"
+	"math"
+)
+
+// Number of wavelengths sampled for each path, the colors of a spectral path have one component for each wavelength.
+const SampledWavelengths = 3
+
+// Wavelengths sampled for a path in spectral mode (hero wavelength sampling).
+//
+// The first wavelength is the hero wavelength, the others are rotated by equal fractions of the sample space so the visible spectrum is covered by every path.
+// While the path is traced the components of the colors are the values of the spectrum at each wavelength instead of the red, green and blue channels.
+type Wavelengths struct {
+	// Wavelengths in nanometers.
+	Lambda [SampledWavelengths]float64
+
+	// Density of sampling each wavelength.
+	PDF [SampledWavelengths]float64
+
+	// Contribution of each wavelength to the CIE XYZ color, already divided by the density.
+	xyz [SampledWavelengths]vmath.Vec3
+
+	// Values of the RGB upsampling basis and of the normalized D65 illuminant at each wavelength.
+	basis      upsamplingBasis
+	illuminant vmath.Vec3
+
+	// True if the wavelengths other than the hero were terminated by a dispersive interaction.
+	terminated bool
+}
+
+// Create wavelengths sampled from a value in the range [0, 1).
+func NewWavelengths(u float64) *Wavelengths {
+	var w = new(Wavelengths)
+	w.Sample(u)
+	return w
+}
+
+// Sample the wavelengths for a new path from a value in the range [0, 1).
+// The wavelengths are sampled proportionally to the sensitivity of the eye, the dimmer ends of the spectrum have less noise this way.
+func (w *Wavelengths) Sample(u float64) {
+	w.terminated = false
+
+	for i := 0; i < SampledWavelengths; i++ {
+		var ui = u + float64(i)/SampledWavelengths
+		if ui >= 1.0 {
+			ui -= 1.0
+		}
+
+		var lambda = sampleVisible(ui)
+		w.Lambda[i] = lambda
+		w.PDF[i] = visiblePDF(lambda)
+		w.xyz[i] = ColorMatching(lambda).DivideScalar(w.PDF[i] * SampledWavelengths * cieYIntegral)
+		w.basis.set(i, lambda)
+		setComponent(&w.illuminant, i, D65(lambda)*d65Scale)
+	}
+}
+
+// Hero wavelength of the path, the wavelength kept when the others are terminated.
+func (w *Wavelengths) Hero() float64 {
+	return w.Lambda[0]
+}
+
+// Terminate the wavelengths other than the hero, used when the path is scattered in a direction that depends on the wavelength (dispersion).
+// Returns the factor applied to the color of the rest of the path, the hero wavelength takes the contribution of the terminated ones.
+func (w *Wavelengths) TerminateSecondary() vmath.Vec3 {
+	if w.terminated {
+		return vmath.Vec3{X: 1.0}
+	}
+
+	w.terminated = true
+	return vmath.Vec3{X: SampledWavelengths}
+}
+
+// Values of the reflectance spectrum of a RGB color at the wavelengths.
+func (w *Wavelengths) Reflectance(color vmath.Vec3) vmath.Vec3 {
+	return w.basis.spectrum(color)
+}
+
+// Values of the emission spectrum of a RGB light color at the wavelengths.
+// The color is the reflectance spectrum lit by the D65 illuminant, so a white light is converted back to white.
+func (w *Wavelengths) Illuminant(color vmath.Vec3) vmath.Vec3 {
+	return w.basis.spectrum(color).Mul(w.illuminant)
+}
+
+// Values at the wavelengths of a property given for the red, green and blue channels (e.g. the refractive indice of a conductor).
+// The property is interpolated linearly between the dominant wavelengths of the channels and is constant outside of them.
+func (w *Wavelengths) Interpolate(v vmath.Vec3) vmath.Vec3 {
+	var values = [3]float64{v.Z, v.Y, v.X}
+
+	var result vmath.Vec3
+	for i := 0; i < SampledWavelengths; i++ {
+		setComponent(&result, i, interpolateChannels(values, w.Lambda[i]))
+	}
+	return result
+}
+
+// Dominant wavelengths of the blue, green and red channels of sRGB.
+var channelWavelengths = [3]float64{465.0, 549.0, 612.0}
+
+// Interpolate the values of the blue, green and red channels at a wavelength.
+func interpolateChannels(values [3]float64, wavelength float64) float64 {
+	if wavelength <= channelWavelengths[0] {
+		return values[0]
+	}
+
+	for i := 1; i < len(channelWavelengths); i++ {
+		if wavelength <= channelWavelengths[i] {
+			var t = (wavelength - channelWavelengths[i-1]) / (channelWavelengths[i] - channelWavelengths[i-1])
+			return values[i-1]*(1.0-t) + values[i]*t
+		}
+	}
+
+	return values[len(values)-1]
+}
+
+// Convert the radiance of the path at the wavelengths into a linear sRGB color.
+func (w *Wavelengths) ToRGB(radiance vmath.Vec3) vmath.Vec3 {
+	var xyz = w.xyz[0].MulScalar(radiance.X).AddScaled(w.xyz[1], radiance.Y).AddScaled(w.xyz[2], radiance.Z)
+	return XYZToRGB(xyz).Mul(whiteBalance)
+}
+
+// Sample a wavelength in the visible range with a density close to the luminance matching function.
+func sampleVisible(u float64) float64 {
+	return 538.0 - 138.888889*math.Atanh(0.85691062-1.82750197*u)
+}
+
+// Density of sampling a wavelength with sampleVisible.
+func visiblePDF(wavelength float64) float64 {
+	var c = math.Cosh(0.0072 * (wavelength - 538.0))
+	return 0.0039398042 / (c * c)
+}
@@ -0,0 +1,38 @@
+package texture
+
+import (
+	"gotracer/vmath"
+	"math"
+)
+
+// Checker texture alternates between two textures in a 3D grid of cubes.
+// The pattern is calculated from the position of the point so it does not depend on the texture coordinates.
+type Checker struct {
+	// Textures used in the even and odd cells.
+	Even Texture
+	Odd  Texture
+
+	// Size of each cell of the grid.
+	Size float64
+}
+
+// Create a checker texture with the cell size.
+func NewChecker(even Texture, odd Texture, size float64) *Checker {
+	var t = new(Checker)
+	t.Even = even
+	t.Odd = odd
+	t.Size = size
+	return t
+}
+
+func (t *Checker) Value(u float64, v float64, p vmath.Vec3) vmath.Vec3 {
+	var x = int(math.Floor(p.X / t.Size))
+	var y = int(math.Floor(p.Y / t.Size))
+	var z = int(math.Floor(p.Z / t.Size))
+
+	if (x+y+z)%2 == 0 {
+		return t.Even.Value(u, v, p)
+	}
+
+	return t.Odd.Value(u, v, p)
+}
@@ -0,0 +1,144 @@
+package texture
+
+import (
+	"fmt"
+	"gotracer/vmath"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+)
+
+// Wrap mode indicates how texture coordinates outside of the [0, 1] range are handled.
+type WrapMode int
+
+const (
+	// Repeat the texture.
+	WrapRepeat WrapMode = iota
+
+	// Use the color of the border of the texture.
+	WrapClamp
+
+	// Repeat the texture mirroring it in each repetition.
+	WrapMirror
+)
+
+// Image texture reads the color from a image using the texture coordinates of the surface.
+// Colors are sampled with bilinear filtering.
+type ImageTexture struct {
+	// Path of the image file, empty if the texture was not loaded from a file.
+	Path string
+
+	// Size of the image in pixels.
+	Width  int
+	Height int
+
+	// Linear color of each pixel, stored row by row starting on the top row.
+	Pixels []vmath.Vec3
+
+	// Wrap mode used for the u and v coordinates.
+	WrapU WrapMode
+	WrapV WrapMode
+}
+
+// Load a image texture from a PNG or JPEG file.
+// The image colors are considered to be encoded in sRGB and are converted into linear values.
+func LoadImageTexture(fname string) (*ImageTexture, error) {
+	var file, err = os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var img image.Image
+	img, _, err = image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+
+	var t = NewImageTexture(img)
+	t.Path = fname
+	return t, nil
+}
+
+// Create a image texture from a image, colors are converted from sRGB into linear values.
+func NewImageTexture(img image.Image) *ImageTexture {
+	var bounds = img.Bounds()
+
+	var t = new(ImageTexture)
+	t.Width = bounds.Dx()
+	t.Height = bounds.Dy()
+	t.Pixels = make([]vmath.Vec3, t.Width*t.Height)
+	t.WrapU = WrapRepeat
+	t.WrapV = WrapRepeat
+
+	for y := 0; y < t.Height; y++ {
+		for x := 0; x < t.Width; x++ {
+			var r, g, b, _ = img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			t.Pixels[y*t.Width+x] = vmath.NewVec3(SRGBToLinear(float64(r)/65535.0), SRGBToLinear(float64(g)/65535.0), SRGBToLinear(float64(b)/65535.0))
+		}
+	}
+
+	return t
+}
+
+// Sample the image with bilinear filtering, v = 0 is the bottom of the image.
+func (t *ImageTexture) Value(u float64, v float64, p vmath.Vec3) vmath.Vec3 {
+	if t.Width == 0 || t.Height == 0 {
+		return vmath.NewVec3(0.0, 1.0, 1.0)
+	}
+
+	// Pixel centers are at half integer coordinates
+	var x = u*float64(t.Width) - 0.5
+	var y = (1.0-v)*float64(t.Height) - 0.5
+
+	var x0 = math.Floor(x)
+	var y0 = math.Floor(y)
+	var fx = x - x0
+	var fy = y - y0
+
+	var color = t.pixel(int(x0), int(y0)).MulScalar((1.0 - fx) * (1.0 - fy))
+	color = color.AddScaled(t.pixel(int(x0)+1, int(y0)), fx*(1.0-fy))
+	color = color.AddScaled(t.pixel(int(x0), int(y0)+1), (1.0-fx)*fy)
+	color = color.AddScaled(t.pixel(int(x0)+1, int(y0)+1), fx*fy)
+
+	return color
+}
+
+// Get the color of a pixel, the wrap modes are applied to the coordinates.
+func (t *ImageTexture) pixel(x int, y int) vmath.Vec3 {
+	return t.Pixels[wrap(y, t.Height, t.WrapV)*t.Width+wrap(x, t.Width, t.WrapU)]
+}
+
+// Apply the wrap mode to a pixel coordinate.
+func wrap(i int, size int, mode WrapMode) int {
+	switch mode {
+	case WrapClamp:
+		if i < 0 {
+			return 0
+		}
+		if i >= size {
+			return size - 1
+		}
+		return i
+	case WrapMirror:
+		var period = 2 * size
+		i = ((i % period) + period) % period
+		if i >= size {
+			i = period - 1 - i
+		}
+		return i
+	}
+
+	return ((i % size) + size) % size
+}
+
+// Convert a value encoded with the sRGB transfer curve into a linear value.
+func SRGBToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
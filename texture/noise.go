@@ -0,0 +1,119 @@
+package texture
+
+import (
+	"gotracer/vmath"
+	"math"
+	"math/rand"
+)
+
+// Number of random gradients used by the perlin noise.
+const perlinPoints = 256
+
+// Procedural noise texture based on perlin noise with turbulence, creates a marble like pattern.
+type Noise struct {
+	// Color of the texture, multiplied by the noise value.
+	Color vmath.Vec3
+
+	// Frequency of the noise pattern.
+	Scale float64
+
+	// Number of noise octaves summed for the turbulence.
+	Octaves int
+
+	// Seed used to generate the random gradients.
+	Seed int64
+
+	// Random gradients and permutation tables.
+	gradients [perlinPoints]vmath.Vec3
+	permX     [perlinPoints]int
+	permY     [perlinPoints]int
+	permZ     [perlinPoints]int
+}
+
+// Create a noise texture, the seed is used to generate the random gradients.
+func NewNoise(color vmath.Vec3, scale float64, octaves int, seed int64) *Noise {
+	var t = new(Noise)
+	t.Color = color
+	t.Scale = scale
+	t.Octaves = octaves
+	t.Seed = seed
+
+	var random = rand.New(rand.NewSource(seed))
+
+	for i := 0; i < perlinPoints; i++ {
+		t.gradients[i] = vmath.NewVec3(random.Float64()*2.0-1.0, random.Float64()*2.0-1.0, random.Float64()*2.0-1.0).Normalize()
+	}
+
+	for i := 0; i < perlinPoints; i++ {
+		t.permX[i] = i
+		t.permY[i] = i
+		t.permZ[i] = i
+	}
+
+	random.Shuffle(perlinPoints, func(i, j int) { t.permX[i], t.permX[j] = t.permX[j], t.permX[i] })
+	random.Shuffle(perlinPoints, func(i, j int) { t.permY[i], t.permY[j] = t.permY[j], t.permY[i] })
+	random.Shuffle(perlinPoints, func(i, j int) { t.permZ[i], t.permZ[j] = t.permZ[j], t.permZ[i] })
+
+	return t
+}
+
+func (t *Noise) Value(u float64, v float64, p vmath.Vec3) vmath.Vec3 {
+	var s = p.MulScalar(t.Scale)
+	var value = 0.5 * (1.0 + math.Sin(s.Z+10.0*t.Turbulence(s)))
+
+	return t.Color.MulScalar(value)
+}
+
+// Sum of multiple octaves of noise with decreasing weight.
+func (t *Noise) Turbulence(p vmath.Vec3) float64 {
+	var sum = 0.0
+	var weight = 1.0
+	var q = p
+
+	for i := 0; i < t.Octaves; i++ {
+		sum += weight * t.Perlin(q)
+		weight *= 0.5
+		q = q.MulScalar(2.0)
+	}
+
+	return math.Abs(sum)
+}
+
+// Perlin gradient noise value for a point, in the range [-1, 1].
+func (t *Noise) Perlin(p vmath.Vec3) float64 {
+	var fx = math.Floor(p.X)
+	var fy = math.Floor(p.Y)
+	var fz = math.Floor(p.Z)
+
+	var u = p.X - fx
+	var v = p.Y - fy
+	var w = p.Z - fz
+
+	var i = int(fx)
+	var j = int(fy)
+	var k = int(fz)
+
+	// Hermite smoothing of the interpolation weights
+	var uu = u * u * (3.0 - 2.0*u)
+	var vv = v * v * (3.0 - 2.0*v)
+	var ww = w * w * (3.0 - 2.0*w)
+
+	var sum = 0.0
+
+	for di := 0; di < 2; di++ {
+		for dj := 0; dj < 2; dj++ {
+			for dk := 0; dk < 2; dk++ {
+				var g = &t.gradients[t.permX[(i+di)&(perlinPoints-1)]^t.permY[(j+dj)&(perlinPoints-1)]^t.permZ[(k+dk)&(perlinPoints-1)]]
+				var weight = vmath.NewVec3(u-float64(di), v-float64(dj), w-float64(dk))
+
+				var a = float64(di)*uu + float64(1-di)*(1.0-uu)
+				var b = float64(dj)*vv + float64(1-dj)*(1.0-vv)
+				var c = float64(dk)*ww + float64(1-dk)*(1.0-ww)
+
+				sum += a * b * c * g.Dot(weight)
+			}
+		}
+	}
+
+	return sum
+}
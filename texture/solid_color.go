@@ -0,0 +1,26 @@
+package texture
+
+import (
+	"gotracer/vmath"
+)
+
+// Solid color texture has the same color in every point.
+type SolidColor struct {
+	// Color of the texture.
+	Color vmath.Vec3
+}
+
+func NewSolidColor(color vmath.Vec3) *SolidColor {
+	var t = new(SolidColor)
+	t.Color = color
+	return t
+}
+
+func (t *SolidColor) Value(u float64, v float64, p vmath.Vec3) vmath.Vec3 {
+	return t.Color
+}
+
+// Create a solid texture with the same value in all channels, used for scalar material parameters.
+func NewSolidValue(value float64) *SolidColor {
+	return NewSolidColor(vmath.NewVec3(value, value, value))
+}
@@ -0,0 +1,14 @@
+package texture
+
+import (
+	"gotracer/vmath"
+)
+
+// Texture provides a color that varies along the surface of the objects.
+//
+// Textures can be used by the material parameters instead of constant colors.
+// Textures are shared between material copies, they should not be changed while rendering.
+type Texture interface {
+	// Get the color of the texture for a surface point, u and v are the texture coordinates of the point.
+	Value(u float64, v float64, p vmath.Vec3) vmath.Vec3
+}
@@ -0,0 +1,23 @@
+package tonemap
+
+import (
+	"gotracer/vmath"
+)
+
+// ACES filmic operator, uses the curve fit of the ACES reference rendering transform by Krzysztof Narkowicz.
+// Gives a contrasty film like look with a smooth roll off for bright values.
+type ACESOperator struct{}
+
+func NewACESOperator() *ACESOperator {
+	return new(ACESOperator)
+}
+
+func (o *ACESOperator) Map(color vmath.Vec3) vmath.Vec3 {
+	var f = func(x float64) float64 {
+		// The curve was fitted for values pre exposed by 0.6
+		x *= 0.6
+		return clamp((x * (2.51*x + 0.03)) / (x*(2.43*x+0.59) + 0.14))
+	}
+
+	return vmath.NewVec3(f(color.X), f(color.Y), f(color.Z))
+}
@@ -0,0 +1,38 @@
+package tonemap
+
+import (
+	"gotracer/vmath"
+)
+
+// Hable filmic operator, created by John Hable for Uncharted 2.
+// The curve has a toe and shoulder similar to film, values are normalized by the white point.
+type HableOperator struct {
+	// Smallest value mapped to pure white.
+	WhitePoint float64
+}
+
+func NewHableOperator(whitePoint float64) *HableOperator {
+	var o = new(HableOperator)
+	o.WhitePoint = whitePoint
+	return o
+}
+
+// Exposure bias applied before the curve as proposed in the original implementation.
+const hableExposureBias = 2.0
+
+// The white point is scaled by the exposure bias as well, so values equal or above it are mapped to one.
+func (o *HableOperator) Map(color vmath.Vec3) vmath.Vec3 {
+	var white = hableCurve(o.WhitePoint * hableExposureBias)
+	var f = func(x float64) float64 {
+		return clamp(hableCurve(x*hableExposureBias) / white)
+	}
+
+	return vmath.NewVec3(f(color.X), f(color.Y), f(color.Z))
+}
+
+// Filmic curve using the parameters of the Uncharted 2 implementation.
+func hableCurve(x float64) float64 {
+	// Shoulder strength, linear strength, linear angle, toe strength, toe numerator and toe denominator
+	const a, b, c, d, e, f = 0.15, 0.50, 0.10, 0.20, 0.02, 0.30
+	return ((x*(a*x+c*b) + d*e) / (x*(a*x+b) + d*f)) - e/f
+}
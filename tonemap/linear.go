@@ -0,0 +1,23 @@
+package tonemap
+
+import (
+	"gotracer/vmath"
+	"math"
+)
+
+// Linear operator clamps the color values to the [0, 1] range.
+// Values above one are lost, bright objects are blown out.
+type LinearOperator struct{}
+
+func NewLinearOperator() *LinearOperator {
+	return new(LinearOperator)
+}
+
+func (o *LinearOperator) Map(color vmath.Vec3) vmath.Vec3 {
+	return vmath.NewVec3(clamp(color.X), clamp(color.Y), clamp(color.Z))
+}
+
+// Clamp a value to the [0, 1] range.
+func clamp(v float64) float64 {
+	return math.Max(0.0, math.Min(1.0, v))
+}
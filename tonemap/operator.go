@@ -0,0 +1,34 @@
+package tonemap
+
+import (
+	"fmt"
+	"gotracer/vmath"
+)
+
+// Operator maps linear HDR color values into the displayable [0, 1] range.
+type Operator interface {
+	// Map a color, the input is linear and exposure was already applied.
+	Map(color vmath.Vec3) vmath.Vec3
+}
+
+// Names of the available tone mapping operators.
+var OperatorNames = []string{"linear", "reinhard", "reinhard-extended", "aces", "hable"}
+
+// Create a tone mapping operator from its name.
+// The white point is the smallest value mapped to pure white, used by the extended Reinhard and Hable operators.
+func NewOperator(name string, whitePoint float64) (Operator, error) {
+	switch name {
+	case "linear", "":
+		return NewLinearOperator(), nil
+	case "reinhard":
+		return NewReinhardOperator(), nil
+	case "reinhard-extended":
+		return NewReinhardExtendedOperator(whitePoint), nil
+	case "aces":
+		return NewACESOperator(), nil
+	case "hable", "uncharted2":
+		return NewHableOperator(whitePoint), nil
+	}
+
+	return nil, fmt.Errorf("tonemap: unknown operator %q", name)
+}
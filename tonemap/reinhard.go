@@ -0,0 +1,39 @@
+package tonemap
+
+import (
+	"gotracer/vmath"
+)
+
+// Reinhard operator maps each channel with x / (1 + x).
+// Values never reach one, very bright colors tend to white but the image looks washed out.
+type ReinhardOperator struct{}
+
+func NewReinhardOperator() *ReinhardOperator {
+	return new(ReinhardOperator)
+}
+
+func (o *ReinhardOperator) Map(color vmath.Vec3) vmath.Vec3 {
+	return vmath.NewVec3(color.X/(1.0+color.X), color.Y/(1.0+color.Y), color.Z/(1.0+color.Z))
+}
+
+// Extended Reinhard operator maps each channel with x * (1 + x / white^2) / (1 + x).
+// Values equal or above the white point are mapped to one, which allows the bright areas to burn out in a controlled way.
+type ReinhardExtendedOperator struct {
+	// Smallest value mapped to pure white.
+	WhitePoint float64
+}
+
+func NewReinhardExtendedOperator(whitePoint float64) *ReinhardExtendedOperator {
+	var o = new(ReinhardExtendedOperator)
+	o.WhitePoint = whitePoint
+	return o
+}
+
+func (o *ReinhardExtendedOperator) Map(color vmath.Vec3) vmath.Vec3 {
+	var white2 = o.WhitePoint * o.WhitePoint
+	var f = func(x float64) float64 {
+		return clamp(x * (1.0 + x/white2) / (1.0 + x))
+	}
+
+	return vmath.NewVec3(f(color.X), f(color.Y), f(color.Z))
+}
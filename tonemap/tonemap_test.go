@@ -0,0 +1,92 @@
+package tonemap
+
+import (
+	"gotracer/vmath"
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-6
+
+// The linear and the power segments of the sRGB curve meet at the breakpoint.
+func TestSRGB(t *testing.T) {
+	var tests = []struct {
+		value    float64
+		expected float64
+	}{
+		{0.0, 0.0},
+		{-1.0, 0.0},
+		{0.001, 0.01292},
+		{0.0031308, 0.0404499},
+		{math.Nextafter(0.0031308, 1.0), 0.0404499},
+		{0.5, 0.7353570},
+		{1.0, 1.0},
+	}
+
+	for _, test := range tests {
+		if v := SRGB(test.value); math.Abs(v-test.expected) > epsilon {
+			t.Errorf("SRGB(%g) got %f, expected %f", test.value, v, test.expected)
+		}
+	}
+}
+
+// Values at the white point of the extended Reinhard and Hable operators are mapped to one, values below it are not.
+func TestWhitePoint(t *testing.T) {
+	for _, white := range []float64{1.0, 4.0, 11.2} {
+		var operators = map[string]Operator{
+			"reinhard-extended": NewReinhardExtendedOperator(white),
+			"hable":             NewHableOperator(white),
+		}
+
+		for name, operator := range operators {
+			if v := operator.Map(vmath.NewVec3(white, white, white)); math.Abs(v.X-1.0) > epsilon || v.X != v.Y || v.Y != v.Z {
+				t.Errorf("%s: white point %g got %s, expected 1", name, white, v.ToString())
+			}
+			if v := operator.Map(vmath.NewVec3(0.99*white, 0.0, 0.0)); v.X >= 1.0 {
+				t.Errorf("%s: value below the white point %g got %f, expected less than 1", name, white, v.X)
+			}
+		}
+	}
+}
+
+// Every operator maps increasing values into increasing values in the [0, 1] range.
+func TestOperatorMonotonic(t *testing.T) {
+	for _, name := range OperatorNames {
+		var operator, err = NewOperator(name, 4.0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var previous = operator.Map(vmath.Vec3{}).X
+		for i := 1; i <= 1000; i++ {
+			var v = operator.Map(vmath.NewVec3(float64(i)/1000.0, 0.0, 0.0)).X
+			if v < previous || v < 0.0 || v > 1.0 {
+				t.Errorf("%s: value %f got %f after %f", name, float64(i)/1000.0, v, previous)
+				break
+			}
+			previous = v
+		}
+	}
+}
+
+// Each EV stop of exposure doubles the values before the operator.
+func TestExposure(t *testing.T) {
+	var tests = []struct {
+		exposure float64
+		scale    float64
+	}{
+		{0.0, 1.0},
+		{1.0, 2.0},
+		{-1.0, 0.5},
+		{2.0, 4.0},
+	}
+
+	for _, test := range tests {
+		var mapper = NewToneMapper(NewLinearOperator(), test.exposure)
+		var v = mapper.Map(vmath.NewVec3(0.1, 0.2, 0.05))
+		var expected = vmath.NewVec3(SRGB(0.1*test.scale), SRGB(0.2*test.scale), SRGB(0.05*test.scale))
+		if v.Sub(expected).Length() > epsilon {
+			t.Errorf("exposure %+g got %s, expected %s", test.exposure, v.ToString(), expected.ToString())
+		}
+	}
+}
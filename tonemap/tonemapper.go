@@ -0,0 +1,46 @@
+package tonemap
+
+import (
+	"gotracer/vmath"
+	"math"
+)
+
+// ToneMapper converts the linear HDR values of the renderer into display values.
+//
+// The exposure is applied first, then the tone mapping operator and finally the sRGB transfer curve.
+type ToneMapper struct {
+	// Tone mapping operator.
+	Operator Operator
+
+	// Exposure in EV stops, each stop doubles the brightness of the image.
+	Exposure float64
+}
+
+// Create a tone mapper with a operator and exposure in EV stops.
+func NewToneMapper(operator Operator, exposure float64) *ToneMapper {
+	var t = new(ToneMapper)
+	t.Operator = operator
+	t.Exposure = exposure
+	return t
+}
+
+// Create the default tone mapper, linear clamp operator without any exposure change.
+func NewDefaultToneMapper() *ToneMapper {
+	return NewToneMapper(NewLinearOperator(), 0.0)
+}
+
+// Map a linear HDR color into a display color in the [0, 1] range encoded with the sRGB transfer curve.
+func (t *ToneMapper) Map(color vmath.Vec3) vmath.Vec3 {
+	color = t.Operator.Map(color.MulScalar(math.Pow(2.0, t.Exposure)))
+
+	return vmath.NewVec3(SRGB(color.X), SRGB(color.Y), SRGB(color.Z))
+}
+
+// Encode a linear value in the [0, 1] range with the sRGB transfer curve.
+func SRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return math.Max(0.0, 12.92*v)
+	}
+
+	return 1.055*math.Pow(v, 1.0/2.4) - 0.055
+}
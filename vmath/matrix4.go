@@ -1,6 +1,334 @@
 package vmath
 
+import (
+	"math"
+	"strconv"
+)
+
 // Matrix4 is used to store 4 by 4 matrices, useful to apply transforms
+//
+// Values are stored in row-major order, vectors are multiplied as columns on the right of the matrix (M * v).
+// Combined transforms are applied from right to left, (A * B) * v applies B first and then A.
 type Matrix4 struct {
 	Values [16]float64
 }
+
+// Create new matrix from values in row-major order.
+func NewMatrix4(values [16]float64) *Matrix4 {
+	var m = new(Matrix4)
+	m.Values = values
+	return m
+}
+
+// Create new identity matrix.
+func NewIdentityMatrix4() *Matrix4 {
+	var m = new(Matrix4)
+	m.Identity()
+	return m
+}
+
+// Create new translation matrix.
+func NewTranslationMatrix4(x float64, y float64, z float64) *Matrix4 {
+	return NewMatrix4([16]float64{
+		1, 0, 0, x,
+		0, 1, 0, y,
+		0, 0, 1, z,
+		0, 0, 0, 1,
+	})
+}
+
+// Create new scale matrix.
+func NewScaleMatrix4(x float64, y float64, z float64) *Matrix4 {
+	return NewMatrix4([16]float64{
+		x, 0, 0, 0,
+		0, y, 0, 0,
+		0, 0, z, 0,
+		0, 0, 0, 1,
+	})
+}
+
+// Create new rotation matrix around the X axis, angle in radians.
+func NewRotationXMatrix4(angle float64) *Matrix4 {
+	var c, s = math.Cos(angle), math.Sin(angle)
+	return NewMatrix4([16]float64{
+		1, 0, 0, 0,
+		0, c, -s, 0,
+		0, s, c, 0,
+		0, 0, 0, 1,
+	})
+}
+
+// Create new rotation matrix around the Y axis, angle in radians.
+func NewRotationYMatrix4(angle float64) *Matrix4 {
+	var c, s = math.Cos(angle), math.Sin(angle)
+	return NewMatrix4([16]float64{
+		c, 0, s, 0,
+		0, 1, 0, 0,
+		-s, 0, c, 0,
+		0, 0, 0, 1,
+	})
+}
+
+// Create new rotation matrix around the Z axis, angle in radians.
+func NewRotationZMatrix4(angle float64) *Matrix4 {
+	var c, s = math.Cos(angle), math.Sin(angle)
+	return NewMatrix4([16]float64{
+		c, -s, 0, 0,
+		s, c, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	})
+}
+
+// Create new rotation matrix around a arbitrary axis, angle in radians.
+// The axis does not need to be normalized.
+func NewRotationAxisMatrix4(axis Vec3, angle float64) *Matrix4 {
+	var a = axis.Normalize()
+	var c, s = math.Cos(angle), math.Sin(angle)
+	var t = 1.0 - c
+
+	return NewMatrix4([16]float64{
+		t*a.X*a.X + c, t*a.X*a.Y - s*a.Z, t*a.X*a.Z + s*a.Y, 0,
+		t*a.X*a.Y + s*a.Z, t*a.Y*a.Y + c, t*a.Y*a.Z - s*a.X, 0,
+		t*a.X*a.Z - s*a.Y, t*a.Y*a.Z + s*a.X, t*a.Z*a.Z + c, 0,
+		0, 0, 0, 1,
+	})
+}
+
+// Create new look-at matrix that transforms from world space into the space of a viewer (view matrix).
+// The viewer is placed at the eye position looking at the target along the -Z axis, with the up direction close to +Y.
+func NewLookAtMatrix4(eye Vec3, target Vec3, up Vec3) *Matrix4 {
+	var z = eye.Sub(target).Normalize()
+	var x = up.Cross(z).Normalize()
+	var y = z.Cross(x)
+
+	return NewMatrix4([16]float64{
+		x.X, x.Y, x.Z, -x.Dot(eye),
+		y.X, y.Y, y.Z, -y.Dot(eye),
+		z.X, z.Y, z.Z, -z.Dot(eye),
+		0, 0, 0, 1,
+	})
+}
+
+// Create new perspective projection matrix, the vertical field of view is in degrees.
+// Points between the near and far planes are mapped into the [-1, 1] range (OpenGL convention).
+func NewPerspectiveMatrix4(fov float64, aspect float64, near float64, far float64) *Matrix4 {
+	var f = 1.0 / math.Tan(fov*math.Pi/360.0)
+
+	return NewMatrix4([16]float64{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, (far + near) / (near - far), 2.0 * far * near / (near - far),
+		0, 0, -1, 0,
+	})
+}
+
+// Set the matrix to the identity.
+func (m *Matrix4) Identity() {
+	m.Values = [16]float64{
+		1, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// Get the value in a row and column.
+func (m *Matrix4) Get(row int, column int) float64 {
+	return m.Values[row*4+column]
+}
+
+// Multiply two matrices, returns a new matrix with the result of a * b.
+func MultiplyMatrices(a *Matrix4, b *Matrix4) *Matrix4 {
+	var r = new(Matrix4)
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			var sum = 0.0
+			for k := 0; k < 4; k++ {
+				sum += a.Values[i*4+k] * b.Values[k*4+j]
+			}
+			r.Values[i*4+j] = sum
+		}
+	}
+
+	return r
+}
+
+// Multiply this matrix by another matrix (m = m * b), the transform b is applied before m.
+func (m *Matrix4) Multiply(b *Matrix4) {
+	m.Values = MultiplyMatrices(m, b).Values
+}
+
+// Premultiply this matrix by another matrix (m = b * m), the transform b is applied after m.
+func (m *Matrix4) Premultiply(b *Matrix4) {
+	m.Values = MultiplyMatrices(b, m).Values
+}
+
+// Transpose the matrix.
+func (m *Matrix4) Transpose() {
+	for i := 0; i < 4; i++ {
+		for j := i + 1; j < 4; j++ {
+			m.Values[i*4+j], m.Values[j*4+i] = m.Values[j*4+i], m.Values[i*4+j]
+		}
+	}
+}
+
+// Calculate the determinant of the matrix.
+func (m *Matrix4) Determinant() float64 {
+	var v = &m.Values
+
+	var s0 = v[0]*v[5] - v[4]*v[1]
+	var s1 = v[0]*v[6] - v[4]*v[2]
+	var s2 = v[0]*v[7] - v[4]*v[3]
+	var s3 = v[1]*v[6] - v[5]*v[2]
+	var s4 = v[1]*v[7] - v[5]*v[3]
+	var s5 = v[2]*v[7] - v[6]*v[3]
+
+	var c5 = v[10]*v[15] - v[14]*v[11]
+	var c4 = v[9]*v[15] - v[13]*v[11]
+	var c3 = v[9]*v[14] - v[13]*v[10]
+	var c2 = v[8]*v[15] - v[12]*v[11]
+	var c1 = v[8]*v[14] - v[12]*v[10]
+	var c0 = v[8]*v[13] - v[12]*v[9]
+
+	return s0*c5 - s1*c4 + s2*c3 + s3*c2 - s4*c1 + s5*c0
+}
+
+// Calculate the inverse of the matrix.
+// Returns false if the matrix is singular and cannot be inverted.
+func (m *Matrix4) Inverse() (*Matrix4, bool) {
+	var v = &m.Values
+
+	var s0 = v[0]*v[5] - v[4]*v[1]
+	var s1 = v[0]*v[6] - v[4]*v[2]
+	var s2 = v[0]*v[7] - v[4]*v[3]
+	var s3 = v[1]*v[6] - v[5]*v[2]
+	var s4 = v[1]*v[7] - v[5]*v[3]
+	var s5 = v[2]*v[7] - v[6]*v[3]
+
+	var c5 = v[10]*v[15] - v[14]*v[11]
+	var c4 = v[9]*v[15] - v[13]*v[11]
+	var c3 = v[9]*v[14] - v[13]*v[10]
+	var c2 = v[8]*v[15] - v[12]*v[11]
+	var c1 = v[8]*v[14] - v[12]*v[10]
+	var c0 = v[8]*v[13] - v[12]*v[9]
+
+	var det = s0*c5 - s1*c4 + s2*c3 + s3*c2 - s4*c1 + s5*c0
+	if det == 0.0 || math.IsNaN(det) || math.IsInf(det, 0) {
+		return nil, false
+	}
+
+	var d = 1.0 / det
+
+	return NewMatrix4([16]float64{
+		(v[5]*c5 - v[6]*c4 + v[7]*c3) * d,
+		(-v[1]*c5 + v[2]*c4 - v[3]*c3) * d,
+		(v[13]*s5 - v[14]*s4 + v[15]*s3) * d,
+		(-v[9]*s5 + v[10]*s4 - v[11]*s3) * d,
+
+		(-v[4]*c5 + v[6]*c2 - v[7]*c1) * d,
+		(v[0]*c5 - v[2]*c2 + v[3]*c1) * d,
+		(-v[12]*s5 + v[14]*s2 - v[15]*s1) * d,
+		(v[8]*s5 - v[10]*s2 + v[11]*s1) * d,
+
+		(v[4]*c4 - v[5]*c2 + v[7]*c0) * d,
+		(-v[0]*c4 + v[1]*c2 - v[3]*c0) * d,
+		(v[12]*s4 - v[13]*s2 + v[15]*s0) * d,
+		(-v[8]*s4 + v[9]*s2 - v[11]*s0) * d,
+
+		(-v[4]*c3 + v[5]*c1 - v[6]*c0) * d,
+		(v[0]*c3 - v[1]*c1 + v[2]*c0) * d,
+		(-v[12]*s3 + v[13]*s1 - v[14]*s0) * d,
+		(v[8]*s3 - v[9]*s1 + v[10]*s0) * d,
+	}), true
+}
+
+// Transform a point, the translation is applied and the result is divided by the homogeneous coordinate.
+func (m *Matrix4) TransformPoint(p Vec3) Vec3 {
+	var v = &m.Values
+
+	var x = v[0]*p.X + v[1]*p.Y + v[2]*p.Z + v[3]
+	var y = v[4]*p.X + v[5]*p.Y + v[6]*p.Z + v[7]
+	var z = v[8]*p.X + v[9]*p.Y + v[10]*p.Z + v[11]
+	var w = v[12]*p.X + v[13]*p.Y + v[14]*p.Z + v[15]
+
+	if w != 1.0 && w != 0.0 {
+		return Vec3{X: x / w, Y: y / w, Z: z / w}
+	}
+
+	return Vec3{X: x, Y: y, Z: z}
+}
+
+// Transform a direction, the translation is ignored and the length of the direction is not preserved.
+func (m *Matrix4) TransformDirection(d Vec3) Vec3 {
+	var v = &m.Values
+
+	return Vec3{
+		X: v[0]*d.X + v[1]*d.Y + v[2]*d.Z,
+		Y: v[4]*d.X + v[5]*d.Y + v[6]*d.Z,
+		Z: v[8]*d.X + v[9]*d.Y + v[10]*d.Z,
+	}
+}
+
+// Transform a normal by the transpose of the matrix and normalize it.
+//
+// Normals are transformed by the inverse transpose of the transform, so this method should be called on the inverse of the transform.
+func (m *Matrix4) TransformNormal(n Vec3) Vec3 {
+	var v = &m.Values
+
+	var r = Vec3{
+		X: v[0]*n.X + v[4]*n.Y + v[8]*n.Z,
+		Y: v[1]*n.X + v[5]*n.Y + v[9]*n.Z,
+		Z: v[2]*n.X + v[6]*n.Y + v[10]*n.Z,
+	}
+
+	return r.Normalize()
+}
+
+// Clone this matrix into a new matrix.
+func (m *Matrix4) Clone() *Matrix4 {
+	return NewMatrix4(m.Values)
+}
+
+// Copy the content of another matrix to this one.
+func (m *Matrix4) Copy(b *Matrix4) {
+	m.Values = b.Values
+}
+
+// Convert the matrix to a string with one row per line.
+func (m *Matrix4) ToString() string {
+	var s = ""
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if j > 0 {
+				s += " "
+			}
+			s += strconv.FormatFloat(m.Values[i*4+j], 'f', -1, 64)
+		}
+		s += "\n"
+	}
+	return s
+}
+
+// Create new matrix that applies a scale, a rotation and a translation in this order.
+func NewComposeMatrix4(position Vec3, rotation *Quaternion, scale Vec3) *Matrix4 {
+	var m = rotation.ToMatrix4()
+	var v = &m.Values
+
+	v[0] *= scale.X
+	v[4] *= scale.X
+	v[8] *= scale.X
+	v[1] *= scale.Y
+	v[5] *= scale.Y
+	v[9] *= scale.Y
+	v[2] *= scale.Z
+	v[6] *= scale.Z
+	v[10] *= scale.Z
+
+	v[3] = position.X
+	v[7] = position.Y
+	v[11] = position.Z
+
+	return m
+}
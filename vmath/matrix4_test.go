@@ -0,0 +1,133 @@
+package vmath
+
+import (
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+func nearVec3(a Vec3, b Vec3) bool {
+	return a.Sub(b).Length() < epsilon
+}
+
+func nearMatrix4(a *Matrix4, b *Matrix4) bool {
+	for i := 0; i < 16; i++ {
+		if math.Abs(a.Values[i]-b.Values[i]) > epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+// Transforms used by the matrix tests, with rotations, non-uniform scales and translations.
+var testTransforms = map[string]*Matrix4{
+	"identity":    NewIdentityMatrix4(),
+	"translation": NewTranslationMatrix4(1.0, -2.0, 3.5),
+	"scale":       NewScaleMatrix4(2.0, 0.5, -3.0),
+	"rotationX":   NewRotationXMatrix4(0.3),
+	"rotationY":   NewRotationYMatrix4(-1.2),
+	"rotationZ":   NewRotationZMatrix4(2.5),
+	"axis":        NewRotationAxisMatrix4(NewVec3(1.0, 2.0, -1.0), 0.7),
+	"compose":     NewComposeMatrix4(NewVec3(4.0, 0.0, -1.0), NewQuaternionFromEuler(0.4, -0.8, 1.3), NewVec3(1.0, 3.0, 0.25)),
+}
+
+func TestMatrix4Inverse(t *testing.T) {
+	for name, m := range testTransforms {
+		var inverse, ok = m.Inverse()
+		if !ok {
+			t.Errorf("%s: matrix is not invertible", name)
+			continue
+		}
+
+		if !nearMatrix4(MultiplyMatrices(m, inverse), NewIdentityMatrix4()) {
+			t.Errorf("%s: m * inverse is not the identity\n%s", name, MultiplyMatrices(m, inverse).ToString())
+		}
+		if !nearMatrix4(MultiplyMatrices(inverse, m), NewIdentityMatrix4()) {
+			t.Errorf("%s: inverse * m is not the identity\n%s", name, MultiplyMatrices(inverse, m).ToString())
+		}
+		if math.Abs(m.Determinant()*inverse.Determinant()-1.0) > epsilon {
+			t.Errorf("%s: determinant of the inverse is not the reciprocal", name)
+		}
+	}
+}
+
+func TestMatrix4InverseSingular(t *testing.T) {
+	var singular = []*Matrix4{
+		NewScaleMatrix4(1.0, 0.0, 1.0),
+		NewMatrix4([16]float64{}),
+		NewMatrix4([16]float64{1, 2, 3, 0, 2, 4, 6, 0, 0, 0, 1, 0, 0, 0, 0, 1}),
+	}
+
+	for i, m := range singular {
+		if _, ok := m.Inverse(); ok {
+			t.Errorf("singular matrix %d was inverted", i)
+		}
+	}
+}
+
+func TestMatrix4Transform(t *testing.T) {
+	var tests = []struct {
+		name      string
+		m         *Matrix4
+		point     Vec3
+		direction Vec3
+	}{
+		{"translation", NewTranslationMatrix4(1.0, 2.0, 3.0), NewVec3(2.0, 3.0, 4.0), NewVec3(1.0, 1.0, 1.0)},
+		{"scale", NewScaleMatrix4(2.0, 3.0, 4.0), NewVec3(2.0, 3.0, 4.0), NewVec3(2.0, 3.0, 4.0)},
+		{"rotationZ", NewRotationZMatrix4(math.Pi / 2.0), NewVec3(-1.0, 1.0, 1.0), NewVec3(-1.0, 1.0, 1.0)},
+	}
+
+	// Transform of the point (1, 1, 1), translations only move points
+	for _, test := range tests {
+		var one = NewVec3(1.0, 1.0, 1.0)
+		if p := test.m.TransformPoint(one); !nearVec3(p, test.point) {
+			t.Errorf("%s: point transformed into %s, expected %s", test.name, p.ToString(), test.point.ToString())
+		}
+		if d := test.m.TransformDirection(one); !nearVec3(d, test.direction) {
+			t.Errorf("%s: direction transformed into %s, expected %s", test.name, d.ToString(), test.direction.ToString())
+		}
+	}
+}
+
+// Normals transformed by the inverse stay perpendicular to the transformed surface.
+func TestMatrix4TransformNormal(t *testing.T) {
+	var tangent = NewVec3(1.0, -1.0, 0.0)
+	var normal = NewVec3(1.0, 1.0, 0.0).Normalize()
+
+	for name, m := range testTransforms {
+		var inverse, _ = m.Inverse()
+		var n = inverse.TransformNormal(normal)
+
+		if math.Abs(n.Length()-1.0) > epsilon {
+			t.Errorf("%s: transformed normal is not normalized", name)
+		}
+		if math.Abs(n.Dot(m.TransformDirection(tangent))) > epsilon {
+			t.Errorf("%s: transformed normal is not perpendicular to the surface", name)
+		}
+	}
+}
+
+func TestMatrix4Multiply(t *testing.T) {
+	var a = testTransforms["translation"]
+	var b = testTransforms["axis"]
+	var p = NewVec3(0.5, -1.5, 2.0)
+
+	// The product applies b first and then a
+	var product = MultiplyMatrices(a, b)
+	if !nearVec3(product.TransformPoint(p), a.TransformPoint(b.TransformPoint(p))) {
+		t.Errorf("product does not apply the transforms in order")
+	}
+
+	var m = a.Clone()
+	m.Multiply(b)
+	if !nearMatrix4(m, product) {
+		t.Errorf("Multiply is not a * b")
+	}
+
+	m = b.Clone()
+	m.Premultiply(a)
+	if !nearMatrix4(m, product) {
+		t.Errorf("Premultiply is not a * b")
+	}
+}
@@ -0,0 +1,291 @@
+package vmath
+
+import (
+	"math"
+	"strconv"
+)
+
+// Quaternion represents a rotation in 3D space, stored as the vector part (X, Y, Z) and the scalar part W.
+//
+// Rotation quaternions should be normalized, rotations are combined by multiplication and
+// interpolated with Slerp without the gimbal lock problems of euler angles.
+type Quaternion struct {
+	X float64
+	Y float64
+	Z float64
+	W float64
+}
+
+// Create new quaternion with values.
+func NewQuaternion(x float64, y float64, z float64, w float64) *Quaternion {
+	var q = new(Quaternion)
+	q.X = x
+	q.Y = y
+	q.Z = z
+	q.W = w
+	return q
+}
+
+// Create new identity quaternion, represents no rotation.
+func NewIdentityQuaternion() *Quaternion {
+	return NewQuaternion(0.0, 0.0, 0.0, 1.0)
+}
+
+// Create new quaternion from a rotation around a axis, angle in radians.
+// The axis does not need to be normalized.
+func NewQuaternionFromAxisAngle(axis Vec3, angle float64) *Quaternion {
+	var a = axis.Normalize()
+	var s = math.Sin(angle / 2.0)
+	return NewQuaternion(a.X*s, a.Y*s, a.Z*s, math.Cos(angle/2.0))
+}
+
+// Create new quaternion from euler angles in radians.
+// The rotations are applied around the X, Y and Z world axes in this order (equivalent to Rz * Ry * Rx).
+func NewQuaternionFromEuler(x float64, y float64, z float64) *Quaternion {
+	var cx, sx = math.Cos(x / 2.0), math.Sin(x / 2.0)
+	var cy, sy = math.Cos(y / 2.0), math.Sin(y / 2.0)
+	var cz, sz = math.Cos(z / 2.0), math.Sin(z / 2.0)
+
+	return NewQuaternion(
+		sx*cy*cz-cx*sy*sz,
+		cx*sy*cz+sx*cy*sz,
+		cx*cy*sz-sx*sy*cz,
+		cx*cy*cz+sx*sy*sz,
+	)
+}
+
+// Create new quaternion from the rotation part of a matrix.
+// The upper 3x3 part of the matrix should be a pure rotation (orthonormal without scale).
+func NewQuaternionFromMatrix4(m *Matrix4) *Quaternion {
+	var v = &m.Values
+	var trace = v[0] + v[5] + v[10]
+
+	var q = new(Quaternion)
+
+	if trace > 0 {
+		var s = 0.5 / math.Sqrt(trace+1.0)
+		q.W = 0.25 / s
+		q.X = (v[9] - v[6]) * s
+		q.Y = (v[2] - v[8]) * s
+		q.Z = (v[4] - v[1]) * s
+	} else if v[0] > v[5] && v[0] > v[10] {
+		var s = 2.0 * math.Sqrt(1.0+v[0]-v[5]-v[10])
+		q.W = (v[9] - v[6]) / s
+		q.X = 0.25 * s
+		q.Y = (v[1] + v[4]) / s
+		q.Z = (v[2] + v[8]) / s
+	} else if v[5] > v[10] {
+		var s = 2.0 * math.Sqrt(1.0+v[5]-v[0]-v[10])
+		q.W = (v[2] - v[8]) / s
+		q.X = (v[1] + v[4]) / s
+		q.Y = 0.25 * s
+		q.Z = (v[6] + v[9]) / s
+	} else {
+		var s = 2.0 * math.Sqrt(1.0+v[10]-v[0]-v[5])
+		q.W = (v[4] - v[1]) / s
+		q.X = (v[2] + v[8]) / s
+		q.Y = (v[6] + v[9]) / s
+		q.Z = 0.25 * s
+	}
+
+	return q
+}
+
+// Create new quaternion with the rotation from the direction a to the direction b.
+func NewQuaternionFromUnitVectors(a Vec3, b Vec3) *Quaternion {
+	var from = a.Normalize()
+	var to = b.Normalize()
+	var r = from.Dot(to) + 1.0
+
+	var q *Quaternion
+
+	if r < 1e-12 {
+		// Opposite directions, rotate 180 degrees around any perpendicular axis
+		if math.Abs(from.X) > math.Abs(from.Z) {
+			q = NewQuaternion(-from.Y, from.X, 0.0, 0.0)
+		} else {
+			q = NewQuaternion(0.0, -from.Z, from.Y, 0.0)
+		}
+	} else {
+		var c = from.Cross(to)
+		q = NewQuaternion(c.X, c.Y, c.Z, r)
+	}
+
+	q.Normalize()
+	return q
+}
+
+// Set value of the quaternion.
+func (q *Quaternion) Set(x float64, y float64, z float64, w float64) {
+	q.X = x
+	q.Y = y
+	q.Z = z
+	q.W = w
+}
+
+// Multiply two quaternions, returns a new quaternion with the result of a * b.
+// The rotation of the result applies the rotation b first and then the rotation a.
+func MultiplyQuaternions(a *Quaternion, b *Quaternion) *Quaternion {
+	return NewQuaternion(
+		a.W*b.X+a.X*b.W+a.Y*b.Z-a.Z*b.Y,
+		a.W*b.Y-a.X*b.Z+a.Y*b.W+a.Z*b.X,
+		a.W*b.Z+a.X*b.Y-a.Y*b.X+a.Z*b.W,
+		a.W*b.W-a.X*b.X-a.Y*b.Y-a.Z*b.Z,
+	)
+}
+
+// Multiply this quaternion by another quaternion (q = q * b), the rotation b is applied before q.
+func (q *Quaternion) Multiply(b *Quaternion) {
+	*q = *MultiplyQuaternions(q, b)
+}
+
+// Premultiply this quaternion by another quaternion (q = b * q), the rotation b is applied after q.
+func (q *Quaternion) Premultiply(b *Quaternion) {
+	*q = *MultiplyQuaternions(b, q)
+}
+
+// Dot product between two quaternions.
+func DotQuaternions(a *Quaternion, b *Quaternion) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z + a.W*b.W
+}
+
+// Length of the quaternion.
+func (q *Quaternion) Length() float64 {
+	return math.Sqrt(DotQuaternions(q, q))
+}
+
+// Normalize the quaternion, zero length quaternions are set to the identity.
+func (q *Quaternion) Normalize() {
+	var l = q.Length()
+	if l == 0.0 {
+		q.Set(0.0, 0.0, 0.0, 1.0)
+		return
+	}
+
+	q.X /= l
+	q.Y /= l
+	q.Z /= l
+	q.W /= l
+}
+
+// Conjugate the quaternion, for normalized quaternions the conjugate is the inverse rotation.
+func (q *Quaternion) Conjugate() {
+	q.X = -q.X
+	q.Y = -q.Y
+	q.Z = -q.Z
+}
+
+// Invert the quaternion.
+func (q *Quaternion) Invert() {
+	var l = DotQuaternions(q, q)
+	q.Conjugate()
+	if l != 0.0 {
+		q.X /= l
+		q.Y /= l
+		q.Z /= l
+		q.W /= l
+	}
+}
+
+// Rotate a vector by the quaternion.
+func (q *Quaternion) RotateVector(v Vec3) Vec3 {
+	// t = 2 * cross(q.xyz, v), v' = v + w * t + cross(q.xyz, t)
+	var u = Vec3{X: q.X, Y: q.Y, Z: q.Z}
+	var t = u.Cross(v).MulScalar(2.0)
+
+	return v.AddScaled(t, q.W).Add(u.Cross(t))
+}
+
+// Get the axis and angle (in radians) of the rotation.
+// For rotations close to zero the X axis is returned.
+func (q *Quaternion) ToAxisAngle() (Vec3, float64) {
+	var n = q.Clone()
+	n.Normalize()
+	if n.W < 0 {
+		n.Set(-n.X, -n.Y, -n.Z, -n.W)
+	}
+
+	var angle = 2.0 * math.Acos(math.Min(n.W, 1.0))
+	var s = math.Sqrt(1.0 - n.W*n.W)
+
+	if s < 1e-9 {
+		return Vec3{X: 1.0}, angle
+	}
+
+	return Vec3{X: n.X / s, Y: n.Y / s, Z: n.Z / s}, angle
+}
+
+// Get the euler angles (in radians) of the rotation, using the same order as NewQuaternionFromEuler.
+// The Y angle is in the [-pi/2, pi/2] range, when it is close to the limits (gimbal lock) the Z angle is set to zero.
+func (q *Quaternion) ToEuler() (float64, float64, float64) {
+	var m = q.ToMatrix4()
+	var v = &m.Values
+
+	var y = math.Asin(math.Max(-1.0, math.Min(1.0, -v[8])))
+
+	if math.Abs(v[8]) < 0.9999999 {
+		return math.Atan2(v[9], v[10]), y, math.Atan2(v[4], v[0])
+	}
+
+	return math.Atan2(-v[6], v[5]), y, 0.0
+}
+
+// Create a rotation matrix from the quaternion.
+func (q *Quaternion) ToMatrix4() *Matrix4 {
+	var x2, y2, z2 = q.X + q.X, q.Y + q.Y, q.Z + q.Z
+	var xx, xy, xz = q.X * x2, q.X * y2, q.X * z2
+	var yy, yz, zz = q.Y * y2, q.Y * z2, q.Z * z2
+	var wx, wy, wz = q.W * x2, q.W * y2, q.W * z2
+
+	return NewMatrix4([16]float64{
+		1.0 - (yy + zz), xy - wz, xz + wy, 0,
+		xy + wz, 1.0 - (xx + zz), yz - wx, 0,
+		xz - wy, yz + wx, 1.0 - (xx + yy), 0,
+		0, 0, 0, 1,
+	})
+}
+
+// Spherical linear interpolation between two rotations, t in the [0, 1] range.
+// The shortest path between the rotations is used.
+func Slerp(a *Quaternion, b *Quaternion, t float64) *Quaternion {
+	var cosHalfTheta = DotQuaternions(a, b)
+	var target = b.Clone()
+
+	// Quaternions q and -q represent the same rotation, use the closest one
+	if cosHalfTheta < 0 {
+		target.Set(-b.X, -b.Y, -b.Z, -b.W)
+		cosHalfTheta = -cosHalfTheta
+	}
+
+	var wa, wb float64
+
+	if cosHalfTheta > 0.9995 {
+		// Rotations are very close, linear interpolation avoids the division by zero
+		wa = 1.0 - t
+		wb = t
+	} else {
+		var halfTheta = math.Acos(cosHalfTheta)
+		var sinHalfTheta = math.Sin(halfTheta)
+		wa = math.Sin((1.0-t)*halfTheta) / sinHalfTheta
+		wb = math.Sin(t*halfTheta) / sinHalfTheta
+	}
+
+	var r = NewQuaternion(a.X*wa+target.X*wb, a.Y*wa+target.Y*wb, a.Z*wa+target.Z*wb, a.W*wa+target.W*wb)
+	r.Normalize()
+	return r
+}
+
+// Clone this quaternion into a new quaternion.
+func (q *Quaternion) Clone() *Quaternion {
+	return NewQuaternion(q.X, q.Y, q.Z, q.W)
+}
+
+// Copy the content of another quaternion to this one.
+func (q *Quaternion) Copy(b *Quaternion) {
+	*q = *b
+}
+
+// Convert the quaternion to a string.
+func (q *Quaternion) ToString() string {
+	return "(" + strconv.FormatFloat(q.X, 'f', -1, 64) + ", " + strconv.FormatFloat(q.Y, 'f', -1, 64) + ", " + strconv.FormatFloat(q.Z, 'f', -1, 64) + ", " + strconv.FormatFloat(q.W, 'f', -1, 64) + ")"
+}
@@ -0,0 +1,140 @@
+package vmath
+
+import (
+	"math"
+	"testing"
+)
+
+func nearQuaternion(a *Quaternion, b *Quaternion) bool {
+	// Quaternions q and -q represent the same rotation
+	return math.Abs(math.Abs(DotQuaternions(a, b))-1.0) < epsilon
+}
+
+func TestQuaternionAxisAngle(t *testing.T) {
+	var tests = []struct {
+		axis     Vec3
+		angle    float64
+		v        Vec3
+		expected Vec3
+	}{
+		{NewVec3(0.0, 0.0, 1.0), math.Pi / 2.0, NewVec3(1.0, 0.0, 0.0), NewVec3(0.0, 1.0, 0.0)},
+		{NewVec3(0.0, 1.0, 0.0), math.Pi / 2.0, NewVec3(0.0, 0.0, 1.0), NewVec3(1.0, 0.0, 0.0)},
+		{NewVec3(1.0, 0.0, 0.0), math.Pi, NewVec3(0.0, 1.0, 0.0), NewVec3(0.0, -1.0, 0.0)},
+		{NewVec3(2.0, 2.0, 2.0), 2.0 * math.Pi / 3.0, NewVec3(1.0, 0.0, 0.0), NewVec3(0.0, 1.0, 0.0)},
+	}
+
+	for i, test := range tests {
+		var q = NewQuaternionFromAxisAngle(test.axis, test.angle)
+
+		if r := q.RotateVector(test.v); !nearVec3(r, test.expected) {
+			t.Errorf("%d: rotated vector %s, expected %s", i, r.ToString(), test.expected.ToString())
+		}
+		if r := q.ToMatrix4().TransformDirection(test.v); !nearVec3(r, test.expected) {
+			t.Errorf("%d: rotation matrix gives %s, expected %s", i, r.ToString(), test.expected.ToString())
+		}
+		if !nearMatrix4(q.ToMatrix4(), NewRotationAxisMatrix4(test.axis, test.angle)) {
+			t.Errorf("%d: rotation matrix is different from the axis-angle matrix", i)
+		}
+
+		var axis, angle = q.ToAxisAngle()
+		if !nearVec3(axis, test.axis.Normalize()) || math.Abs(angle-test.angle) > epsilon {
+			t.Errorf("%d: axis-angle is %s %f, expected %s %f", i, axis.ToString(), angle, test.axis.Normalize().ToString(), test.angle)
+		}
+	}
+}
+
+func TestQuaternionEuler(t *testing.T) {
+	var tests = [][3]float64{
+		{0.0, 0.0, 0.0},
+		{0.3, 0.0, 0.0},
+		{0.0, -0.7, 0.0},
+		{0.0, 0.0, 2.1},
+		{0.4, -0.8, 1.3},
+		{-2.5, 1.2, -0.3},
+	}
+
+	for _, angles := range tests {
+		var q = NewQuaternionFromEuler(angles[0], angles[1], angles[2])
+
+		// Rotations around the X, Y and Z axes in this order
+		var m = NewRotationZMatrix4(angles[2])
+		m.Multiply(NewRotationYMatrix4(angles[1]))
+		m.Multiply(NewRotationXMatrix4(angles[0]))
+		if !nearMatrix4(q.ToMatrix4(), m) {
+			t.Errorf("%v: rotation is not Rz * Ry * Rx", angles)
+		}
+
+		var x, y, z = q.ToEuler()
+		if !nearQuaternion(NewQuaternionFromEuler(x, y, z), q) {
+			t.Errorf("%v: euler angles %f %f %f give a different rotation", angles, x, y, z)
+		}
+
+		if !nearQuaternion(NewQuaternionFromMatrix4(m), q) {
+			t.Errorf("%v: quaternion from the rotation matrix is different", angles)
+		}
+	}
+}
+
+func TestQuaternionMultiply(t *testing.T) {
+	var a = NewQuaternionFromEuler(0.4, -0.8, 1.3)
+	var b = NewQuaternionFromAxisAngle(NewVec3(1.0, 2.0, -1.0), 0.7)
+	var v = NewVec3(0.5, -1.5, 2.0)
+
+	// The product applies b first and then a
+	var product = MultiplyQuaternions(a, b)
+	if !nearVec3(product.RotateVector(v), a.RotateVector(b.RotateVector(v))) {
+		t.Errorf("product does not apply the rotations in order")
+	}
+
+	var inverse = a.Clone()
+	inverse.Invert()
+	if !nearQuaternion(MultiplyQuaternions(a, inverse), NewIdentityQuaternion()) {
+		t.Errorf("a * inverse is not the identity")
+	}
+
+	var from = NewVec3(1.0, 2.0, 3.0).Normalize()
+	var to = NewVec3(-2.0, 0.5, 1.0).Normalize()
+	if r := NewQuaternionFromUnitVectors(from, to).RotateVector(from); !nearVec3(r, to) {
+		t.Errorf("rotation between unit vectors gives %s, expected %s", r.ToString(), to.ToString())
+	}
+}
+
+func TestQuaternionSlerp(t *testing.T) {
+	var axis = NewVec3(0.0, 1.0, 0.0)
+	var tests = []struct {
+		a, b float64
+	}{
+		{0.0, math.Pi / 2.0},
+		{-1.0, 2.0},
+		{0.5, 0.5 + 1e-6},
+	}
+
+	for _, test := range tests {
+		var a = NewQuaternionFromAxisAngle(axis, test.a)
+		var b = NewQuaternionFromAxisAngle(axis, test.b)
+
+		if !nearQuaternion(Slerp(a, b, 0.0), a) || !nearQuaternion(Slerp(a, b, 1.0), b) {
+			t.Errorf("%v: slerp does not start and end in the rotations", test)
+		}
+
+		for _, f := range []float64{0.25, 0.5, 0.75} {
+			var r = Slerp(a, b, f)
+			var expected = NewQuaternionFromAxisAngle(axis, test.a+(test.b-test.a)*f)
+
+			if math.Abs(r.Length()-1.0) > epsilon {
+				t.Errorf("%v: slerp at %f is not normalized", test, f)
+			}
+			if !nearQuaternion(r, expected) {
+				t.Errorf("%v: slerp at %f is not the rotation at the same fraction of the angle", test, f)
+			}
+		}
+	}
+
+	// The shortest path is used, q and -q are the same rotation
+	var a = NewQuaternionFromAxisAngle(axis, 0.2)
+	var b = NewQuaternionFromAxisAngle(axis, 0.6)
+	b.Set(-b.X, -b.Y, -b.Z, -b.W)
+	if !nearQuaternion(Slerp(a, b, 0.5), NewQuaternionFromAxisAngle(axis, 0.4)) {
+		t.Errorf("slerp does not use the shortest path")
+	}
+}
@@ -0,0 +1,217 @@
+package vmath
+
+import (
+	"gotracer/sampler"
+	"math"
+	"strconv"
+)
+
+// Vec3 is a 3D vector represented by x,y,z values.
+//
+// Vectors are values, operations return a new vector instead of modifying the receiver so they can be kept on the stack without allocations.
+type Vec3 struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+// Create new vector with values.
+func NewVec3(x float64, y float64, z float64) Vec3 {
+	return Vec3{X: x, Y: y, Z: z}
+}
+
+// Create new vector with random values in the range [min, max).
+func RandomVec3(random *sampler.Random, min float64, max float64) Vec3 {
+	var delta = max - min
+	return Vec3{X: random.Float64()*delta + min, Y: random.Float64()*delta + min, Z: random.Float64()*delta + min}
+}
+
+// Add vectors
+func (a Vec3) Add(b Vec3) Vec3 {
+	return Vec3{X: a.X + b.X, Y: a.Y + b.Y, Z: a.Z + b.Z}
+}
+
+// Subtract vectors
+func (a Vec3) Sub(b Vec3) Vec3 {
+	return Vec3{X: a.X - b.X, Y: a.Y - b.Y, Z: a.Z - b.Z}
+}
+
+// Multiply vectors component by component
+func (a Vec3) Mul(b Vec3) Vec3 {
+	return Vec3{X: a.X * b.X, Y: a.Y * b.Y, Z: a.Z * b.Z}
+}
+
+// Divide vectors component by component
+func (a Vec3) Divide(b Vec3) Vec3 {
+	return Vec3{X: a.X / b.X, Y: a.Y / b.Y, Z: a.Z / b.Z}
+}
+
+// Multiply vector by scalar
+func (a Vec3) MulScalar(b float64) Vec3 {
+	return Vec3{X: a.X * b, Y: a.Y * b, Z: a.Z * b}
+}
+
+// Divide vector by scalar
+func (a Vec3) DivideScalar(b float64) Vec3 {
+	return Vec3{X: a.X / b, Y: a.Y / b, Z: a.Z / b}
+}
+
+// Multiply vector by a scalar and add the result to this vector (a + b * s).
+func (a Vec3) AddScaled(b Vec3, s float64) Vec3 {
+	return Vec3{X: a.X + b.X*s, Y: a.Y + b.Y*s, Z: a.Z + b.Z*s}
+}
+
+// Negate the vector
+func (a Vec3) Negate() Vec3 {
+	return Vec3{X: -a.X, Y: -a.Y, Z: -a.Z}
+}
+
+// Apply sqrt to the individual components of the vector
+func (a Vec3) Sqrt() Vec3 {
+	return Vec3{X: math.Sqrt(a.X), Y: math.Sqrt(a.Y), Z: math.Sqrt(a.Z)}
+}
+
+// Dot product between two vectors
+func (a Vec3) Dot(b Vec3) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+// Cross product between two vectors
+func (a Vec3) Cross(b Vec3) Vec3 {
+	return Vec3{X: a.Y*b.Z - a.Z*b.Y, Y: a.Z*b.X - a.X*b.Z, Z: a.X*b.Y - a.Y*b.X}
+}
+
+// Length of the vector
+func (a Vec3) Length() float64 {
+	return math.Sqrt(a.X*a.X + a.Y*a.Y + a.Z*a.Z)
+}
+
+// Squared length of the vector (useful for comparisons, avoids the squaredroot calc).
+func (a Vec3) SquaredLength() float64 {
+	return a.X*a.X + a.Y*a.Y + a.Z*a.Z
+}
+
+// Unit length vector with the same direction as this one, zero length vectors are returned unchanged.
+func (a Vec3) Normalize() Vec3 {
+	var l = a.Length()
+	if l == 0.0 {
+		return a
+	}
+	return Vec3{X: a.X / l, Y: a.Y / l, Z: a.Z / l}
+}
+
+// Get the value of a axis (0 for X, 1 for Y and 2 for Z).
+func (a Vec3) Axis(axis int) float64 {
+	if axis == 0 {
+		return a.X
+	} else if axis == 1 {
+		return a.Y
+	}
+	return a.Z
+}
+
+// Largest component of the vector.
+func (a Vec3) MaxComponent() float64 {
+	return math.Max(a.X, math.Max(a.Y, a.Z))
+}
+
+// Component wise minimum of two vectors.
+func (a Vec3) Min(b Vec3) Vec3 {
+	return Vec3{X: math.Min(a.X, b.X), Y: math.Min(a.Y, b.Y), Z: math.Min(a.Z, b.Z)}
+}
+
+// Component wise maximum of two vectors.
+func (a Vec3) Max(b Vec3) Vec3 {
+	return Vec3{X: math.Max(a.X, b.X), Y: math.Max(a.Y, b.Y), Z: math.Max(a.Z, b.Z)}
+}
+
+// Check if all the components of the vector are zero.
+func (a Vec3) IsZero() bool {
+	return a.X == 0.0 && a.Y == 0.0 && a.Z == 0.0
+}
+
+// Generate a string with the vector values
+func (a Vec3) ToString() string {
+	return "(" + strconv.FormatFloat(a.X, 'f', -1, 64) + ", " + strconv.FormatFloat(a.Y, 'f', -1, 64) + ", " + strconv.FormatFloat(a.Z, 'f', -1, 64) + ")"
+}
+
+// Calculate the reflection of a vector relative to a normal vector.
+func Reflect(v Vec3, n Vec3) Vec3 {
+	return v.Sub(n.MulScalar(2.0 * v.Dot(n)))
+}
+
+// Calculate the refracted vector of a vector relative to a normal vector.
+// This calculation is done using the snells law. Ni is the initial refractive indice and No is the out refraction indice.
+// The refractionRatio parameters is calculated from Ni/No.
+// Returns false if the ray is totally reflected.
+func Refract(v Vec3, normal Vec3, refractionRatio float64) (Vec3, bool) {
+	var uv = v.Normalize()
+	var dt = uv.Dot(normal)
+	var discriminant = 1.0 - refractionRatio*refractionRatio*(1-dt*dt)
+
+	if discriminant > 0 {
+		var refracted = uv.Sub(normal.MulScalar(dt)).MulScalar(refractionRatio)
+		return refracted.Sub(normal.MulScalar(math.Sqrt(discriminant))), true
+	}
+
+	return Vec3{}, false
+}
+
+// Real glass has reflectivity that varies with angle look at a window at a steep angle and it becomes a mirror.
+// The behavior can be approximated by Christophe Schlick polynomial aproximation.
+func Schlick(cosine float64, reflectiveIndex float64) float64 {
+	var r = math.Pow((1-reflectiveIndex)/(1+reflectiveIndex), 2)
+	return r + (1-r)*math.Pow(1-cosine, 5)
+}
+
+// Calculate a random point inside of a unit disk, used to get ray origins on a disk around lookfrom rather than from a point.
+// Uses the concentric mapping of Shirley and Chiu, the stratification of the sampler values is preserved.
+func RandomInUnitDisk(s sampler.Sampler) Vec3 {
+	var u, v = s.Get2D()
+	var a = 2.0*u - 1.0
+	var b = 2.0*v - 1.0
+
+	if a == 0.0 && b == 0.0 {
+		return Vec3{}
+	}
+
+	var r, phi float64
+	if math.Abs(a) > math.Abs(b) {
+		r = a
+		phi = (math.Pi / 4.0) * (b / a)
+	} else {
+		r = b
+		phi = (math.Pi / 2.0) - (math.Pi/4.0)*(a/b)
+	}
+
+	return Vec3{X: r * math.Cos(phi), Y: r * math.Sin(phi)}
+}
+
+// Calculate a random vector inside of a unit sphere, uniformly distributed in its volume.
+func RandomInUnitSphere(s sampler.Sampler) Vec3 {
+	var direction = RandomUnitVector(s)
+	return direction.MulScalar(math.Cbrt(s.Get1D()))
+}
+
+// Calculate a random unitary vector, uniformly distributed in the surface of a sphere.
+func RandomUnitVector(s sampler.Sampler) Vec3 {
+	var u, v = s.Get2D()
+	var z = u*2.0 - 1.0
+	var a = v * 2.0 * math.Pi
+	var r = math.Sqrt(1.0 - z*z)
+
+	return Vec3{X: r * math.Cos(a), Y: r * math.Sin(a), Z: z}
+}
+
+// Create two unitary vectors perpendicular to a normalized vector n, forming a orthonormal basis with it.
+func OrthonormalBasis(n Vec3) (Vec3, Vec3) {
+	var a = Vec3{X: 1.0}
+	if math.Abs(n.X) > 0.9 {
+		a = Vec3{Y: 1.0}
+	}
+
+	var v = n.Cross(a).Normalize()
+	var u = v.Cross(n)
+
+	return u, v
+}
@@ -0,0 +1,193 @@
+//go:build !headless
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"gotracer/camera"
+	"gotracer/framebuffer"
+	"gotracer/geometry"
+	"gotracer/sampler"
+	"gotracer/scenefile"
+	"log"
+	"math"
+	"time"
+
+	"github.com/gopxl/pixel/v2"
+	"github.com/gopxl/pixel/v2/pixelgl"
+	"golang.org/x/image/colornames"
+)
+
+// Open the preview window and render the scene interactively until the window is closed.
+// The window library (GLFW and OpenGL) is only linked in builds without the headless tag.
+func RunWindow(settings *RenderSettings, sceneFile string) error {
+	pixelgl.Run(func() {
+		run(settings, sceneFile)
+	})
+	return nil
+}
+
+func run(settings *RenderSettings, sceneFile string) {
+	var bounds = pixel.R(0, 0, float64(settings.Width), float64(settings.Height))
+	var windowBounds = pixel.R(0, 0, float64(settings.Width)*settings.Upscale, float64(settings.Height)*settings.Upscale)
+
+	var scene = CreateScene(settings.Seed)
+	var camera = camera.NewCameraDefocusBounds(bounds)
+
+	if sceneFile != "" {
+		var err error
+		scene, camera, err = scenefile.Load(sceneFile, bounds)
+		CheckError(err)
+	}
+
+	CreateCopies(scene, camera, settings)
+
+	Buffer = framebuffer.NewFramebufferBounds(bounds)
+
+	var toneMapper, err = settings.ToneMapper()
+	CheckError(err)
+
+	var sampler sampler.Sampler
+	sampler, err = settings.NewSampler()
+	CheckError(err)
+
+	var config = pixelgl.WindowConfig{
+		Resizable:   false,
+		Undecorated: false,
+		VSync:       false,
+		Title:       "Gotracer",
+		Bounds:      windowBounds}
+
+	var window *pixelgl.Window
+	window, err = pixelgl.NewWindow(config)
+
+	CheckError(err)
+
+	// Camera movement is scaled by the wall clock time between input updates
+	var lastInput = time.Now()
+
+	for !window.Closed() {
+
+		var start = time.Now()
+
+		window.Clear(colornames.Black)
+
+		// Without temporal filter only the last frame is presented
+		if !settings.TemporalFilter {
+			Buffer.Reset()
+		}
+
+		// Frames cancelled by the camera input are presented with the tiles rendered so far
+		var err = RenderFrame(window, scene, camera, sampler, settings)
+		if err != nil && window.Closed() {
+			break
+		}
+
+		var picture = Buffer.Picture(toneMapper)
+		var sprite = pixel.NewSprite(picture, picture.Bounds())
+		sprite.Draw(window, pixel.IM.Moved(window.Bounds().Center()).Scaled(window.Bounds().Center(), settings.Upscale))
+
+		log.Printf("Frame time %s, %d samples, %d invalid samples discarded", time.Since(start), Buffer.MinSamples(), Buffer.InvalidSamples())
+
+		var now = time.Now()
+		if MoveCamera(window, camera, now.Sub(lastInput)) {
+			UpdateCamera(camera, settings)
+		}
+		lastInput = now
+
+		window.Update()
+	}
+}
+
+// Move the camera with the keyboard, the movement is scaled by the time elapsed since the last update.
+// Returns true if the camera was changed.
+func MoveCamera(window *pixelgl.Window, camera *camera.CameraDefocus, elapsed time.Duration) bool {
+	var speed = 1.0 * elapsed.Seconds()
+	var position = camera.Position
+	var aperture = camera.Aperture
+
+	if window.Pressed(pixelgl.KeyRight) {
+		camera.Position.X += speed
+	}
+	if window.Pressed(pixelgl.KeyLeft) {
+		camera.Position.X -= speed
+	}
+	if window.Pressed(pixelgl.KeyUp) {
+		camera.Position.Z -= speed
+	}
+	if window.Pressed(pixelgl.KeyDown) {
+		camera.Position.Z += speed
+	}
+	if window.Pressed(pixelgl.KeyLeftControl) || window.Pressed(pixelgl.KeyRightControl) {
+		camera.Position.Y -= speed
+	}
+	if window.Pressed(pixelgl.KeySpace) {
+		camera.Position.Y += speed
+	}
+	if window.Pressed(pixelgl.KeyW) {
+		camera.Aperture += 0.1
+	}
+	if window.Pressed(pixelgl.KeyS) {
+		camera.Aperture = math.Max(camera.Aperture-0.1, 0.0)
+	}
+
+	return camera.Position != position || camera.Aperture != aperture
+}
+
+// Keys that move the camera, pressing any of them cancels the frame being rendered after MovingFrameTime.
+var CameraKeys = []pixelgl.Button{pixelgl.KeyRight, pixelgl.KeyLeft, pixelgl.KeyUp, pixelgl.KeyDown, pixelgl.KeyLeftControl, pixelgl.KeyRightControl, pixelgl.KeySpace, pixelgl.KeyW, pixelgl.KeyS}
+
+// Interval between the checks of the window input while a frame is rendered.
+const InputPollInterval = 20 * time.Millisecond
+
+// Time a frame is rendered before it is cancelled by a camera key, so the window keeps showing partial frames while the camera moves.
+const MovingFrameTime = 100 * time.Millisecond
+
+// Render a frame in the background while the window input is checked, the title of the window shows the progress of the frame.
+// The frame is cancelled when the window is closed, or when a camera key is pressed after the frame was rendered for MovingFrameTime.
+// Returns the error of Render, the buffer keeps the tiles rendered before the frame was cancelled.
+func RenderFrame(window *pixelgl.Window, scene *geometry.Scene, camera *camera.CameraDefocus, sampler sampler.Sampler, settings *RenderSettings) error {
+	var ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	var start = time.Now()
+
+	var progress = make(chan Progress, 1)
+	var done = make(chan error, 1)
+
+	go func() {
+		done <- Render(ctx, Buffer, scene, camera, sampler, settings, func(p Progress) {
+			// Keep only the latest progress, the callbacks are not concurrent
+			select {
+			case <-progress:
+			default:
+			}
+			progress <- p
+		})
+	}()
+
+	var ticker = time.NewTicker(InputPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case p := <-progress:
+			window.SetTitle(fmt.Sprintf("Gotracer - %d samples, %.0f%%, %s remaining", p.Samples, p.Fraction()*100.0, p.Remaining.Round(time.Millisecond)))
+		case <-ticker.C:
+		}
+
+		window.UpdateInput()
+
+		if window.Closed() {
+			cancel()
+		}
+		for i := 0; i < len(CameraKeys) && time.Since(start) >= MovingFrameTime; i++ {
+			if window.Pressed(CameraKeys[i]) {
+				cancel()
+			}
+		}
+	}
+}
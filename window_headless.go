@@ -0,0 +1,10 @@
+//go:build headless
+
+package main
+
+import "errors"
+
+// Builds with the headless tag do not link the window library, only the render subcommand is available.
+func RunWindow(settings *RenderSettings, sceneFile string) error {
+	return errors.New("gotracer: built without the preview window (headless tag), use the render subcommand")
+}